@@ -17,12 +17,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/dapr/components-contrib/pubsub/dispatcher"
 	"github.com/dapr/kit/retry"
 )
 
@@ -34,47 +36,286 @@ type consumer struct {
 }
 
 func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
+	retryConfig, retryEnabled := consumer.k.retryConfig(claim.Topic())
+	b := retryConfig.NewBackOffWithContext(session.Context())
+	if handler, ok := consumer.k.getBulkTopicHandler(claim.Topic()); ok {
+		return consumer.consumeBulk(session, claim, handler, b, retryEnabled)
+	}
+
+	if consumer.k.keyedConcurrency {
+		return consumer.consumeKeyed(session, claim, retryConfig, retryEnabled)
+	}
+
 	for message := range claim.Messages() {
-		if consumer.k.consumeRetryEnabled {
-			if err := retry.NotifyRecover(func() error {
-				return consumer.doCallback(session, message)
-			}, b, func(err error, d time.Duration) {
-				consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
-			}, func() {
-				consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
-			}); err != nil {
-				return err
+		if err := consumer.processMessage(session, message, b, retryEnabled, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processMessage hands message to its handler, and marks it once processed
+// successfully if mark is true. The error of the message is returned once
+// the retries are exhausted, or logged when retries are disabled.
+func (consumer *consumer) processMessage(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, b backoff.BackOff, retryEnabled bool, mark bool) error {
+	if !retryEnabled {
+		err := consumer.doCallback(session, message, mark)
+		if err != nil {
+			consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+		}
+
+		return nil
+	}
+
+	return retry.NotifyRecover(func() error {
+		return consumer.doCallback(session, message, mark)
+	}, b, func(err error, d time.Duration) {
+		consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+	}, func() {
+		consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
+	})
+}
+
+// consumeKeyed processes the messages of claim in parallel, the messages with
+// the same key being processed in order. The messages are marked in the
+// order of the claim once processed, so that the committed offset never
+// skips a message which is still being processed. The claim stops at the
+// first message whose retries are exhausted.
+func (consumer *consumer) consumeKeyed(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, retryConfig retry.Config, retryEnabled bool) error {
+	// The dispatcher outlives the session, so that the dispatched messages
+	// are always accounted for in wg.
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	keyed := dispatcher.NewKeyed(dispatcherCtx, consumer.k.concurrency, consumer.k.concurrency)
+
+	ctx, cancel := context.WithCancel(session.Context())
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	tracker := &offsetTracker{session: session}
+
+consume:
+	for {
+		var message *sarama.ConsumerMessage
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				break consume
 			}
-		} else {
-			err := consumer.doCallback(session, message)
-			if err != nil {
-				consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+			message = msg
+		case <-ctx.Done():
+			break consume
+		}
+
+		tracked := tracker.add(message)
+		wg.Add(1)
+		err := keyed.Dispatch(ctx, string(message.Key), func() {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err := consumer.processMessage(session, message, retryConfig.NewBackOffWithContext(ctx), retryEnabled, false); err != nil {
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+
+				return
 			}
+			tracker.done(tracked)
+		})
+		if err != nil {
+			wg.Done()
+
+			break
 		}
 	}
+	wg.Wait()
 
-	return nil
+	return firstErr
+}
+
+// offsetTracker marks the messages of a claim in order, once all the
+// previous messages are processed.
+type offsetTracker struct {
+	session sarama.ConsumerGroupSession
+	lock    sync.Mutex
+	pending []*trackedMessage
+}
+
+type trackedMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
 }
 
-func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage) error {
+func (t *offsetTracker) add(message *sarama.ConsumerMessage) *trackedMessage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	tracked := &trackedMessage{message: message}
+	t.pending = append(t.pending, tracked)
+
+	return tracked
+}
+
+func (t *offsetTracker) done(tracked *trackedMessage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	tracked.done = true
+	n := 0
+	for n < len(t.pending) && t.pending[n].done {
+		n++
+	}
+	if n > 0 {
+		t.session.MarkMessage(t.pending[n-1].message, "")
+		t.pending = t.pending[n:]
+	}
+}
+
+// consumeBulk hands the messages of claim to handler in batches. The messages
+// of a batch which failed are retried together when retries are enabled.
+func (consumer *consumer) consumeBulk(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, handler bulkTopicHandler, b backoff.BackOff, retryEnabled bool) error {
+	messages := make([]*sarama.ConsumerMessage, 0, handler.maxMessagesCount)
+	var (
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				if len(messages) == 0 {
+					return nil
+				}
+
+				return consumer.processBulk(session, handler.handler, messages, b, retryEnabled)
+			}
+			messages = append(messages, message)
+			if len(messages) == 1 {
+				timer = time.NewTimer(handler.maxAwaitDuration)
+				timeout = timer.C
+			}
+			if len(messages) < handler.maxMessagesCount {
+				continue
+			}
+		case <-timeout:
+		case <-session.Context().Done():
+			return nil
+		}
+
+		timer.Stop()
+		timer, timeout = nil, nil
+		if err := consumer.processBulk(session, handler.handler, messages, b, retryEnabled); err != nil {
+			return err
+		}
+		messages = messages[:0]
+	}
+}
+
+func (consumer *consumer) processBulk(session sarama.ConsumerGroupSession, handler BulkEventHandler, messages []*sarama.ConsumerMessage, b backoff.BackOff, retryEnabled bool) error {
+	if !retryEnabled {
+		_, err := consumer.doBulkCallback(session, handler, messages)
+		if err != nil {
+			consumer.k.logger.Errorf("Error processing Kafka messages of topic %s: %v.", messages[0].Topic, err)
+		}
+
+		return nil
+	}
+
+	pending := messages
+
+	return retry.NotifyRecover(func() (err error) {
+		pending, err = consumer.doBulkCallback(session, handler, pending)
+
+		return err
+	}, b, func(err error, d time.Duration) {
+		consumer.k.logger.Errorf("Error processing %d Kafka messages of topic %s: %v. Retrying...", len(pending), messages[0].Topic, err)
+	}, func() {
+		consumer.k.logger.Infof("Successfully processed Kafka messages of topic %s after they previously failed", messages[0].Topic)
+	})
+}
+
+// doBulkCallback hands messages to handler and marks the messages which
+// succeeded. It returns the messages which failed and the first error.
+func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession, handler BulkEventHandler, messages []*sarama.ConsumerMessage) ([]*sarama.ConsumerMessage, error) {
+	consumer.k.logger.Debugf("Processing %d Kafka messages of topic %s", len(messages), messages[0].Topic)
+	events := make([]*NewEvent, len(messages))
+	for i, message := range messages {
+		events[i] = newEvent(message)
+	}
+
+	var (
+		failed   []*sarama.ConsumerMessage
+		firstErr error
+	)
+	for i, err := range handler(session.Context(), events) {
+		if err == nil {
+			session.MarkMessage(messages[i], "")
+
+			continue
+		}
+		failed = append(failed, messages[i])
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return failed, firstErr
+}
+
+func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, mark bool) error {
 	consumer.k.logger.Debugf("Processing Kafka message: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
 	handler, err := consumer.k.GetTopicHandler(message.Topic)
 	if err != nil {
 		return err
 	}
-	event := NewEvent{
-		Topic: message.Topic,
-		Data:  message.Value,
-	}
-	err = handler(session.Context(), &event)
-	if err == nil {
+	err = handler(session.Context(), newEvent(message))
+	if err == nil && mark {
 		session.MarkMessage(message, "")
 	}
 
 	return err
 }
 
+// newEvent returns the event of message. The headers of message are the
+// metadata of the event, along with its key, as set by Publish, and its
+// partition, offset and timestamp.
+func newEvent(message *sarama.ConsumerMessage) *NewEvent {
+	metadata := make(map[string]string, len(message.Headers)+4)
+	for _, header := range message.Headers {
+		if header != nil {
+			metadata[string(header.Key)] = string(header.Value)
+		}
+	}
+	if message.Key != nil {
+		metadata[key] = string(message.Key)
+	}
+	metadata[PartitionMetadataKey] = strconv.FormatInt(int64(message.Partition), 10)
+	metadata[OffsetMetadataKey] = strconv.FormatInt(message.Offset, 10)
+	if !message.Timestamp.IsZero() {
+		metadata[TimestampMetadataKey] = message.Timestamp.Format(time.RFC3339Nano)
+	}
+
+	return &NewEvent{
+		Topic:    message.Topic,
+		Data:     message.Value,
+		Metadata: metadata,
+	}
+}
+
 func (consumer *consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
@@ -94,11 +335,69 @@ func (k *Kafka) AddTopicHandler(topic string, handler EventHandler) {
 	k.subscribeLock.Unlock()
 }
 
+// AddBulkTopicHandler adds the handler of a topic whose events are handled
+// in batches of up to maxMessagesCount events, awaited for up to
+// maxAwaitDuration.
+func (k *Kafka) AddBulkTopicHandler(topic string, handler BulkEventHandler, maxMessagesCount int, maxAwaitDuration time.Duration) {
+	k.bulkTopicHandlersLock.Lock()
+	k.bulkTopicHandlers[topic] = bulkTopicHandler{
+		handler:          handler,
+		maxMessagesCount: maxMessagesCount,
+		maxAwaitDuration: maxAwaitDuration,
+	}
+	k.bulkTopicHandlersLock.Unlock()
+}
+
+// getBulkTopicHandler returns the handler of topic if its events are handled in batches.
+func (k *Kafka) getBulkTopicHandler(topic string) (bulkTopicHandler, bool) {
+	k.bulkTopicHandlersLock.RLock()
+	defer k.bulkTopicHandlersLock.RUnlock()
+
+	handler, ok := k.bulkTopicHandlers[topic]
+
+	return handler, ok
+}
+
 // RemoveTopicHandler removes a topic handler
 func (k *Kafka) RemoveTopicHandler(topic string) {
 	k.subscribeLock.Lock()
 	delete(k.subscribeTopics, topic)
 	k.subscribeLock.Unlock()
+
+	k.bulkTopicHandlersLock.Lock()
+	delete(k.bulkTopicHandlers, topic)
+	k.bulkTopicHandlersLock.Unlock()
+
+	k.retryConfigsLock.Lock()
+	delete(k.topicRetryConfigs, topic)
+	k.retryConfigsLock.Unlock()
+}
+
+// SetTopicRetryConfig sets the retry policy of the messages of topic, which
+// overrides the policy configured with the backOff metadata. The messages of
+// topic are retried even if consumeRetryEnabled is false.
+func (k *Kafka) SetTopicRetryConfig(topic string, config retry.Config) {
+	k.retryConfigsLock.Lock()
+	k.topicRetryConfigs[topic] = config
+	k.retryConfigsLock.Unlock()
+}
+
+// BackOffConfig returns the retry policy configured with the backOff metadata.
+func (k *Kafka) BackOffConfig() retry.Config {
+	return k.backOffConfig
+}
+
+// retryConfig returns the retry policy of the messages of topic, and whether
+// they are retried.
+func (k *Kafka) retryConfig(topic string) (retry.Config, bool) {
+	k.retryConfigsLock.RLock()
+	defer k.retryConfigsLock.RUnlock()
+
+	if config, ok := k.topicRetryConfigs[topic]; ok {
+		return config, true
+	}
+
+	return k.backOffConfig, k.consumeRetryEnabled
 }
 
 // GetTopicHandler returns the handler for a topic
@@ -124,6 +423,13 @@ func (k *Kafka) Subscribe(ctx context.Context) error {
 	k.closeSubscriptionResources()
 
 	topics := k.subscribeTopics.TopicList()
+	k.bulkTopicHandlersLock.RLock()
+	for topic := range k.bulkTopicHandlers {
+		if _, ok := k.subscribeTopics[topic]; !ok {
+			topics = append(topics, topic)
+		}
+	}
+	k.bulkTopicHandlersLock.RUnlock()
 	if len(topics) == 0 {
 		// Nothing to subscribe to
 		return nil
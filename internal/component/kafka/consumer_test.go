@@ -0,0 +1,230 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/retry"
+)
+
+// fakeSession records the marked offsets.
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	topic    string
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string {
+	return c.topic
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+func TestConsumeBulk(t *testing.T) {
+	newClaim := func(count int) *fakeClaim {
+		claim := &fakeClaim{topic: "orders", messages: make(chan *sarama.ConsumerMessage, count)}
+		for i := 0; i < count; i++ {
+			claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: int64(i), Value: []byte{byte('a' + i)}}
+		}
+		close(claim.messages)
+
+		return claim
+	}
+
+	t.Run("batches and marks the messages", func(t *testing.T) {
+		k := NewKafka(getKafka().logger)
+		var batches [][]string
+		k.AddBulkTopicHandler("orders", func(ctx context.Context, events []*NewEvent) []error {
+			batch := make([]string, len(events))
+			errs := make([]error, len(events))
+			for i, event := range events {
+				batch[i] = string(event.Data)
+				if event.Data[0] == 'b' {
+					errs[i] = errors.New("handler error")
+				}
+			}
+			batches = append(batches, batch)
+
+			return errs
+		}, 2, time.Minute)
+
+		session := &fakeSession{ctx: context.Background()}
+		c := consumer{k: k}
+		require.NoError(t, c.ConsumeClaim(session, newClaim(3)))
+		assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, batches)
+		assert.Equal(t, []int64{0, 2}, session.marked)
+	})
+
+	t.Run("retries the failed messages", func(t *testing.T) {
+		k := NewKafka(getKafka().logger)
+		config := retry.DefaultConfig()
+		config.Duration = time.Millisecond
+		config.MaxRetries = 3
+		k.SetTopicRetryConfig("orders", config)
+		var batches [][]string
+		k.AddBulkTopicHandler("orders", func(ctx context.Context, events []*NewEvent) []error {
+			batch := make([]string, len(events))
+			errs := make([]error, len(events))
+			for i, event := range events {
+				batch[i] = string(event.Data)
+				if event.Data[0] == 'b' && len(batches) == 0 {
+					errs[i] = errors.New("handler error")
+				}
+			}
+			batches = append(batches, batch)
+
+			return errs
+		}, 10, 10*time.Millisecond)
+
+		session := &fakeSession{ctx: context.Background()}
+		c := consumer{k: k}
+		require.NoError(t, c.ConsumeClaim(session, newClaim(3)))
+		assert.Equal(t, [][]string{{"a", "b", "c"}, {"b"}}, batches)
+		assert.Equal(t, []int64{0, 2, 1}, session.marked)
+	})
+}
+
+func TestConsumeKeyed(t *testing.T) {
+	newClaim := func(count int) *fakeClaim {
+		claim := &fakeClaim{topic: "orders", messages: make(chan *sarama.ConsumerMessage, count)}
+		for i := 0; i < count; i++ {
+			claim.messages <- &sarama.ConsumerMessage{
+				Topic:  "orders",
+				Key:    []byte(fmt.Sprintf("key-%d", i%3)),
+				Offset: int64(i),
+				Value:  []byte(fmt.Sprintf("%02d", i)),
+			}
+		}
+		close(claim.messages)
+
+		return claim
+	}
+	newKeyedKafka := func() *Kafka {
+		k := NewKafka(getKafka().logger)
+		k.keyedConcurrency = true
+		k.concurrency = 4
+
+		return k
+	}
+
+	t.Run("processes the messages of a key in order", func(t *testing.T) {
+		k := newKeyedKafka()
+		var (
+			lock      sync.Mutex
+			processed = map[string][]string{}
+		)
+		k.AddTopicHandler("orders", func(ctx context.Context, event *NewEvent) error {
+			time.Sleep(time.Duration(event.Data[1]%3) * time.Millisecond)
+			lock.Lock()
+			defer lock.Unlock()
+			key := event.Metadata[key]
+			processed[key] = append(processed[key], string(event.Data))
+
+			return nil
+		})
+
+		session := &fakeSession{ctx: context.Background()}
+		c := consumer{k: k}
+		require.NoError(t, c.ConsumeClaim(session, newClaim(30)))
+
+		require.Len(t, processed, 3)
+		for key, data := range processed {
+			require.Len(t, data, 10, key)
+			assert.IsIncreasing(t, data, key)
+		}
+		require.NotEmpty(t, session.marked)
+		assert.IsIncreasing(t, session.marked)
+		assert.Equal(t, int64(29), session.marked[len(session.marked)-1])
+	})
+
+	t.Run("stops at a message whose retries are exhausted", func(t *testing.T) {
+		k := newKeyedKafka()
+		config := retry.DefaultConfig()
+		config.Duration = time.Millisecond
+		config.MaxRetries = 1
+		k.SetTopicRetryConfig("orders", config)
+		k.AddTopicHandler("orders", func(ctx context.Context, event *NewEvent) error {
+			if string(event.Data) == "05" {
+				return errors.New("handler error")
+			}
+
+			return nil
+		})
+
+		session := &fakeSession{ctx: context.Background()}
+		c := consumer{k: k}
+		assert.EqualError(t, c.ConsumeClaim(session, newClaim(30)), "handler error")
+		for _, offset := range session.marked {
+			assert.Less(t, offset, int64(5))
+		}
+	})
+}
+
+func TestNewEvent(t *testing.T) {
+	timestamp := time.Date(2022, 3, 1, 10, 0, 0, 500, time.UTC)
+	event := newEvent(&sarama.ConsumerMessage{
+		Topic:     "orders",
+		Key:       []byte("order-1"),
+		Value:     []byte("data"),
+		Partition: 2,
+		Offset:    42,
+		Timestamp: timestamp,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("traceparent"), Value: []byte("00-1-2-01")},
+			{Key: []byte(OffsetMetadataKey), Value: []byte("spoofed")},
+		},
+	})
+
+	assert.Equal(t, "orders", event.Topic)
+	assert.Equal(t, []byte("data"), event.Data)
+	assert.Equal(t, map[string]string{
+		"traceparent":        "00-1-2-01",
+		key:                  "order-1",
+		PartitionMetadataKey: "2",
+		OffsetMetadataKey:    "42",
+		TimestampMetadataKey: "2022-03-01T10:00:00.0000005Z",
+	}, event.Metadata)
+
+	event = newEvent(&sarama.ConsumerMessage{Topic: "orders", Value: []byte("data")})
+	assert.Equal(t, map[string]string{
+		PartitionMetadataKey: "0",
+		OffsetMetadataKey:    "0",
+	}, event.Metadata)
+}
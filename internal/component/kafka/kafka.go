@@ -40,21 +40,36 @@ type Kafka struct {
 	config          *sarama.Config
 	subscribeTopics TopicHandlers
 	subscribeLock   sync.Mutex
+	// bulkTopicHandlers holds the handlers of the topics whose events are handled in batches.
+	// It is read by the consumer while Subscribe holds subscribeLock, so it has its own lock.
+	bulkTopicHandlers     map[string]bulkTopicHandler
+	bulkTopicHandlersLock sync.RWMutex
 
 	backOffConfig retry.Config
+	// topicRetryConfigs holds the retry policies of the topics which override backOffConfig.
+	// It is read by the consumer while Subscribe holds subscribeLock, so it has its own lock.
+	topicRetryConfigs map[string]retry.Config
+	retryConfigsLock  sync.RWMutex
 
 	// The default value should be true for kafka pubsub component and false for kafka binding component
 	// This default value can be overridden by metadata consumeRetryEnabled
 	DefaultConsumeRetryEnabled bool
 	consumeRetryEnabled        bool
 	consumeRetryInterval       time.Duration
+
+	// In the keyed concurrency mode, the messages of a partition are processed
+	// in parallel by concurrency workers, in order for a given key.
+	keyedConcurrency bool
+	concurrency      int
 }
 
 func NewKafka(logger logger.Logger) *Kafka {
 	return &Kafka{
-		logger:          logger,
-		subscribeTopics: make(TopicHandlers),
-		subscribeLock:   sync.Mutex{},
+		logger:            logger,
+		subscribeTopics:   make(TopicHandlers),
+		subscribeLock:     sync.Mutex{},
+		bulkTopicHandlers: make(map[string]bulkTopicHandler),
+		topicRetryConfigs: make(map[string]retry.Config),
 	}
 }
 
@@ -126,6 +141,8 @@ func (k *Kafka) Init(metadata map[string]string) error {
 	}
 	k.consumeRetryEnabled = meta.ConsumeRetryEnabled
 	k.consumeRetryInterval = meta.ConsumeRetryInterval
+	k.keyedConcurrency = meta.KeyedConcurrency
+	k.concurrency = meta.Concurrency
 
 	k.logger.Debug("Kafka message bus initialization complete")
 
@@ -146,6 +163,25 @@ func (k *Kafka) Close() (err error) {
 // EventHandler is the handler used to handle the subscribed event.
 type EventHandler func(ctx context.Context, msg *NewEvent) error
 
+// BulkEventHandler is the handler used to handle a batch of subscribed events.
+// It returns the error of each event, by index.
+type BulkEventHandler func(ctx context.Context, events []*NewEvent) []error
+
+// bulkTopicHandler is the handler of a topic whose events are handled in
+// batches of up to maxMessagesCount events, awaited for up to maxAwaitDuration.
+type bulkTopicHandler struct {
+	handler          BulkEventHandler
+	maxMessagesCount int
+	maxAwaitDuration time.Duration
+}
+
+// The metadata of the subscribed events holding the coordinates of their record.
+const (
+	PartitionMetadataKey = "__partition"
+	OffsetMetadataKey    = "__offset"
+	TimestampMetadataKey = "__timestamp"
+)
+
 // NewEvent is an event arriving from a message bus instance.
 type NewEvent struct {
 	Data        []byte            `json:"data"`
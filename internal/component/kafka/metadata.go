@@ -31,6 +31,10 @@ const (
 	clientKey            = "clientKey"
 	consumeRetryEnabled  = "consumeRetryEnabled"
 	consumeRetryInterval = "consumeRetryInterval"
+	concurrency          = "concurrency"
+	concurrencyMode      = "concurrencyMode"
+	// keyedConcurrencyMode is the keyed concurrency mode of the pub sub components.
+	keyedConcurrencyMode = "keyed"
 	authType             = "authType"
 	passwordAuthType     = "password"
 	oidcAuthType         = "oidc"
@@ -59,6 +63,8 @@ type kafkaMetadata struct {
 	ConsumeRetryEnabled  bool
 	ConsumeRetryInterval time.Duration
 	Version              sarama.KafkaVersion
+	KeyedConcurrency     bool
+	Concurrency          int
 }
 
 // upgradeMetadata updates metadata properties based on deprecated usage.
@@ -101,6 +107,7 @@ func (k *Kafka) upgradeMetadata(metadata map[string]string) (map[string]string,
 func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, error) {
 	meta := kafkaMetadata{
 		ConsumeRetryInterval: 100 * time.Millisecond,
+		Concurrency:          10,
 	}
 	// use the runtimeConfig.ID as the consumer group so that each dapr runtime creates its own consumergroup
 	if val, ok := metadata["consumerID"]; ok && val != "" {
@@ -265,6 +272,16 @@ func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, er
 		meta.ConsumeRetryInterval = durationVal
 	}
 
+	meta.KeyedConcurrency = metadata[concurrencyMode] == keyedConcurrencyMode
+
+	if val, ok := metadata[concurrency]; ok && val != "" {
+		intVal, err := strconv.Atoi(val)
+		if err != nil || intVal < 1 {
+			return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: %s", concurrency, val)
+		}
+		meta.Concurrency = intVal
+	}
+
 	if val, ok := metadata["version"]; ok && val != "" {
 		version, err := sarama.ParseKafkaVersion(val)
 		if err != nil {
@@ -73,6 +73,25 @@ func TestParseMetadata(t *testing.T) {
 		require.Equal(t, sarama.V0_10_2_0, meta.Version)
 	})
 
+	t.Run("keyed concurrency mode", func(t *testing.T) {
+		m := getCompleteMetadata()
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.False(t, meta.KeyedConcurrency)
+		require.Equal(t, 10, meta.Concurrency)
+
+		m["concurrencyMode"] = "keyed"
+		m["concurrency"] = "4"
+		meta, err = k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.True(t, meta.KeyedConcurrency)
+		require.Equal(t, 4, meta.Concurrency)
+
+		m["concurrency"] = "0"
+		_, err = k.getKafkaMetadata(m)
+		require.Error(t, err)
+	})
+
 	t.Run("invalid kafka version", func(t *testing.T) {
 		m := getCompleteMetadata()
 		m["version"] = "not_valid_version"
@@ -45,6 +45,60 @@ func (k *Kafka) Publish(topic string, data []byte, metadata map[string]string) e
 	// k.logger.Debugf("Publishing topic %v with data: %v", topic, string(data))
 	k.logger.Debugf("Publishing on topic %v", topic)
 
+	msg := producerMessage(topic, data, metadata)
+
+	partition, offset, err := k.producer.SendMessage(msg)
+
+	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BulkMessage is a message published with BulkPublish.
+type BulkMessage struct {
+	Data     []byte
+	Metadata map[string]string
+}
+
+// BulkPublish publishes messages to topic in a single request to the Kafka
+// cluster. The errors of the messages which could not be published are
+// returned by the index of the message.
+func (k *Kafka) BulkPublish(topic string, messages []BulkMessage) (map[int]error, error) {
+	if k.producer == nil {
+		return nil, errors.New("component is closed")
+	}
+	k.logger.Debugf("Publishing %d messages on topic %v", len(messages), topic)
+
+	msgs := make([]*sarama.ProducerMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = producerMessage(topic, m.Data, m.Metadata)
+		msgs[i].Metadata = i
+	}
+
+	err := k.producer.SendMessages(msgs)
+	if err == nil {
+		return nil, nil
+	}
+
+	var producerErrs sarama.ProducerErrors
+	if !errors.As(err, &producerErrs) {
+		return nil, err
+	}
+	errs := make(map[int]error, len(producerErrs))
+	for _, producerErr := range producerErrs {
+		errs[producerErr.Msg.Metadata.(int)] = producerErr.Err
+	}
+
+	return errs, nil
+}
+
+// producerMessage builds the message publishing data to topic. The key
+// metadata is the key of the message and the other metadata are its headers.
+func producerMessage(topic string, data []byte, metadata map[string]string) *sarama.ProducerMessage {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(data),
@@ -64,13 +118,5 @@ func (k *Kafka) Publish(topic string, data []byte, metadata map[string]string) e
 		}
 	}
 
-	partition, offset, err := k.producer.SendMessage(msg)
-
-	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return msg
 }
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSyncProducer records the sent messages and fails the messages whose
+// value is "fail".
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+	sent []*sarama.ProducerMessage
+}
+
+func (p *fakeSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		if value, _ := msg.Value.Encode(); string(value) == "fail" {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: errors.New("send error")})
+		} else {
+			p.sent = append(p.sent, msg)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func TestBulkPublish(t *testing.T) {
+	producer := &fakeSyncProducer{}
+	k := getKafka()
+	k.producer = producer
+
+	errs, err := k.BulkPublish("orders", []BulkMessage{
+		{Data: []byte("first"), Metadata: map[string]string{key: "k1", "header": "value"}},
+		{Data: []byte("fail")},
+		{Data: []byte("third")},
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[1], "send error")
+
+	require.Len(t, producer.sent, 2)
+	assert.Equal(t, "orders", producer.sent[0].Topic)
+	assert.Equal(t, sarama.StringEncoder("k1"), producer.sent[0].Key)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("header"), Value: []byte("value")}}, producer.sent[0].Headers)
+	assert.Equal(t, sarama.ByteEncoder("third"), producer.sent[1].Value)
+
+	k.producer = nil
+	_, err = k.BulkPublish("orders", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrations runs the versioned schema migrations of the components
+// backed by PostgreSQL compatible databases.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dapr/kit/logger"
+)
+
+// DefaultMetadataTableName is the default name of the table holding the schema versions.
+const DefaultMetadataTableName = "dapr_metadata"
+
+// Migration is an up-step which upgrades the schema to the next version.
+type Migration struct {
+	// Description is logged when the migration runs.
+	Description string
+	// Up applies the migration in the transaction of the runner.
+	Up func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Locker acquires a lock on key which is held until the transaction ends.
+type Locker func(ctx context.Context, tx *sql.Tx, key string) error
+
+// Options configures the migration runner.
+type Options struct {
+	Logger logger.Logger
+	// MetadataTableName is the table holding the schema version of each key.
+	// It is created if it does not exist. It defaults to DefaultMetadataTableName.
+	MetadataTableName string
+	// Key identifies the schema being migrated in the metadata table, e.g. the
+	// name of a state table.
+	Key string
+	// Lock serializes the runners of concurrent component instances.
+	// If it is nil, the runners rely on the isolation of the transaction.
+	Lock Locker
+}
+
+// PostgresAdvisoryLock acquires a transaction level advisory lock on key.
+func PostgresAdvisoryLock(ctx context.Context, tx *sql.Tx, key string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key)
+
+	return err
+}
+
+// Migrate applies, in order, the migrations which have not been applied yet.
+// The version of a schema is the number of migrations applied to it, and the
+// migrations must never be reordered or removed once released.
+// All the migrations run in a single transaction, so that a failed migration
+// leaves the schema unchanged.
+func Migrate(ctx context.Context, db *sql.DB, opts Options, migrations []Migration) error {
+	if opts.Key == "" {
+		return errors.New("migrations: missing key")
+	}
+	metadataTable := opts.MetadataTableName
+	if metadataTable == "" {
+		metadataTable = DefaultMetadataTableName
+	}
+	versionKey := "migrations-" + opts.Key
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if opts.Lock != nil {
+		if err = opts.Lock(ctx, tx, metadataTable+":"+versionKey); err != nil {
+			return fmt.Errorf("migrations: error acquiring the lock: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key text NOT NULL PRIMARY KEY,
+		value text NOT NULL)`, metadataTable))
+	if err != nil {
+		return fmt.Errorf("migrations: error creating the metadata table: %w", err)
+	}
+
+	version, err := currentVersion(ctx, tx, metadataTable, versionKey)
+	if err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		opts.Logger.Warnf("The schema of %s is at version %d, which is newer than the latest version %d known to this component", opts.Key, version, len(migrations))
+
+		return nil
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	for i := version; i < len(migrations); i++ {
+		opts.Logger.Infof("Migrating the schema of %s to version %d: %s", opts.Key, i+1, migrations[i].Description)
+		if err = migrations[i].Up(ctx, tx); err != nil {
+			return fmt.Errorf("migrations: error migrating %s to version %d: %w", opts.Key, i+1, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, metadataTable), versionKey, strconv.Itoa(len(migrations)))
+	if err != nil {
+		return fmt.Errorf("migrations: error saving the schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func currentVersion(ctx context.Context, tx *sql.Tx, metadataTable string, versionKey string) (int, error) {
+	var value string
+	err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT value FROM %s WHERE key = $1 FOR UPDATE", metadataTable), versionKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("migrations: error reading the schema version: %w", err)
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("migrations: invalid schema version %q: %w", value, err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func testMigrations(applied *[]int) []Migration {
+	migrations := make([]Migration, 3)
+	for i := range migrations {
+		version := i + 1
+		migrations[i] = Migration{
+			Description: "test",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				*applied = append(*applied, version)
+				_, err := tx.ExecContext(ctx, "ALTER TABLE state")
+
+				return err
+			},
+		}
+	}
+
+	return migrations
+}
+
+func TestMigrate(t *testing.T) {
+	opts := Options{
+		Logger: logger.NewLogger("test"),
+		Key:    "state",
+		Lock:   PostgresAdvisoryLock,
+	}
+
+	t.Run("applies the pending migrations in order", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).WithArgs("dapr_metadata:migrations-state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT value FROM dapr_metadata WHERE key = \$1 FOR UPDATE`).WithArgs("migrations-state").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO dapr_metadata").WithArgs("migrations-state", "3").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		var applied []int
+		err = Migrate(context.Background(), db, opts, testMigrations(&applied))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2, 3}, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new schema", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT value FROM dapr_metadata").WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO dapr_metadata").WithArgs("migrations-state", "3").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		var applied []int
+		err = Migrate(context.Background(), db, opts, testMigrations(&applied))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS custom_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT value FROM custom_metadata").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("3"))
+		mock.ExpectRollback()
+
+		var applied []int
+		err = Migrate(context.Background(), db, Options{
+			Logger:            opts.Logger,
+			MetadataTableName: "custom_metadata",
+			Key:               "state",
+		}, testMigrations(&applied))
+
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed migration", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT value FROM dapr_metadata").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("2"))
+		mock.ExpectExec("ALTER TABLE state").WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		var applied []int
+		err = Migrate(context.Background(), db, opts, testMigrations(&applied))
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
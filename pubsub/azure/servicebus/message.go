@@ -199,6 +199,14 @@ func NewASBMessageFromPubsubRequest(req *pubsub.PublishRequest) (*azservicebus.M
 	scheduledEnqueueTime, ok, _ := tryGetScheduledEnqueueTime(req.Metadata)
 	if ok {
 		asbMsg.ScheduledEnqueueTime = scheduledEnqueueTime
+	} else {
+		deliveryTime, ok, err := pubsub.DeliveryTime(req.Metadata, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			asbMsg.ScheduledEnqueueTime = &deliveryTime
+		}
 	}
 
 	return asbMsg, nil
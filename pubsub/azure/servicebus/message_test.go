@@ -103,6 +103,30 @@ func TestNewASBMessageFromPubsubRequest(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Maps scheduled delivery time to scheduled enqueue time.",
+			pubsubRequest: pubsub.PublishRequest{
+				Data: testMessageData,
+				Metadata: map[string]string{
+					pubsub.ScheduledDeliveryTimeKey: nowUtc.Format(time.RFC3339),
+				},
+			},
+			expectedAzServiceBusMessage: azservicebus.Message{
+				Body:                 testMessageData,
+				ScheduledEnqueueTime: &nowUtc,
+			},
+			expectError: false,
+		},
+		{
+			name: "Errors when deliver after is invalid.",
+			pubsubRequest: pubsub.PublishRequest{
+				Data: testMessageData,
+				Metadata: map[string]string{
+					pubsub.DeliverAfterKey: "later",
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -71,7 +71,7 @@ type azureServiceBus struct {
 func NewAzureServiceBus(logger logger.Logger) pubsub.PubSub {
 	return &azureServiceBus{
 		logger:     logger,
-		features:   []pubsub.Feature{pubsub.FeatureMessageTTL},
+		features:   []pubsub.Feature{pubsub.FeatureMessageTTL, pubsub.FeatureDelayedDelivery},
 		topics:     map[string]*servicebus.Sender{},
 		topicsLock: &sync.RWMutex{},
 	}
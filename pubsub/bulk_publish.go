@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import "fmt"
+
+// BulkPublisher is the interface of the components which publish several
+// messages to a topic in a single request to the broker.
+type BulkPublisher interface {
+	// BulkPublish publishes the entries of req and returns the outcome of each
+	// entry. The error is not nil if at least one entry could not be published.
+	BulkPublish(req *BulkPublishRequest) (BulkPublishResponse, error)
+}
+
+// DefaultBulkPublisher is a default implementation of BulkPublisher which
+// publishes the entries one by one.
+type DefaultBulkPublisher struct {
+	p PubSub
+}
+
+// NewDefaultBulkPublisher builds a default bulk publisher for pubsub.
+func NewDefaultBulkPublisher(pubsub PubSub) DefaultBulkPublisher {
+	return DefaultBulkPublisher{p: pubsub}
+}
+
+// BulkPublish publishes the entries of req one by one. All the entries are
+// attempted even if some of them fail.
+func (b *DefaultBulkPublisher) BulkPublish(req *BulkPublishRequest) (BulkPublishResponse, error) {
+	errs := make([]error, len(req.Entries))
+	for i := range req.Entries {
+		errs[i] = b.p.Publish(req.PublishRequest(&req.Entries[i]))
+	}
+
+	return NewBulkPublishResponse(req.Entries, errs)
+}
+
+// BulkPublish publishes the entries of req with pubsub, in a single request if
+// it implements BulkPublisher, or one by one otherwise.
+func BulkPublish(pubsub PubSub, req *BulkPublishRequest) (BulkPublishResponse, error) {
+	if bulkPublisher, ok := pubsub.(BulkPublisher); ok {
+		return bulkPublisher.BulkPublish(req)
+	}
+	b := NewDefaultBulkPublisher(pubsub)
+
+	return b.BulkPublish(req)
+}
+
+// PublishRequest returns the request publishing entry on its own, with the
+// metadata of the request and of the entry.
+func (req *BulkPublishRequest) PublishRequest(entry *BulkMessageEntry) *PublishRequest {
+	return &PublishRequest{
+		Data:        entry.Event,
+		PubsubName:  req.PubsubName,
+		Topic:       req.Topic,
+		Metadata:    req.EntryMetadata(entry),
+		ContentType: entry.ContentType,
+	}
+}
+
+// EntryMetadata returns the metadata of the request merged with the metadata
+// of entry, which takes precedence.
+func (req *BulkPublishRequest) EntryMetadata(entry *BulkMessageEntry) map[string]string {
+	if len(req.Metadata) == 0 {
+		return entry.Metadata
+	}
+	if len(entry.Metadata) == 0 {
+		return req.Metadata
+	}
+
+	metadata := make(map[string]string, len(req.Metadata)+len(entry.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range entry.Metadata {
+		metadata[k] = v
+	}
+
+	return metadata
+}
+
+// NewBulkPublishResponse builds the response of a bulk publish request from
+// the error of each entry, errs[i] being the error of entries[i]. The returned
+// error is not nil if at least one entry failed.
+func NewBulkPublishResponse(entries []BulkMessageEntry, errs []error) (BulkPublishResponse, error) {
+	res := BulkPublishResponse{Statuses: make([]BulkPublishResponseEntry, len(entries))}
+	var firstErr error
+	failed := 0
+	for i, entry := range entries {
+		res.Statuses[i] = BulkPublishResponseEntry{EntryID: entry.EntryID, Status: PublishSucceeded}
+		if i < len(errs) && errs[i] != nil {
+			res.Statuses[i].Status = PublishFailed
+			res.Statuses[i].Error = errs[i]
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return res, fmt.Errorf("%d of %d entries could not be published: %w", failed, len(entries), firstErr)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPubSub records the published messages and fails the messages
+// whose data is "fail".
+type recordingPubSub struct {
+	published []*PublishRequest
+}
+
+func (p *recordingPubSub) Init(metadata Metadata) error {
+	return nil
+}
+
+func (p *recordingPubSub) Features() []Feature {
+	return nil
+}
+
+func (p *recordingPubSub) Publish(req *PublishRequest) error {
+	if string(req.Data) == "fail" {
+		return errors.New("publish error")
+	}
+	p.published = append(p.published, req)
+
+	return nil
+}
+
+func (p *recordingPubSub) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	return nil
+}
+
+func (p *recordingPubSub) Close() error {
+	return nil
+}
+
+func TestBulkPublish(t *testing.T) {
+	p := &recordingPubSub{}
+	req := &BulkPublishRequest{
+		PubsubName: "pubsub",
+		Topic:      "orders",
+		Metadata:   map[string]string{"ttlInSeconds": "10", "key": "request"},
+		Entries: []BulkMessageEntry{
+			{EntryID: "1", Event: []byte("first")},
+			{EntryID: "2", Event: []byte("fail")},
+			{EntryID: "3", Event: []byte("third"), Metadata: map[string]string{"key": "entry"}},
+		},
+	}
+
+	res, err := BulkPublish(p, req)
+	assert.ErrorContains(t, err, "1 of 3 entries")
+	require.Len(t, res.Statuses, 3)
+	assert.Equal(t, BulkPublishResponseEntry{EntryID: "1", Status: PublishSucceeded}, res.Statuses[0])
+	assert.Equal(t, "2", res.Statuses[1].EntryID)
+	assert.Equal(t, PublishFailed, res.Statuses[1].Status)
+	assert.EqualError(t, res.Statuses[1].Error, "publish error")
+	assert.Equal(t, PublishSucceeded, res.Statuses[2].Status)
+
+	require.Len(t, p.published, 2)
+	assert.Equal(t, "orders", p.published[0].Topic)
+	assert.Equal(t, "pubsub", p.published[0].PubsubName)
+	assert.Equal(t, req.Metadata, p.published[0].Metadata)
+	assert.Equal(t, map[string]string{"ttlInSeconds": "10", "key": "entry"}, p.published[1].Metadata)
+}
+
+func TestNewBulkPublishResponse(t *testing.T) {
+	entries := []BulkMessageEntry{{EntryID: "1"}, {EntryID: "2"}}
+
+	res, err := NewBulkPublishResponse(entries, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []BulkPublishResponseEntry{
+		{EntryID: "1", Status: PublishSucceeded},
+		{EntryID: "2", Status: PublishSucceeded},
+	}, res.Statuses)
+
+	res, err = NewBulkPublishResponse(entries, []error{nil, errors.New("publish error")})
+	assert.ErrorContains(t, err, "publish error")
+	assert.Equal(t, PublishSucceeded, res.Statuses[0].Status)
+	assert.Equal(t, PublishFailed, res.Statuses[1].Status)
+}
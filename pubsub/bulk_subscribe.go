@@ -0,0 +1,265 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// BulkSubscribeMaxMessagesCountKey is the subscribe metadata key of the
+	// maximum number of messages delivered to a bulk handler at once.
+	BulkSubscribeMaxMessagesCountKey = "maxMessagesCount"
+	// BulkSubscribeMaxAwaitDurationKey is the subscribe metadata key of the
+	// maximum time to wait for a batch to fill up before it is delivered, in
+	// milliseconds or as a duration.
+	BulkSubscribeMaxAwaitDurationKey = "maxAwaitDuration"
+
+	defaultBulkSubscribeMaxMessagesCount = 100
+	defaultBulkSubscribeMaxAwaitDuration = time.Second
+)
+
+// BulkMessage is a batch of messages of a topic delivered to a BulkHandler.
+type BulkMessage struct {
+	Entries  []BulkMessageEntry `json:"entries"`
+	Topic    string             `json:"topic"`
+	Metadata map[string]string  `json:"metadata"`
+}
+
+// BulkSubscribeResponseEntry is the outcome of the processing of an entry of
+// a BulkMessage. The entry is acknowledged if Error is nil.
+type BulkSubscribeResponseEntry struct {
+	EntryID string `json:"entryId"`
+	Error   error  `json:"-"`
+}
+
+// BulkHandler is the handler used to invoke the app handler with a batch of
+// messages. It returns the outcome of the entries, the entries without
+// outcome being acknowledged if the returned error is nil.
+type BulkHandler func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error)
+
+// BulkSubscriber is the interface of the components which deliver batches
+// of messages natively.
+type BulkSubscriber interface {
+	BulkSubscribe(ctx context.Context, req SubscribeRequest, handler BulkHandler) error
+}
+
+// BulkSubscribeConfig is the batching configured in the subscribe metadata.
+type BulkSubscribeConfig struct {
+	MaxMessagesCount int
+	MaxAwaitDuration time.Duration
+}
+
+// NewBulkSubscribeConfig returns the batching configured in the subscribe
+// metadata, i.e. batches of up to 100 messages awaited for up to 1 second
+// by default.
+func NewBulkSubscribeConfig(metadata map[string]string) (BulkSubscribeConfig, error) {
+	config := BulkSubscribeConfig{
+		MaxMessagesCount: defaultBulkSubscribeMaxMessagesCount,
+		MaxAwaitDuration: defaultBulkSubscribeMaxAwaitDuration,
+	}
+
+	if val, ok := metadata[BulkSubscribeMaxMessagesCountKey]; ok && val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return config, fmt.Errorf("invalid %s %s", BulkSubscribeMaxMessagesCountKey, val)
+		}
+		config.MaxMessagesCount = n
+	}
+
+	if val, ok := metadata[BulkSubscribeMaxAwaitDurationKey]; ok && val != "" {
+		if ms, err := strconv.ParseUint(val, 10, 64); err == nil {
+			config.MaxAwaitDuration = time.Duration(ms) * time.Millisecond
+		} else if d, err := time.ParseDuration(val); err == nil {
+			config.MaxAwaitDuration = d
+		} else {
+			return config, fmt.Errorf("invalid %s %s", BulkSubscribeMaxAwaitDurationKey, val)
+		}
+		if config.MaxAwaitDuration <= 0 {
+			return config, fmt.Errorf("invalid %s %s", BulkSubscribeMaxAwaitDurationKey, val)
+		}
+	}
+
+	return config, nil
+}
+
+// BulkEntryErrors returns the error of each entry of msg, by index, from the
+// outcome returned by a BulkHandler.
+func BulkEntryErrors(msg *BulkMessage, statuses []BulkSubscribeResponseEntry, err error) []error {
+	errs := make([]error, len(msg.Entries))
+	byID := make(map[string]BulkSubscribeResponseEntry, len(statuses))
+	for _, status := range statuses {
+		byID[status.EntryID] = status
+	}
+	for i, entry := range msg.Entries {
+		if status, ok := byID[entry.EntryID]; ok {
+			errs[i] = status.Error
+		} else {
+			errs[i] = err
+		}
+	}
+
+	return errs
+}
+
+// BulkSubscribe subscribes handler to the topic of req with pubsub, natively
+// if it implements BulkSubscriber, or by buffering the messages delivered
+// one by one otherwise. The buffered messages are only batched together if
+// the component delivers them concurrently, while a batch is being processed.
+func BulkSubscribe(ctx context.Context, pubsub PubSub, req SubscribeRequest, handler BulkHandler) error {
+	if bulkSubscriber, ok := pubsub.(BulkSubscriber); ok {
+		return bulkSubscriber.BulkSubscribe(ctx, req, handler)
+	}
+
+	config, err := NewBulkSubscribeConfig(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return pubsub.Subscribe(ctx, req, NewBufferingHandler(ctx, req.Topic, handler, config))
+}
+
+// bufferedEntry is a message waiting for its batch to be delivered.
+type bufferedEntry struct {
+	entry BulkMessageEntry
+	done  chan error
+}
+
+// bufferingHandler buffers the messages delivered one by one to deliver
+// them in batches to a BulkHandler.
+type bufferingHandler struct {
+	ctx     context.Context
+	topic   string
+	handler BulkHandler
+	config  BulkSubscribeConfig
+
+	lock    sync.Mutex
+	pending []*bufferedEntry
+	timer   *time.Timer
+	// delivering is the number of batches being processed.
+	delivering int
+}
+
+// NewBufferingHandler returns a Handler which delivers the messages to
+// handler in batches as configured by config. The messages are delivered at
+// once if no batch is being processed, so that the components delivering the
+// messages one at a time are not slowed down, and are otherwise buffered until
+// the batch is full, the maximum await duration is elapsed or no batch is
+// being processed anymore. The returned handler blocks until the batch of the
+// message is processed, and returns the error of the message, so that the
+// message is acknowledged or not to the broker.
+func NewBufferingHandler(ctx context.Context, topic string, handler BulkHandler, config BulkSubscribeConfig) Handler {
+	h := &bufferingHandler{
+		ctx:     ctx,
+		topic:   topic,
+		handler: handler,
+		config:  config,
+	}
+
+	return h.handle
+}
+
+func (h *bufferingHandler) handle(ctx context.Context, msg *NewMessage) error {
+	buffered := &bufferedEntry{
+		entry: BulkMessageEntry{
+			EntryID:     uuid.New().String(),
+			Event:       msg.Data,
+			ContentType: msg.ContentType,
+			Metadata:    msg.Metadata,
+		},
+		done: make(chan error, 1),
+	}
+
+	h.lock.Lock()
+	h.pending = append(h.pending, buffered)
+	if h.delivering == 0 || len(h.pending) >= h.config.MaxMessagesCount {
+		batch := h.take()
+		h.lock.Unlock()
+		go h.deliver(batch)
+	} else {
+		if len(h.pending) == 1 {
+			h.timer = time.AfterFunc(h.config.MaxAwaitDuration, h.flush)
+		}
+		h.lock.Unlock()
+	}
+
+	select {
+	case err := <-buffered.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take returns the pending messages, which are delivered as a batch, and
+// resets the buffer. It must be called with the lock held.
+func (h *bufferingHandler) take() []*bufferedEntry {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	batch := h.pending
+	h.pending = nil
+	if len(batch) > 0 {
+		h.delivering++
+	}
+
+	return batch
+}
+
+// flush delivers the pending messages once the maximum await duration is
+// elapsed.
+func (h *bufferingHandler) flush() {
+	h.lock.Lock()
+	batch := h.take()
+	h.lock.Unlock()
+
+	if len(batch) > 0 {
+		h.deliver(batch)
+	}
+}
+
+func (h *bufferingHandler) deliver(batch []*bufferedEntry) {
+	msg := &BulkMessage{
+		Entries: make([]BulkMessageEntry, len(batch)),
+		Topic:   h.topic,
+	}
+	for i, buffered := range batch {
+		msg.Entries[i] = buffered.entry
+	}
+
+	statuses, err := h.handler(h.ctx, msg)
+	for i, err := range BulkEntryErrors(msg, statuses, err) {
+		batch[i].done <- err
+	}
+
+	// The messages buffered meanwhile are delivered once no batch is being processed.
+	var next []*bufferedEntry
+	h.lock.Lock()
+	h.delivering--
+	if h.delivering == 0 {
+		next = h.take()
+	}
+	h.lock.Unlock()
+
+	if len(next) > 0 {
+		go h.deliver(next)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBulkSubscribeConfig(t *testing.T) {
+	config, err := NewBulkSubscribeConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, BulkSubscribeConfig{MaxMessagesCount: 100, MaxAwaitDuration: time.Second}, config)
+
+	config, err = NewBulkSubscribeConfig(map[string]string{
+		BulkSubscribeMaxMessagesCountKey: "10",
+		BulkSubscribeMaxAwaitDurationKey: "250",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, BulkSubscribeConfig{MaxMessagesCount: 10, MaxAwaitDuration: 250 * time.Millisecond}, config)
+
+	config, err = NewBulkSubscribeConfig(map[string]string{BulkSubscribeMaxAwaitDurationKey: "2s"})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, config.MaxAwaitDuration)
+
+	_, err = NewBulkSubscribeConfig(map[string]string{BulkSubscribeMaxMessagesCountKey: "0"})
+	assert.Error(t, err)
+	_, err = NewBulkSubscribeConfig(map[string]string{BulkSubscribeMaxAwaitDurationKey: "soon"})
+	assert.Error(t, err)
+}
+
+func TestBulkEntryErrors(t *testing.T) {
+	msg := &BulkMessage{Entries: []BulkMessageEntry{{EntryID: "1"}, {EntryID: "2"}, {EntryID: "3"}}}
+	failed := errors.New("failed")
+
+	errs := BulkEntryErrors(msg, []BulkSubscribeResponseEntry{{EntryID: "2", Error: failed}}, nil)
+	assert.Equal(t, []error{nil, failed, nil}, errs)
+
+	errs = BulkEntryErrors(msg, []BulkSubscribeResponseEntry{{EntryID: "1"}}, failed)
+	assert.Equal(t, []error{nil, failed, failed}, errs)
+}
+
+func TestBufferingHandler(t *testing.T) {
+	t.Run("delivers at once when no batch is being processed", func(t *testing.T) {
+		var batches [][]string
+		handler := NewBufferingHandler(context.Background(), "orders", func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			batch := []string{}
+			for _, entry := range msg.Entries {
+				batch = append(batch, string(entry.Event))
+			}
+			batches = append(batches, batch)
+
+			return nil, nil
+		}, BulkSubscribeConfig{MaxMessagesCount: 10, MaxAwaitDuration: time.Minute})
+
+		for _, data := range []string{"a", "b", "c"} {
+			assert.NoError(t, handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(data)}))
+		}
+		assert.Equal(t, [][]string{{"a"}, {"b"}, {"c"}}, batches)
+	})
+
+	t.Run("buffers the messages while a batch is being processed", func(t *testing.T) {
+		var (
+			lock    sync.Mutex
+			batches [][]string
+		)
+		release := make(chan struct{})
+		handler := NewBufferingHandler(context.Background(), "orders", func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			assert.Equal(t, "orders", msg.Topic)
+			batch := []string{}
+			statuses := []BulkSubscribeResponseEntry{}
+			for _, entry := range msg.Entries {
+				batch = append(batch, string(entry.Event))
+				if string(entry.Event) == "fail" {
+					statuses = append(statuses, BulkSubscribeResponseEntry{EntryID: entry.EntryID, Error: errors.New("failed")})
+				}
+			}
+			lock.Lock()
+			batches = append(batches, batch)
+			lock.Unlock()
+			if batch[0] == "first" {
+				<-release
+			}
+
+			return statuses, nil
+		}, BulkSubscribeConfig{MaxMessagesCount: 3, MaxAwaitDuration: time.Minute})
+
+		first := make(chan error)
+		go func() {
+			first <- handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte("first")})
+		}()
+		require.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return len(batches) == 1
+		}, time.Second, time.Millisecond)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i, data := range []string{"a", "fail", "c", "d"} {
+			wg.Add(1)
+			go func(i int, data string) {
+				defer wg.Done()
+				errs[i] = handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(data)})
+			}(i, data)
+		}
+		require.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return len(batches) == 2
+		}, time.Second, time.Millisecond)
+
+		// The last message is delivered once the first batch is processed.
+		close(release)
+		assert.NoError(t, <-first)
+		wg.Wait()
+
+		require.Len(t, batches, 3)
+		assert.Len(t, batches[1], 3)
+		assert.ElementsMatch(t, []string{"a", "fail", "c", "d"}, append(batches[1], batches[2]...))
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+		assert.NoError(t, errs[2])
+		assert.NoError(t, errs[3])
+	})
+
+	t.Run("delivers after the maximum await duration", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		handler := NewBufferingHandler(context.Background(), "orders", func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			assert.Len(t, msg.Entries, 1)
+			if string(msg.Entries[0].Event) == "first" {
+				<-release
+			}
+
+			return nil, errors.New("failed")
+		}, BulkSubscribeConfig{MaxMessagesCount: 10, MaxAwaitDuration: 10 * time.Millisecond})
+
+		go handler(context.Background(), &NewMessage{Data: []byte("first")})
+		time.Sleep(10 * time.Millisecond)
+		assert.EqualError(t, handler(context.Background(), &NewMessage{Data: []byte("a")}), "failed")
+	})
+}
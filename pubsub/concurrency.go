@@ -23,8 +23,14 @@ const (
 	ConcurrencyKey                 = "concurrencyMode"
 	Single         ConcurrencyMode = "single"
 	Parallel       ConcurrencyMode = "parallel"
+	// Keyed processes the messages with the same partition key in order, and the messages with different keys in parallel.
+	Keyed ConcurrencyMode = "keyed"
 )
 
+// PartitionKeyMetadataKey is the metadata key of the partition key of a
+// message, which orders the messages processed in the Keyed concurrency mode.
+const PartitionKeyMetadataKey = "partitionKey"
+
 // Concurrency takes a metadata object and returns the ConcurrencyMode configured. Default is Parallel.
 func Concurrency(metadata map[string]string) (ConcurrencyMode, error) {
 	if val, ok := metadata[ConcurrencyKey]; ok && val != "" {
@@ -33,6 +39,8 @@ func Concurrency(metadata map[string]string) (ConcurrencyMode, error) {
 			return Single, nil
 		case string(Parallel):
 			return Parallel, nil
+		case string(Keyed):
+			return Keyed, nil
 		default:
 			return "", fmt.Errorf("invalid %s %s", ConcurrencyKey, val)
 		}
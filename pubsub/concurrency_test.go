@@ -41,6 +41,13 @@ func TestConcurrency(t *testing.T) {
 		assert.Equal(t, Single, c)
 	})
 
+	t.Run("keyed", func(t *testing.T) {
+		m := map[string]string{ConcurrencyKey: string(Keyed)}
+		c, _ := Concurrency(m)
+
+		assert.Equal(t, Keyed, c)
+	})
+
 	t.Run("invalid", func(t *testing.T) {
 		m := map[string]string{ConcurrencyKey: "a"}
 		c, err := Concurrency(m)
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// DeadLetterTopicKey is the subscribe metadata key of the topic receiving
+	// the messages which could not be delivered.
+	DeadLetterTopicKey = "deadLetterTopic"
+	// DeadLetterMaxDeliveriesKey is the subscribe metadata key of the number of
+	// failed deliveries after which a message is sent to the dead-letter topic.
+	DeadLetterMaxDeliveriesKey = "deadLetterMaxDeliveries"
+
+	// DeadLetterErrorKey is the metadata of a dead-lettered message holding the
+	// error of its last delivery.
+	DeadLetterErrorKey = "deadLetterError"
+	// DeadLetterSourceTopicKey is the metadata of a dead-lettered message
+	// holding the topic it was published to.
+	DeadLetterSourceTopicKey = "deadLetterSourceTopic"
+	// DeadLetterDeliveriesKey is the metadata of a dead-lettered message holding
+	// the number of failed deliveries.
+	DeadLetterDeliveriesKey = "deadLetterDeliveries"
+
+	// DeadLetterErrorField, DeadLetterSourceTopicField and DeadLetterDeliveriesField
+	// are the CloudEvent extension attributes of a dead-lettered message holding
+	// the same information as its metadata, for the brokers which do not carry
+	// the metadata of the messages.
+	DeadLetterErrorField       = "deadlettererror"
+	DeadLetterSourceTopicField = "deadlettersourcetopic"
+	DeadLetterDeliveriesField  = "deadletterdeliveries"
+
+	defaultDeadLetterMaxDeliveries = 3
+	// deadLetterTrackedMessages bounds the number of messages whose failed
+	// deliveries are counted at once.
+	deadLetterTrackedMessages = 10000
+)
+
+// deadLetterHandler counts the failed deliveries of the messages, and sends
+// a message to the dead-letter topic once it reaches the maximum.
+type deadLetterHandler struct {
+	topic         string
+	maxDeliveries int
+	handler       Handler
+	publish       func(req *PublishRequest) error
+
+	lock     sync.Mutex
+	failures *lru.Cache
+}
+
+// NewDeadLetterHandler wraps handler to send the messages which failed to be
+// delivered to the topic set in the deadLetterTopic metadata of req.
+// A message is sent to the dead-letter topic with publish once its delivery
+// failed deadLetterMaxDeliveries times (3 by default), with the error of the
+// last delivery in its metadata and, if the message is a CloudEvent, in its
+// extension attributes. It is then acknowledged to the broker.
+// The deliveries of a message are identified by its CloudEvent ID, or by its
+// content if it is not a CloudEvent, and must be handled by this instance.
+// handler is returned as is if req has no dead-letter topic.
+func NewDeadLetterHandler(req SubscribeRequest, handler Handler, publish func(req *PublishRequest) error) (Handler, error) {
+	topic := req.Metadata[DeadLetterTopicKey]
+	if topic == "" {
+		return handler, nil
+	}
+	if topic == req.Topic {
+		return nil, fmt.Errorf("the dead-letter topic must differ from the topic %s", req.Topic)
+	}
+
+	maxDeliveries := defaultDeadLetterMaxDeliveries
+	if val, ok := req.Metadata[DeadLetterMaxDeliveriesKey]; ok && val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %s", DeadLetterMaxDeliveriesKey, val)
+		}
+		maxDeliveries = n
+	}
+
+	failures, err := lru.New(deadLetterTrackedMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &deadLetterHandler{
+		topic:         topic,
+		maxDeliveries: maxDeliveries,
+		handler:       handler,
+		publish:       publish,
+		failures:      failures,
+	}
+
+	return h.handle, nil
+}
+
+func (h *deadLetterHandler) handle(ctx context.Context, msg *NewMessage) error {
+	err := h.handler(ctx, msg)
+	id := messageID(msg)
+	if err == nil {
+		h.failures.Remove(id)
+
+		return nil
+	}
+
+	h.lock.Lock()
+	deliveries := 1
+	if val, ok := h.failures.Get(id); ok {
+		deliveries += val.(int)
+	}
+	h.failures.Add(id, deliveries)
+	h.lock.Unlock()
+
+	if deliveries < h.maxDeliveries {
+		return err
+	}
+
+	metadata := make(map[string]string, len(msg.Metadata)+3)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	metadata[DeadLetterErrorKey] = err.Error()
+	metadata[DeadLetterSourceTopicKey] = msg.Topic
+	metadata[DeadLetterDeliveriesKey] = strconv.Itoa(deliveries)
+
+	pubErr := h.publish(&PublishRequest{
+		Data:        withDeadLetterAttributes(msg.Data, err.Error(), msg.Topic, deliveries),
+		Topic:       h.topic,
+		Metadata:    metadata,
+		ContentType: msg.ContentType,
+	})
+	if pubErr != nil {
+		return fmt.Errorf("%w; error sending the message to the dead-letter topic %s: %s", err, h.topic, pubErr)
+	}
+	h.failures.Remove(id)
+
+	return nil
+}
+
+// withDeadLetterAttributes returns the CloudEvent data with the dead-letter
+// extension attributes set, or data unchanged if it is not a CloudEvent.
+func withDeadLetterAttributes(data []byte, deliveryErr string, sourceTopic string, deliveries int) []byte {
+	var ce map[string]interface{}
+	if err := unmarshalPrecise(data, &ce); err != nil || ce[SpecVersionField] == nil {
+		return data
+	}
+
+	ce[DeadLetterErrorField] = deliveryErr
+	ce[DeadLetterSourceTopicField] = sourceTopic
+	ce[DeadLetterDeliveriesField] = deliveries
+	b, err := json.Marshal(ce)
+	if err != nil {
+		return data
+	}
+
+	return b
+}
+
+// messageID returns the CloudEvent ID of msg, or a hash of its content.
+func messageID(msg *NewMessage) string {
+	var ce struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(msg.Data, &ce) == nil && ce.ID != "" {
+		return msg.Topic + "|" + ce.ID
+	}
+
+	h := fnv.New64a()
+	h.Write(msg.Data)
+
+	return msg.Topic + "|#" + strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterHandler(t *testing.T) {
+	failing := func(ctx context.Context, msg *NewMessage) error {
+		return errors.New("handler error")
+	}
+
+	t.Run("without dead-letter topic", func(t *testing.T) {
+		called := false
+		handler, err := NewDeadLetterHandler(SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *NewMessage) error {
+			called = true
+
+			return nil
+		}, nil)
+		require.NoError(t, err)
+
+		assert.NoError(t, handler(context.Background(), &NewMessage{}))
+		assert.True(t, called)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "orders"},
+		}, failing, nil)
+		assert.Error(t, err)
+
+		_, err = NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "dlq", DeadLetterMaxDeliveriesKey: "0"},
+		}, failing, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("sends the message after the maximum deliveries", func(t *testing.T) {
+		var published []*PublishRequest
+		handler, err := NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "dlq", DeadLetterMaxDeliveriesKey: "2"},
+		}, failing, func(req *PublishRequest) error {
+			published = append(published, req)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		msg := &NewMessage{
+			Topic:    "orders",
+			Data:     []byte(`{"specversion":"1.0","id":"1","data":"order"}`),
+			Metadata: map[string]string{"key": "value"},
+		}
+		other := &NewMessage{Topic: "orders", Data: []byte(`{"id":"2","data":"order"}`)}
+
+		assert.Error(t, handler(context.Background(), msg))
+		assert.Error(t, handler(context.Background(), other))
+		assert.Empty(t, published)
+
+		assert.NoError(t, handler(context.Background(), msg))
+		require.Len(t, published, 1)
+		assert.Equal(t, "dlq", published[0].Topic)
+		assert.JSONEq(t, `{
+			"specversion": "1.0",
+			"id": "1",
+			"data": "order",
+			"deadlettererror": "handler error",
+			"deadlettersourcetopic": "orders",
+			"deadletterdeliveries": 2
+		}`, string(published[0].Data))
+		assert.Equal(t, map[string]string{
+			"key":                    "value",
+			DeadLetterErrorKey:       "handler error",
+			DeadLetterSourceTopicKey: "orders",
+			DeadLetterDeliveriesKey:  "2",
+		}, published[0].Metadata)
+
+		// The deliveries are counted again once the message is dead-lettered.
+		assert.Error(t, handler(context.Background(), msg))
+		assert.Len(t, published, 1)
+	})
+
+	t.Run("resets the deliveries on success", func(t *testing.T) {
+		fail := true
+		handler, err := NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "dlq", DeadLetterMaxDeliveriesKey: "2"},
+		}, func(ctx context.Context, msg *NewMessage) error {
+			if fail {
+				return errors.New("handler error")
+			}
+
+			return nil
+		}, func(req *PublishRequest) error {
+			return errors.New("unexpected publish")
+		})
+		require.NoError(t, err)
+
+		msg := &NewMessage{Topic: "orders", Data: []byte("raw payload")}
+		assert.Error(t, handler(context.Background(), msg))
+		fail = false
+		assert.NoError(t, handler(context.Background(), msg))
+		fail = true
+		assert.Error(t, handler(context.Background(), msg))
+	})
+
+	t.Run("keeps the data of the messages which are not CloudEvents", func(t *testing.T) {
+		var published []*PublishRequest
+		handler, err := NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "dlq", DeadLetterMaxDeliveriesKey: "1"},
+		}, failing, func(req *PublishRequest) error {
+			published = append(published, req)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.NoError(t, handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(`{"id":"1"}`)}))
+		require.Len(t, published, 1)
+		assert.Equal(t, `{"id":"1"}`, string(published[0].Data))
+	})
+
+	t.Run("returns the error if the message cannot be dead-lettered", func(t *testing.T) {
+		handler, err := NewDeadLetterHandler(SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{DeadLetterTopicKey: "dlq", DeadLetterMaxDeliveriesKey: "1"},
+		}, failing, func(req *PublishRequest) error {
+			return errors.New("publish error")
+		})
+		require.NoError(t, err)
+
+		err = handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte("raw payload")})
+		assert.ErrorContains(t, err, "handler error")
+		assert.ErrorContains(t, err, "publish error")
+	})
+}
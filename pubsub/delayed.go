@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// ScheduledDeliveryTimeKey is the publish metadata key of the time, in
+	// RFC3339 format, at which the message must be delivered.
+	ScheduledDeliveryTimeKey = "scheduledDeliveryTime"
+	// DeliverAfterKey is the publish metadata key of the duration after which
+	// the message must be delivered, e.g. 30s.
+	DeliverAfterKey = "deliverAfter"
+)
+
+// DeliveryTime returns the time at which a message published with metadata
+// at now must be delivered, from its scheduledDeliveryTime metadata, or
+// from its deliverAfter metadata otherwise. ok is false if the delivery of
+// the message is not delayed.
+func DeliveryTime(metadata map[string]string, now time.Time) (deliveryTime time.Time, ok bool, err error) {
+	if val, found := metadata[ScheduledDeliveryTimeKey]; found && val != "" {
+		deliveryTime, err = time.Parse(time.RFC3339, val)
+		if err != nil {
+			return deliveryTime, false, fmt.Errorf("invalid %s %s: %w", ScheduledDeliveryTimeKey, val, err)
+		}
+
+		return deliveryTime, true, nil
+	}
+
+	if val, found := metadata[DeliverAfterKey]; found && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return deliveryTime, false, fmt.Errorf("invalid %s %s", DeliverAfterKey, val)
+		}
+
+		return now.Add(d), true, nil
+	}
+
+	return deliveryTime, false, nil
+}
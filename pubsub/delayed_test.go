@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryTime(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	_, ok, err := DeliveryTime(map[string]string{"other": "value"}, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	deliveryTime, ok, err := DeliveryTime(map[string]string{DeliverAfterKey: "90s"}, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(90*time.Second), deliveryTime)
+
+	deliveryTime, ok, err = DeliveryTime(map[string]string{
+		ScheduledDeliveryTimeKey: "2022-03-02T08:00:00Z",
+		DeliverAfterKey:          "90s",
+	}, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 3, 2, 8, 0, 0, 0, time.UTC), deliveryTime)
+
+	_, _, err = DeliveryTime(map[string]string{ScheduledDeliveryTimeKey: "tomorrow"}, now)
+	assert.Error(t, err)
+	_, _, err = DeliveryTime(map[string]string{DeliverAfterKey: "-1s"}, now)
+	assert.Error(t, err)
+}
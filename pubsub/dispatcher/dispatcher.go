@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// Keyed runs the tasks dispatched with the same key one at a time,
+// in the order they are dispatched, and the tasks with different keys in
+// parallel on a bounded pool of workers. The tasks without key are spread
+// over the workers.
+type Keyed struct {
+	ctx    context.Context
+	queues []chan func()
+	next   uint32
+}
+
+// NewKeyed starts a dispatcher with the given number of workers,
+// each queuing up to queueDepth tasks. The workers stop once ctx is done,
+// dropping the queued tasks.
+func NewKeyed(ctx context.Context, workers, queueDepth int) *Keyed {
+	if workers < 1 {
+		workers = 1
+	}
+	d := &Keyed{ctx: ctx, queues: make([]chan func(), workers)}
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), queueDepth)
+		go d.worker(d.queues[i])
+	}
+
+	return d
+}
+
+func (d *Keyed) worker(queue chan func()) {
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case task := <-queue:
+			task()
+		}
+	}
+}
+
+// Dispatch queues task to the worker of key. It blocks while the queue of
+// the worker is full, and returns an error if ctx or the context of the
+// dispatcher is done first.
+func (d *Keyed) Dispatch(ctx context.Context, key string, task func()) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case d.queueOf(key) <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	}
+}
+
+// queueOf returns the queue of the worker of key.
+func (d *Keyed) queueOf(key string) chan func() {
+	var i uint32
+	if key == "" {
+		i = atomic.AddUint32(&d.next, 1)
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		i = h.Sum32()
+	}
+
+	return d.queues[i%uint32(len(d.queues))]
+}
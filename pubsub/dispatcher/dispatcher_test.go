@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyed(t *testing.T) {
+	t.Run("runs the tasks of a key in order", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		d := NewKeyed(ctx, 4, 10)
+
+		var (
+			lock sync.Mutex
+			wg   sync.WaitGroup
+		)
+		processed := map[string][]int{}
+		for i := 0; i < 50; i++ {
+			key := "key-" + strconv.Itoa(i%5)
+			i := i
+			wg.Add(1)
+			require.NoError(t, d.Dispatch(context.Background(), key, func() {
+				defer wg.Done()
+				lock.Lock()
+				processed[key] = append(processed[key], i)
+				lock.Unlock()
+			}))
+		}
+		wg.Wait()
+
+		require.Len(t, processed, 5)
+		for k, values := range processed {
+			require.Len(t, values, 10, k)
+			for j := 1; j < len(values); j++ {
+				assert.Less(t, values[j-1], values[j], k)
+			}
+		}
+	})
+
+	t.Run("runs different keys in parallel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		d := NewKeyed(ctx, 2, 1)
+
+		// Find a key which is not handled by the worker of "a".
+		other := ""
+		for i := 0; other == ""; i++ {
+			key := strconv.Itoa(i)
+			if d.queueOf(key) != d.queueOf("a") {
+				other = key
+			}
+		}
+
+		release := make(chan struct{})
+		require.NoError(t, d.Dispatch(context.Background(), "a", func() {
+			<-release
+		}))
+		done := make(chan struct{})
+		require.NoError(t, d.Dispatch(context.Background(), other, func() {
+			close(done)
+		}))
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the task of another key is blocked")
+		}
+		close(release)
+	})
+
+	t.Run("stops waiting once the context is done", func(t *testing.T) {
+		dispatcherCtx, stop := context.WithCancel(context.Background())
+		d := NewKeyed(dispatcherCtx, 1, 0)
+		release := make(chan struct{})
+		defer close(release)
+		require.NoError(t, d.Dispatch(context.Background(), "a", func() {
+			<-release
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, d.Dispatch(ctx, "a", func() {}), context.DeadlineExceeded)
+
+		stop()
+		assert.ErrorIs(t, d.Dispatch(context.Background(), "a", func() {}), context.Canceled)
+	})
+}
@@ -16,6 +16,12 @@ package pubsub
 const (
 	// FeatureMessageTTL is the feature to handle message TTL.
 	FeatureMessageTTL Feature = "MESSAGE_TTL"
+	// FeatureBulkPublish is the feature to publish several messages in a single request to the broker.
+	FeatureBulkPublish Feature = "BULK_PUBLISH"
+	// FeatureBulkSubscribe is the feature to deliver batches of messages to a BulkHandler natively.
+	FeatureBulkSubscribe Feature = "BULK_SUBSCRIBE"
+	// FeatureDelayedDelivery is the feature to deliver the messages published with the scheduledDeliveryTime or deliverAfter metadata at a later time.
+	FeatureDelayedDelivery Feature = "DELAYED_DELIVERY"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"container/heap"
+	"time"
+)
+
+// delayedMessage is a message published to topic once deliveryTime is reached.
+type delayedMessage struct {
+	topic        string
+	data         []byte
+	deliveryTime time.Time
+}
+
+// delayedMessages is a heap of delayed messages ordered by delivery time.
+type delayedMessages []*delayedMessage
+
+func (h delayedMessages) Len() int           { return len(h) }
+func (h delayedMessages) Less(i, j int) bool { return h[i].deliveryTime.Before(h[j].deliveryTime) }
+func (h delayedMessages) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *delayedMessages) Push(x interface{}) {
+	*h = append(*h, x.(*delayedMessage))
+}
+
+func (h *delayedMessages) Pop() interface{} {
+	old := *h
+	n := len(old)
+	msg := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return msg
+}
+
+// delay parks msg until its delivery time and wakes the delivery loop up,
+// in case msg is due before the messages already parked.
+func (a *bus) delay(msg *delayedMessage) {
+	a.delayedLock.Lock()
+	heap.Push(&a.delayed, msg)
+	a.delayedLock.Unlock()
+
+	select {
+	case a.delayedWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDelayedMessagesLoop publishes the delayed messages when they are
+// due, until the bus is closed.
+func (a *bus) deliverDelayedMessagesLoop() {
+	for {
+		a.delayedLock.Lock()
+		now := time.Now()
+		var due []*delayedMessage
+		for len(a.delayed) > 0 && !a.delayed[0].deliveryTime.After(now) {
+			due = append(due, heap.Pop(&a.delayed).(*delayedMessage))
+		}
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if len(a.delayed) > 0 {
+			timer = time.NewTimer(a.delayed[0].deliveryTime.Sub(now))
+			timerC = timer.C
+		}
+		a.delayedLock.Unlock()
+
+		for _, msg := range due {
+			a.bus.Publish(msg.topic, msg.data)
+		}
+
+		select {
+		case <-a.ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+
+			return
+		case <-a.delayedWakeup:
+		case <-timerC:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
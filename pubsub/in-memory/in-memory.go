@@ -15,16 +15,31 @@ package inmemory
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/asaskevich/EventBus"
 
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
+// defaultMaxRetries is the number of times a failed delivery is retried,
+// unless the subscription configures another retry policy.
+const defaultMaxRetries = 9
+
 type bus struct {
 	bus EventBus.Bus
 	log logger.Logger
+
+	// delayed holds the messages which are not due yet.
+	delayed       delayedMessages
+	delayedLock   sync.Mutex
+	delayedWakeup chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
@@ -34,36 +49,83 @@ func New(logger logger.Logger) pubsub.PubSub {
 }
 
 func (a *bus) Close() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+
 	return nil
 }
 
 func (a *bus) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureBulkPublish, pubsub.FeatureDelayedDelivery}
 }
 
 func (a *bus) Init(metadata pubsub.Metadata) error {
 	a.bus = EventBus.New()
+	a.delayedWakeup = make(chan struct{}, 1)
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	go a.deliverDelayedMessagesLoop()
 
 	return nil
 }
 
 func (a *bus) Publish(req *pubsub.PublishRequest) error {
-	a.bus.Publish(req.Topic, req.Data)
+	return a.publish(req.Topic, req.Data, req.Metadata)
+}
+
+// BulkPublish publishes the entries in order. Publishing to the bus only
+// fails for invalid delayed delivery metadata.
+func (a *bus) BulkPublish(req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	errs := make([]error, len(req.Entries))
+	for i, entry := range req.Entries {
+		errs[i] = a.publish(req.Topic, entry.Event, req.EntryMetadata(&entry))
+	}
+
+	return pubsub.NewBulkPublishResponse(req.Entries, errs)
+}
+
+// publish publishes data to topic, or parks it until its delivery time
+// if its metadata delays its delivery.
+func (a *bus) publish(topic string, data []byte, metadata map[string]string) error {
+	now := time.Now()
+	deliveryTime, delayed, err := pubsub.DeliveryTime(metadata, now)
+	if err != nil {
+		return err
+	}
+	if delayed && deliveryTime.After(now) {
+		a.delay(&delayedMessage{topic: topic, data: data, deliveryTime: deliveryTime})
+
+		return nil
+	}
+
+	a.bus.Publish(topic, data)
 
 	return nil
 }
 
 func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, a.Publish)
+	if err != nil {
+		return err
+	}
+
+	retryConfig := retry.DefaultConfig()
+	retryConfig.Duration = 0
+	retryConfig.MaxRetries = defaultMaxRetries
+	retryConfig, err = pubsub.NewRetryConfig(req.Metadata, retryConfig)
+	if err != nil {
+		return err
+	}
+	handler = pubsub.NewRetryHandler(handler, retryConfig, a.log)
+
 	retryHandler := func(data []byte) {
-		for i := 0; i < 10; i++ {
-			handleErr := handler(ctx, &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: req.Metadata})
-			if handleErr == nil {
-				break
-			}
+		handleErr := handler(ctx, &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: req.Metadata})
+		if handleErr != nil {
 			a.log.Error(handleErr)
 		}
 	}
-	err := a.bus.SubscribeAsync(req.Topic, retryHandler, true)
+	err = a.bus.SubscribeAsync(req.Topic, retryHandler, true)
 	if err != nil {
 		return err
 	}
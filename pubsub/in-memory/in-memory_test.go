@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -37,6 +38,54 @@ func TestNewInMemoryBus(t *testing.T) {
 	assert.Equal(t, "ABCD", string(<-ch))
 }
 
+func TestBulkPublish(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+
+	ch := make(chan []byte, 2)
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return publish(ch, msg)
+	})
+
+	res, err := bus.(pubsub.BulkPublisher).BulkPublish(&pubsub.BulkPublishRequest{
+		Topic: "demo",
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryID: "1", Event: []byte("ABCD")},
+			{EntryID: "2", Event: []byte("EFGH")},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, res.Statuses, 2)
+	assert.ElementsMatch(t, []string{"ABCD", "EFGH"}, []string{string(<-ch), string(<-ch)})
+}
+
+func TestDelayedDelivery(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+	defer bus.Close()
+
+	ch := make(chan []byte, 3)
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return publish(ch, msg)
+	})
+
+	start := time.Now()
+	err := bus.Publish(&pubsub.PublishRequest{Data: []byte("second"), Topic: "demo", Metadata: map[string]string{pubsub.DeliverAfterKey: "200ms"}})
+	assert.NoError(t, err)
+	err = bus.Publish(&pubsub.PublishRequest{Data: []byte("first"), Topic: "demo", Metadata: map[string]string{pubsub.DeliverAfterKey: "100ms"}})
+	assert.NoError(t, err)
+	err = bus.Publish(&pubsub.PublishRequest{Data: []byte("now"), Topic: "demo"})
+	assert.NoError(t, err)
+	err = bus.Publish(&pubsub.PublishRequest{Data: []byte("invalid"), Topic: "demo", Metadata: map[string]string{pubsub.DeliverAfterKey: "later"}})
+	assert.Error(t, err)
+
+	assert.Equal(t, "now", string(<-ch))
+	assert.Equal(t, "first", string(<-ch))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, "second", string(<-ch))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
 func TestMultipleSubscribers(t *testing.T) {
 	bus := New(logger.NewLogger("test"))
 	bus.Init(pubsub.Metadata{})
@@ -78,6 +127,40 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, 5, i)
 }
 
+func TestDeadLetterTopic(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+
+	ch := make(chan *pubsub.NewMessage)
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "dlq"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		go func() { ch <- msg }()
+
+		return nil
+	})
+
+	attempts := 0
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{
+		Topic:    "demo",
+		Metadata: map[string]string{pubsub.DeadLetterTopicKey: "dlq"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		attempts++
+
+		return errors.New("poison message")
+	})
+
+	bus.Publish(&pubsub.PublishRequest{Data: []byte(`{"specversion":"1.0","id":"1","data":"ABCD"}`), Topic: "demo"})
+	msg := <-ch
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "1",
+		"data": "ABCD",
+		"deadlettererror": "poison message",
+		"deadlettersourcetopic": "demo",
+		"deadletterdeliveries": 3
+	}`, string(msg.Data))
+	assert.Equal(t, 3, attempts)
+}
+
 func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 	go func() { ch <- msg.Data }()
 
@@ -94,6 +94,11 @@ func (js *jetstreamPubSub) Publish(req *pubsub.PublishRequest) error {
 }
 
 func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, js.Publish)
+	if err != nil {
+		return err
+	}
+
 	var opts []nats.SubOpt
 
 	if v := js.meta.durableName; v != "" {
@@ -156,7 +161,6 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 		}
 	}
 
-	var err error
 	var subscription *nats.Subscription
 	if queue := js.meta.queueGroupName; queue != "" {
 		js.l.Debugf("nats: subscribed to subject %s with queue group %s",
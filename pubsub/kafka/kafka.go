@@ -15,6 +15,7 @@ package kafka
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/dapr/kit/logger"
 
@@ -30,14 +31,58 @@ type PubSub struct {
 }
 
 func (p *PubSub) Init(metadata pubsub.Metadata) error {
+	if _, err := pubsub.Concurrency(metadata.Properties); err != nil {
+		return err
+	}
+
 	p.subscribeCtx, p.subscribeCancel = context.WithCancel(context.Background())
 
 	return p.kafka.Init(metadata.Properties)
 }
 
 func (p *PubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, p.Publish)
+	if err != nil {
+		return err
+	}
+
+	if pubsub.HasRetryConfig(req.Metadata) {
+		retryConfig, err := pubsub.NewRetryConfig(req.Metadata, p.kafka.BackOffConfig())
+		if err != nil {
+			return err
+		}
+		p.kafka.SetTopicRetryConfig(req.Topic, retryConfig)
+	}
+
 	p.kafka.AddTopicHandler(req.Topic, adaptHandler(handler))
 
+	return p.subscribe(ctx, req.Topic)
+}
+
+// BulkSubscribe hands the messages of the topic to handler in batches. The
+// messages which failed are retried as configured for the topic.
+func (p *PubSub) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	config, err := pubsub.NewBulkSubscribeConfig(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	if pubsub.HasRetryConfig(req.Metadata) {
+		retryConfig, err := pubsub.NewRetryConfig(req.Metadata, p.kafka.BackOffConfig())
+		if err != nil {
+			return err
+		}
+		p.kafka.SetTopicRetryConfig(req.Topic, retryConfig)
+	}
+
+	p.kafka.AddBulkTopicHandler(req.Topic, adaptBulkHandler(handler), config.MaxMessagesCount, config.MaxAwaitDuration)
+
+	return p.subscribe(ctx, req.Topic)
+}
+
+// subscribe (re)starts the consumer group, and removes the handler of topic
+// once ctx is done.
+func (p *PubSub) subscribe(ctx context.Context, topic string) error {
 	go func() {
 		// Wait for context cancelation
 		select {
@@ -46,7 +91,7 @@ func (p *PubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 		}
 
 		// Remove the topic handler before restarting the subscriber
-		p.kafka.RemoveTopicHandler(req.Topic)
+		p.kafka.RemoveTopicHandler(topic)
 
 		// If the component's context has been canceled, do not re-subscribe
 		if p.subscribeCtx.Err() != nil {
@@ -78,13 +123,37 @@ func (p *PubSub) Publish(req *pubsub.PublishRequest) error {
 	return p.kafka.Publish(req.Topic, req.Data, req.Metadata)
 }
 
+// BulkPublish publishes the entries to Kafka cluster in a single request.
+func (p *PubSub) BulkPublish(req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	msgs := make([]kafka.BulkMessage, len(req.Entries))
+	for i := range req.Entries {
+		msgs[i] = kafka.BulkMessage{
+			Data:     req.Entries[i].Event,
+			Metadata: req.EntryMetadata(&req.Entries[i]),
+		}
+	}
+
+	// An error of the request fails all the entries.
+	failed, err := p.kafka.BulkPublish(req.Topic, msgs)
+	errs := make([]error, len(req.Entries))
+	for i := range errs {
+		if err != nil {
+			errs[i] = err
+		} else {
+			errs[i] = failed[i]
+		}
+	}
+
+	return pubsub.NewBulkPublishResponse(req.Entries, errs)
+}
+
 func (p *PubSub) Close() (err error) {
 	p.subscribeCancel()
 	return p.kafka.Close()
 }
 
 func (p *PubSub) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureBulkPublish, pubsub.FeatureBulkSubscribe}
 }
 
 func adaptHandler(handler pubsub.Handler) kafka.EventHandler {
@@ -97,3 +166,23 @@ func adaptHandler(handler pubsub.Handler) kafka.EventHandler {
 		})
 	}
 }
+
+func adaptBulkHandler(handler pubsub.BulkHandler) kafka.BulkEventHandler {
+	return func(ctx context.Context, events []*kafka.NewEvent) []error {
+		msg := &pubsub.BulkMessage{
+			Entries: make([]pubsub.BulkMessageEntry, len(events)),
+		}
+		for i, event := range events {
+			msg.Topic = event.Topic
+			msg.Entries[i] = pubsub.BulkMessageEntry{
+				EntryID:     strconv.Itoa(i),
+				Event:       event.Data,
+				ContentType: event.ContentType,
+				Metadata:    event.Metadata,
+			}
+		}
+		statuses, err := handler(ctx, msg)
+
+		return pubsub.BulkEntryErrors(msg, statuses, err)
+	}
+}
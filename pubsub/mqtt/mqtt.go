@@ -24,7 +24,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 
 	"github.com/dapr/components-contrib/pubsub"
@@ -52,6 +51,7 @@ const (
 	defaultRetain       = false
 	defaultWait         = 30 * time.Second
 	defaultCleanSession = true
+	defaultRetryDelay   = 5 * time.Second
 )
 
 // mqttPubSub type allows sending and receiving data to/from MQTT broker.
@@ -213,6 +213,20 @@ func (m *mqttPubSub) Publish(req *pubsub.PublishRequest) error {
 
 // Subscribe to the mqtt pub sub topic.
 func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, m.Publish)
+	if err != nil {
+		return err
+	}
+
+	retryConfig := retry.DefaultConfig()
+	retryConfig.Duration = defaultRetryDelay
+	retryConfig.MaxRetries = int64(m.metadata.backOffMaxRetries)
+	retryConfig, err = pubsub.NewRetryConfig(req.Metadata, retryConfig)
+	if err != nil {
+		return err
+	}
+	handler = pubsub.NewRetryHandler(handler, retryConfig, m.logger)
+
 	if ctxErr := m.ctx.Err(); ctxErr != nil {
 		// If the global context has been canceled, we do not allow more subscriptions
 		return ctxErr
@@ -316,29 +330,14 @@ func (m *mqttPubSub) onMessage(ctx context.Context) func(client mqtt.Client, mqt
 			return
 		}
 
-		// TODO: Make the backoff configurable for constant or exponential
-		var b backoff.BackOff = backoff.NewConstantBackOff(5 * time.Second)
-		b = backoff.WithContext(b, ctx)
-		if m.metadata.backOffMaxRetries >= 0 {
-			b = backoff.WithMaxRetries(b, uint64(m.metadata.backOffMaxRetries))
-		}
-		if err := retry.NotifyRecover(func() error {
-			m.logger.Debugf("Processing MQTT message %s/%d", mqttMsg.Topic(), mqttMsg.MessageID())
-
-			if err := topicHandler(ctx, &msg); err != nil {
-				return err
-			}
-
-			mqttMsg.Ack()
-
-			return nil
-		}, b, func(err error, d time.Duration) {
-			m.logger.Errorf("Error processing MQTT message: %s/%d. Retrying...", mqttMsg.Topic(), mqttMsg.MessageID())
-		}, func() {
-			m.logger.Infof("Successfully processed MQTT message after it previously failed: %s/%d", mqttMsg.Topic(), mqttMsg.MessageID())
-		}); err != nil {
+		m.logger.Debugf("Processing MQTT message %s/%d", mqttMsg.Topic(), mqttMsg.MessageID())
+		if err := topicHandler(ctx, &msg); err != nil {
 			m.logger.Errorf("Failed processing MQTT message: %s/%d: %v", mqttMsg.Topic(), mqttMsg.MessageID(), err)
+
+			return
 		}
+
+		mqttMsg.Ack()
 	}
 }
 
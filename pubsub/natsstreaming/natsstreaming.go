@@ -182,6 +182,9 @@ func parseNATSStreamingMetadata(meta pubsub.Metadata) (metadata, error) {
 	if err != nil {
 		return m, fmt.Errorf("nats-streaming error: can't parse %s: %s", pubsub.ConcurrencyKey, err)
 	}
+	if c == pubsub.Keyed {
+		return m, fmt.Errorf("nats-streaming error: %s %s is not supported", pubsub.ConcurrencyKey, c)
+	}
 
 	m.concurrencyMode = c
 	return m, nil
@@ -229,6 +232,11 @@ func (n *natsStreamingPubSub) Publish(req *pubsub.PublishRequest) error {
 }
 
 func (n *natsStreamingPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, n.Publish)
+	if err != nil {
+		return err
+	}
+
 	natStreamingsubscriptionOptions, err := n.subscriptionOptions()
 	if err != nil {
 		return fmt.Errorf("nats-streaming: error getting subscription options %s", err)
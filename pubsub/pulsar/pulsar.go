@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/apache/pulsar-client-go/pulsar"
@@ -202,32 +203,12 @@ func (p *Pulsar) Init(metadata pubsub.Metadata) error {
 }
 
 func (p *Pulsar) Publish(req *pubsub.PublishRequest) error {
-	var (
-		producer pulsar.Producer
-		msg      *pulsar.ProducerMessage
-		err      error
-	)
-	topic := p.formatTopic(req.Topic)
-	cache, _ := p.cache.Get(topic)
-	if cache == nil {
-		p.logger.Debugf("creating producer for topic %s, full topic name in pulsar is %s", req.Topic, topic)
-		producer, err = p.client.CreateProducer(pulsar.ProducerOptions{
-			Topic:                   topic,
-			DisableBatching:         p.metadata.DisableBatching,
-			BatchingMaxPublishDelay: p.metadata.BatchingMaxPublishDelay,
-			BatchingMaxMessages:     p.metadata.BatchingMaxMessages,
-			BatchingMaxSize:         p.metadata.BatchingMaxSize,
-		})
-		if err != nil {
-			return err
-		}
-
-		p.cache.Add(topic, producer)
-	} else {
-		producer = cache.(pulsar.Producer)
+	producer, err := p.getProducer(req.Topic)
+	if err != nil {
+		return err
 	}
 
-	msg, err = parsePublishMetadata(req)
+	msg, err := parsePublishMetadata(req)
 	if err != nil {
 		return err
 	}
@@ -238,6 +219,67 @@ func (p *Pulsar) Publish(req *pubsub.PublishRequest) error {
 	return nil
 }
 
+// BulkPublish sends the entries asynchronously and flushes the producer, so
+// that they are batched together.
+func (p *Pulsar) BulkPublish(req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	errs := make([]error, len(req.Entries))
+	producer, err := p.getProducer(req.Topic)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return pubsub.NewBulkPublishResponse(req.Entries, errs)
+	}
+
+	var wg sync.WaitGroup
+	for i := range req.Entries {
+		msg, err := parsePublishMetadata(req.PublishRequest(&req.Entries[i]))
+		if err != nil {
+			errs[i] = err
+
+			continue
+		}
+
+		wg.Add(1)
+		i := i
+		producer.SendAsync(p.publishCtx, msg, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+			errs[i] = err
+			wg.Done()
+		})
+	}
+	if err := producer.Flush(); err != nil {
+		p.logger.Warnf("pulsar: error flushing the producer of topic %s: %v", req.Topic, err)
+	}
+	wg.Wait()
+
+	return pubsub.NewBulkPublishResponse(req.Entries, errs)
+}
+
+// getProducer returns the cached producer of topic, or creates it.
+func (p *Pulsar) getProducer(topic string) (pulsar.Producer, error) {
+	fullTopic := p.formatTopic(topic)
+	cache, _ := p.cache.Get(fullTopic)
+	if cache != nil {
+		return cache.(pulsar.Producer), nil
+	}
+
+	p.logger.Debugf("creating producer for topic %s, full topic name in pulsar is %s", topic, fullTopic)
+	producer, err := p.client.CreateProducer(pulsar.ProducerOptions{
+		Topic:                   fullTopic,
+		DisableBatching:         p.metadata.DisableBatching,
+		BatchingMaxPublishDelay: p.metadata.BatchingMaxPublishDelay,
+		BatchingMaxMessages:     p.metadata.BatchingMaxMessages,
+		BatchingMaxSize:         p.metadata.BatchingMaxSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.cache.Add(fullTopic, producer)
+
+	return producer, nil
+}
+
 // parsePublishMetadata parse publish metadata.
 func parsePublishMetadata(req *pubsub.PublishRequest) (
 	msg *pulsar.ProducerMessage, err error,
@@ -257,6 +299,12 @@ func parsePublishMetadata(req *pubsub.PublishRequest) (
 			return nil, err
 		}
 	}
+	if val, ok := req.Metadata[pubsub.ScheduledDeliveryTimeKey]; ok {
+		msg.DeliverAt, err = time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return
 }
@@ -340,7 +388,7 @@ func (p *Pulsar) Close() error {
 }
 
 func (p *Pulsar) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureBulkPublish, pubsub.FeatureDelayedDelivery}
 }
 
 // formatTopic formats the topic into pulsar's structure with tenant and namespace.
@@ -58,6 +58,14 @@ func TestParsePublishMetadata(t *testing.T) {
 	assert.Equal(t, val, msg.DeliverAfter)
 	assert.Equal(t, "2021-08-31T11:45:02Z",
 		msg.DeliverAt.Format(time.RFC3339))
+
+	m.Metadata = map[string]string{
+		"scheduledDeliveryTime": "2022-03-01T10:00:00Z",
+	}
+	msg, err = parsePublishMetadata(m)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-03-01T10:00:00Z",
+		msg.DeliverAt.Format(time.RFC3339))
 }
 
 func TestMissingHost(t *testing.T) {
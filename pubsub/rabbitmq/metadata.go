@@ -38,24 +38,27 @@ type metadata struct {
 	maxLenBytes      int64
 	exchangeKind     string
 	publisherConfirm bool
-	concurrency      pubsub.ConcurrencyMode
+	// enableDelayedDelivery requires the rabbitmq_delayed_message_exchange plugin.
+	enableDelayedDelivery bool
+	concurrency           pubsub.ConcurrencyMode
 }
 
 const (
-	metadataConsumerIDKey           = "consumerID"
-	metadataHostKey                 = "host"
-	metadataDurableKey              = "durable"
-	metadataEnableDeadLetterKey     = "enableDeadLetter"
-	metadataDeleteWhenUnusedKey     = "deletedWhenUnused"
-	metadataAutoAckKey              = "autoAck"
-	metadataRequeueInFailureKey     = "requeueInFailure"
-	metadataDeliveryModeKey         = "deliveryMode"
-	metadataPrefetchCountKey        = "prefetchCount"
-	metadataReconnectWaitSecondsKey = "reconnectWaitSeconds"
-	metadataMaxLenKey               = "maxLen"
-	metadataMaxLenBytesKey          = "maxLenBytes"
-	metadataExchangeKindKey         = "exchangeKind"
-	metadataPublisherConfirmKey     = "publisherConfirm"
+	metadataConsumerIDKey            = "consumerID"
+	metadataHostKey                  = "host"
+	metadataDurableKey               = "durable"
+	metadataEnableDeadLetterKey      = "enableDeadLetter"
+	metadataDeleteWhenUnusedKey      = "deletedWhenUnused"
+	metadataAutoAckKey               = "autoAck"
+	metadataRequeueInFailureKey      = "requeueInFailure"
+	metadataDeliveryModeKey          = "deliveryMode"
+	metadataPrefetchCountKey         = "prefetchCount"
+	metadataReconnectWaitSecondsKey  = "reconnectWaitSeconds"
+	metadataMaxLenKey                = "maxLen"
+	metadataMaxLenBytesKey           = "maxLenBytes"
+	metadataExchangeKindKey          = "exchangeKind"
+	metadataPublisherConfirmKey      = "publisherConfirm"
+	metadataEnableDelayedDeliveryKey = "enableDelayedDelivery"
 
 	defaultReconnectWaitSeconds = 3
 )
@@ -158,10 +161,19 @@ func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataEnableDelayedDeliveryKey]; found && val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			result.enableDelayedDelivery = boolVal
+		}
+	}
+
 	c, err := pubsub.Concurrency(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, err
 	}
+	if c == pubsub.Keyed {
+		return &result, fmt.Errorf("%s %s %s is not supported", errorMessagePrefix, pubsub.ConcurrencyKey, c)
+	}
 	result.concurrency = c
 
 	return &result, nil
@@ -297,6 +297,25 @@ func TestCreateMetadata(t *testing.T) {
 			assert.Equal(t, tt.expected, m.enableDeadLetter)
 		})
 	}
+	for _, tt := range booleanFlagTests {
+		t.Run(fmt.Sprintf("enableDelayedDelivery value=%s", tt.in), func(t *testing.T) {
+			fakeProperties := getFakeProperties()
+
+			fakeMetaData := pubsub.Metadata{
+				Properties: fakeProperties,
+			}
+			fakeMetaData.Properties[metadataEnableDelayedDeliveryKey] = tt.in
+
+			// act
+			m, err := createMetadata(fakeMetaData)
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, fakeProperties[metadataHostKey], m.host)
+			assert.Equal(t, fakeProperties[metadataConsumerIDKey], m.consumerID)
+			assert.Equal(t, tt.expected, m.enableDelayedDelivery)
+		})
+	}
 	validExchangeKind := []string{amqp.ExchangeDirect, amqp.ExchangeTopic, amqp.ExchangeFanout, amqp.ExchangeHeaders}
 
 	for _, exchangeKind := range validExchangeKind {
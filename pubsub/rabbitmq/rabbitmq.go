@@ -31,6 +31,7 @@ import (
 
 const (
 	fanoutExchangeKind              = "fanout"
+	delayedMessageExchangeKind      = "x-delayed-message"
 	logMessagePrefix                = "rabbitmq pub/sub:"
 	errorMessagePrefix              = "rabbitmq pub/sub error:"
 	errorChannelNotInitialized      = "channel not initialized"
@@ -45,6 +46,8 @@ const (
 	argMaxLength          = "x-max-length"
 	argMaxLengthBytes     = "x-max-length-bytes"
 	argDeadLetterExchange = "x-dead-letter-exchange"
+	argDelayedType        = "x-delayed-type"
+	headerDelay           = "x-delay"
 	queueModeLazy         = "lazy"
 	reqMetadataRoutingKey = "routingKey"
 )
@@ -184,7 +187,7 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 	return nil
 }
 
-func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
+func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest, headers amqp.Table) (rabbitMQChannelBroker, int, error) {
 	r.channelMutex.Lock()
 	defer r.channelMutex.Unlock()
 
@@ -192,7 +195,8 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
 	}
 
-	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.exchangeKind); err != nil {
+	exchangeKind, exchangeArgs := r.topicExchange()
+	if err := r.ensureExchangeDeclared(r.channel, req.Topic, exchangeKind, exchangeArgs); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
 
 		return r.channel, r.connectionCount, err
@@ -203,6 +207,7 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 	}
 
 	confirm, err := r.channel.PublishWithDeferredConfirm(req.Topic, routingKey, false, false, amqp.Publishing{
+		Headers:      headers,
 		ContentType:  "text/plain",
 		Body:         req.Data,
 		DeliveryMode: r.metadata.deliveryMode,
@@ -229,10 +234,15 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 func (r *rabbitMQ) Publish(req *pubsub.PublishRequest) error {
 	r.logger.Debugf("%s publishing message to %s", logMessagePrefix, req.Topic)
 
+	headers, err := r.publishHeaders(req)
+	if err != nil {
+		return err
+	}
+
 	attempt := 0
 	for {
 		attempt++
-		channel, connectionCount, err := r.publishSync(req)
+		channel, connectionCount, err := r.publishSync(req, headers)
 		if err == nil {
 			return nil
 		}
@@ -275,7 +285,8 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 
 // this function call should be wrapped by channelMutex.
 func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub.SubscribeRequest, queueName string) (*amqp.Queue, error) {
-	err := r.ensureExchangeDeclared(channel, req.Topic, r.metadata.exchangeKind)
+	exchangeKind, exchangeArgs := r.topicExchange()
+	err := r.ensureExchangeDeclared(channel, req.Topic, exchangeKind, exchangeArgs)
 	if err != nil {
 		r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, queueName, err)
 
@@ -288,7 +299,7 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		// declare dead letter exchange
 		dlxName := fmt.Sprintf(defaultDeadLetterExchangeFormat, queueName)
 		dlqName := fmt.Sprintf(defaultDeadLetterQueueFormat, queueName)
-		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind)
+		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind, nil)
 		if err != nil {
 			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, dlqName, err)
 
@@ -496,10 +507,10 @@ func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic str
 }
 
 // this function call should be wrapped by channelMutex.
-func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string) error {
+func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string, args amqp.Table) error {
 	if !r.containsExchange(exchange) {
 		r.logger.Debugf("%s declaring exchange '%s' of kind '%s'", logMessagePrefix, exchange, exchangeKind)
-		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, nil)
+		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, args)
 		if err != nil {
 			r.logger.Errorf("%s ensureExchangeDeclared: channel.ExchangeDeclare failed: %v", logMessagePrefix, err)
 
@@ -512,6 +523,39 @@ func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchang
 	return nil
 }
 
+// topicExchange returns the kind and the arguments of the exchanges of the topics.
+// With delayed delivery enabled, they are delayed message exchanges, provided by
+// the rabbitmq_delayed_message_exchange plugin, routing as the exchangeKind.
+func (r *rabbitMQ) topicExchange() (string, amqp.Table) {
+	if r.metadata.enableDelayedDelivery {
+		return delayedMessageExchangeKind, amqp.Table{argDelayedType: r.metadata.exchangeKind}
+	}
+
+	return r.metadata.exchangeKind, nil
+}
+
+// publishHeaders returns the headers delaying the delivery of the message
+// published by req, if requested by its metadata.
+func (r *rabbitMQ) publishHeaders(req *pubsub.PublishRequest) (amqp.Table, error) {
+	deliveryTime, ok, err := pubsub.DeliveryTime(req.Metadata, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	if !r.metadata.enableDelayedDelivery {
+		return nil, fmt.Errorf("%s delayed delivery requires %s to be enabled", errorMessagePrefix, metadataEnableDelayedDeliveryKey)
+	}
+
+	delay := time.Until(deliveryTime).Milliseconds()
+	if delay < 0 {
+		delay = 0
+	}
+
+	return amqp.Table{headerDelay: delay}, nil
+}
+
 // this function call should be wrapped by channelMutex.
 func (r *rabbitMQ) containsExchange(exchange string) bool {
 	_, exists := r.declaredExchanges[exchange]
@@ -564,6 +608,10 @@ func (r *rabbitMQ) Close() error {
 }
 
 func (r *rabbitMQ) Features() []pubsub.Feature {
+	if r.metadata != nil && r.metadata.enableDelayedDelivery {
+		return []pubsub.Feature{pubsub.FeatureDelayedDelivery}
+	}
+
 	return nil
 }
 
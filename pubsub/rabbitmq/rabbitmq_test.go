@@ -28,7 +28,8 @@ import (
 
 func newBroker() *rabbitMQInMemoryBroker {
 	return &rabbitMQInMemoryBroker{
-		buffer: make(chan amqp.Delivery, 2),
+		buffer:    make(chan amqp.Delivery, 2),
+		exchanges: make(map[string]amqp.Table),
 	}
 }
 
@@ -155,6 +156,62 @@ func TestPublishAndSubscribe(t *testing.T) {
 	assert.Equal(t, "foo bar", lastMessage)
 }
 
+func TestPublishDelayed(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{
+		Properties: map[string]string{
+			metadataHostKey:                  "anyhost",
+			metadataConsumerIDKey:            "consumer",
+			metadataExchangeKindKey:          amqp.ExchangeTopic,
+			metadataEnableDelayedDeliveryKey: "true",
+		},
+	}
+	err := pubsubRabbitMQ.Init(metadata)
+	assert.Nil(t, err)
+	assert.Equal(t, []pubsub.Feature{pubsub.FeatureDelayedDelivery}, pubsubRabbitMQ.Features())
+
+	topic := "mytopic"
+	err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{
+		Topic:    topic,
+		Data:     []byte("hello world"),
+		Metadata: map[string]string{pubsub.DeliverAfterKey: "1m"},
+	})
+	assert.Nil(t, err)
+	<-broker.buffer
+
+	assert.Equal(t, amqp.Table{
+		"kind": delayedMessageExchangeKind,
+		"args": amqp.Table{argDelayedType: amqp.ExchangeTopic},
+	}, broker.exchanges[topic])
+	delay, ok := broker.lastHeaders[headerDelay].(int64)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Minute.Milliseconds(), delay, float64(time.Second.Milliseconds()))
+}
+
+func TestPublishDelayedNotEnabled(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{
+		Properties: map[string]string{
+			metadataHostKey:       "anyhost",
+			metadataConsumerIDKey: "consumer",
+		},
+	}
+	err := pubsubRabbitMQ.Init(metadata)
+	assert.Nil(t, err)
+	assert.Empty(t, pubsubRabbitMQ.Features())
+
+	err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{
+		Topic:    "mytopic",
+		Data:     []byte("hello world"),
+		Metadata: map[string]string{pubsub.DeliverAfterKey: "1m"},
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), metadataEnableDelayedDeliveryKey)
+	assert.Empty(t, broker.buffer)
+}
+
 func TestPublishReconnect(t *testing.T) {
 	broker := newBroker()
 	pubsubRabbitMQ := newRabbitMQTest(broker)
@@ -342,6 +399,9 @@ type rabbitMQInMemoryBroker struct {
 
 	connectCount int
 	closeCount   int
+
+	exchanges   map[string]amqp.Table
+	lastHeaders amqp.Table
 }
 
 func (r *rabbitMQInMemoryBroker) Qos(prefetchCount, prefetchSize int, global bool) error {
@@ -359,6 +419,7 @@ func (r *rabbitMQInMemoryBroker) PublishWithDeferredConfirm(exchange string, key
 		return nil, errors.New(errorChannelConnection)
 	}
 
+	r.lastHeaders = msg.Headers
 	r.buffer <- createAMQPMessage(msg.Body)
 
 	return nil, nil
@@ -385,6 +446,8 @@ func (r *rabbitMQInMemoryBroker) Ack(tag uint64, multiple bool) error {
 }
 
 func (r *rabbitMQInMemoryBroker) ExchangeDeclare(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error {
+	r.exchanges[name] = amqp.Table{"kind": kind, "args": args}
+
 	return nil
 }
 
@@ -15,6 +15,8 @@ package redis
 
 import (
 	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 type metadata struct {
@@ -28,7 +30,12 @@ type metadata struct {
 	queueDepth uint
 	// The number of concurrent workers that are processing messages
 	concurrency uint
+	// Whether the messages with the same partition key are processed in order
+	concurrencyMode pubsub.ConcurrencyMode
 
 	// the max len of stream
 	maxLenApprox int64
+
+	// The interval between checking for delayed messages that are due (0 disables their delivery)
+	delayedDeliveryInterval time.Duration
 }
@@ -15,16 +15,21 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/dispatcher"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const (
@@ -35,6 +40,16 @@ const (
 	queueDepth        = "queueDepth"
 	concurrency       = "concurrency"
 	maxLenApprox      = "maxLenApprox"
+
+	delayedDeliveryInterval = "delayedDeliveryInterval"
+
+	// delayedMessagesKeyPrefix prefixes the keys of the sorted sets of the
+	// messages which are not due yet, one per stream, scored by their delivery
+	// time in milliseconds.
+	delayedMessagesKeyPrefix = "dapr:pubsub:delayed:"
+	// delayedMessagesCount is the maximum number of due messages moved to
+	// their streams at once.
+	delayedMessagesCount = 100
 )
 
 // redisStreams handles consuming from a Redis stream using
@@ -50,6 +65,14 @@ type redisStreams struct {
 	logger         logger.Logger
 
 	queue chan redisMessageWrapper
+	// dispatcher replaces queue in the keyed concurrency mode. The ordering
+	// of a key is best-effort: a failed message is redelivered by
+	// `reclaimPendingMessagesLoop`, after the newer messages of its key,
+	// unless the retry policy of the subscription retries it beforehand.
+	dispatcher *dispatcher.Keyed
+	// delayedStreams holds the streams whose delayed messages are moved
+	// by this instance: the ones it subscribed or published delayed messages to.
+	delayedStreams sync.Map
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -64,6 +87,14 @@ type redisMessageWrapper struct {
 	handler   pubsub.Handler
 }
 
+// delayedMessage is a message parked in the delayed messages sorted set of
+// its stream. Its ID keeps the members of identical messages distinct.
+type delayedMessage struct {
+	ID       string            `json:"id"`
+	Data     []byte            `json:"data"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
 // NewRedisStreams returns a new redis streams pub-sub implementation.
 func NewRedisStreams(logger logger.Logger) pubsub.PubSub {
 	return &redisStreams{logger: logger}
@@ -76,6 +107,8 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		redeliverInterval: 15 * time.Second,
 		queueDepth:        100,
 		concurrency:       10,
+
+		delayedDeliveryInterval: time.Second,
 	}
 
 	if val, ok := meta.Properties[consumerID]; ok && val != "" {
@@ -104,6 +137,16 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		}
 	}
 
+	if val, ok := meta.Properties[delayedDeliveryInterval]; ok && val != "" {
+		if delayedDeliveryIntervalMs, err := strconv.ParseUint(val, 10, 64); err == nil {
+			m.delayedDeliveryInterval = time.Duration(delayedDeliveryIntervalMs) * time.Millisecond
+		} else if d, err := time.ParseDuration(val); err == nil {
+			m.delayedDeliveryInterval = d
+		} else {
+			return m, fmt.Errorf("redis streams error: can't parse delayedDeliveryInterval field: %s", err)
+		}
+	}
+
 	if val, ok := meta.Properties[queueDepth]; ok && val != "" {
 		queueDepth, err := strconv.ParseUint(val, 10, 64)
 		if err != nil {
@@ -120,6 +163,12 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		m.concurrency = uint(concurrency)
 	}
 
+	c, err := pubsub.Concurrency(meta.Properties)
+	if err != nil {
+		return m, fmt.Errorf("redis streams error: can't parse %s: %s", pubsub.ConcurrencyKey, err)
+	}
+	m.concurrencyMode = c
+
 	if val, ok := meta.Properties[maxLenApprox]; ok && val != "" {
 		maxLenApprox, err := strconv.ParseInt(val, 10, 64)
 		if err != nil {
@@ -147,6 +196,15 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 	if _, err = r.client.Ping(r.ctx).Result(); err != nil {
 		return fmt.Errorf("redis streams: error connecting to redis at %s: %s", r.clientSettings.Host, err)
 	}
+
+	go r.deliverDelayedMessagesLoop(r.ctx)
+
+	if r.metadata.concurrencyMode == pubsub.Keyed {
+		r.dispatcher = dispatcher.NewKeyed(r.ctx, int(r.metadata.concurrency), int(r.metadata.queueDepth))
+
+		return nil
+	}
+
 	r.queue = make(chan redisMessageWrapper, int(r.metadata.queueDepth))
 
 	for i := uint(0); i < r.metadata.concurrency; i++ {
@@ -157,29 +215,134 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 }
 
 func (r *redisStreams) Publish(req *pubsub.PublishRequest) error {
-	_, err := r.client.XAdd(r.ctx, &redis.XAddArgs{
-		Stream:       req.Topic,
-		MaxLenApprox: r.metadata.maxLenApprox,
-		Values:       map[string]interface{}{"data": req.Data},
-	}).Result()
+	cmd, err := r.publishCmd(r.client, req.Topic, req.Data, req.Metadata)
 	if err != nil {
 		return fmt.Errorf("redis streams: error from publish: %s", err)
 	}
+	if err = cmd.Err(); err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
 
 	return nil
 }
 
+// publishCmd adds a message to the stream, or, if its delivery is delayed,
+// to the delayed messages sorted set, from which it is moved to the stream
+// by `deliverDelayedMessagesLoop` when due.
+func (r *redisStreams) publishCmd(c redis.Cmdable, stream string, data []byte, metadata map[string]string) (redis.Cmder, error) {
+	deliveryTime, delayed, err := pubsub.DeliveryTime(metadata, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !delayed || !deliveryTime.After(time.Now()) {
+		return c.XAdd(r.ctx, &redis.XAddArgs{
+			Stream:       stream,
+			MaxLenApprox: r.metadata.maxLenApprox,
+			Values:       streamValues(data, metadata),
+		}), nil
+	}
+
+	member, err := json.Marshal(delayedMessage{
+		ID:       uuid.New().String(),
+		Data:     data,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.delayedStreams.Store(stream, struct{}{})
+
+	return c.ZAdd(r.ctx, delayedMessagesKey(stream), &redis.Z{
+		Score:  float64(deliveryTime.UnixMilli()),
+		Member: member,
+	}), nil
+}
+
+// BulkPublish adds the entries to the stream in a single pipeline.
+func (r *redisStreams) BulkPublish(req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]redis.Cmder, len(req.Entries))
+	errs := make([]error, len(req.Entries))
+	for i, entry := range req.Entries {
+		cmd, err := r.publishCmd(pipe, req.Topic, entry.Event, req.EntryMetadata(&entry))
+		if err != nil {
+			errs[i] = fmt.Errorf("redis streams: error from publish: %s", err)
+			continue
+		}
+		cmds[i] = cmd
+	}
+	// The error of each command is checked below.
+	_, _ = pipe.Exec(r.ctx)
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			errs[i] = fmt.Errorf("redis streams: error from publish: %s", err)
+		}
+	}
+
+	return pubsub.NewBulkPublishResponse(req.Entries, errs)
+}
+
 func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	err := r.client.XGroupCreateMkStream(ctx, req.Topic, r.metadata.consumerID, "0").Err()
+	handler, err := pubsub.NewDeadLetterHandler(req, handler, r.Publish)
+	if err != nil {
+		return err
+	}
+
+	// The failed messages are redelivered after the processing timeout,
+	// so they are not retried by default.
+	retryConfig, err := pubsub.NewRetryConfig(req.Metadata, retry.DefaultConfigWithNoRetry())
+	if err != nil {
+		return err
+	}
+	handler = pubsub.NewRetryHandler(handler, retryConfig, r.logger)
+
+	if err = r.createConsumerGroup(ctx, req.Topic); err != nil {
+		return err
+	}
+
+	go r.pollNewMessagesLoop(ctx, req.Topic, handler)
+	go r.reclaimPendingMessagesLoop(ctx, req.Topic, func(msgs []redis.XMessage) {
+		r.enqueueMessages(ctx, req.Topic, handler, msgs)
+	})
+
+	return nil
+}
+
+// BulkSubscribe reads the messages of the stream in batches, which are
+// handed to handler as a whole. The messages which failed remain pending
+// and are redelivered one by one by `reclaimPendingMessagesLoop`.
+func (r *redisStreams) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	config, err := pubsub.NewBulkSubscribeConfig(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	if err = r.createConsumerGroup(ctx, req.Topic); err != nil {
+		return err
+	}
+
+	go r.pollBulkMessagesLoop(ctx, req.Topic, handler, config)
+	go r.reclaimPendingMessagesLoop(ctx, req.Topic, func(msgs []redis.XMessage) {
+		r.processBulk(ctx, req.Topic, handler, msgs)
+	})
+
+	return nil
+}
+
+func (r *redisStreams) createConsumerGroup(ctx context.Context, stream string) error {
+	r.delayedStreams.Store(stream, struct{}{})
+
+	err := r.client.XGroupCreateMkStream(ctx, stream, r.metadata.consumerID, "0").Err()
 	// Ignore BUSYGROUP errors
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		r.logger.Errorf("redis streams: %s", err)
 		return err
 	}
 
-	go r.pollNewMessagesLoop(ctx, req.Topic, handler)
-	go r.reclaimPendingMessagesLoop(ctx, req.Topic, handler)
-
 	return nil
 }
 
@@ -190,6 +353,16 @@ func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handl
 	for _, msg := range msgs {
 		rmsg := createRedisMessageWrapper(ctx, stream, handler, msg)
 
+		if r.dispatcher != nil {
+			if err := r.dispatcher.Dispatch(ctx, rmsg.message.Metadata[pubsub.PartitionKeyMetadataKey], func() {
+				r.processMessage(rmsg)
+			}); err != nil {
+				return
+			}
+
+			continue
+		}
+
 		select {
 		// Might block if the queue is full so we need the ctx.Done below.
 		case r.queue <- rmsg:
@@ -204,25 +377,50 @@ func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handl
 // createRedisMessageWrapper encapsulates the Redis message, message identifier, and handler
 // in `redisMessage` for processing.
 func createRedisMessageWrapper(ctx context.Context, stream string, handler pubsub.Handler, msg redis.XMessage) redisMessageWrapper {
-	var data []byte
+	return redisMessageWrapper{
+		ctx: ctx,
+		message: pubsub.NewMessage{
+			Topic:    stream,
+			Data:     messageData(msg),
+			Metadata: messageMetadata(msg),
+		},
+		messageID: msg.ID,
+		handler:   handler,
+	}
+}
+
+// messageData returns the data field of msg.
+func messageData(msg redis.XMessage) []byte {
 	if dataValue, exists := msg.Values["data"]; exists && dataValue != nil {
 		switch v := dataValue.(type) {
 		case string:
-			data = []byte(v)
+			return []byte(v)
 		case []byte:
-			data = v
+			return v
 		}
 	}
 
-	return redisMessageWrapper{
-		ctx: ctx,
-		message: pubsub.NewMessage{
-			Topic: stream,
-			Data:  data,
-		},
-		messageID: msg.ID,
-		handler:   handler,
+	return nil
+}
+
+// streamValues returns the fields of the stream entry of a message, i.e.
+// its data and its partition key, if any.
+func streamValues(data []byte, metadata map[string]string) map[string]interface{} {
+	values := map[string]interface{}{"data": data}
+	if partitionKey := metadata[pubsub.PartitionKeyMetadataKey]; partitionKey != "" {
+		values[pubsub.PartitionKeyMetadataKey] = partitionKey
+	}
+
+	return values
+}
+
+// messageMetadata returns the metadata of msg, i.e. its partition key.
+func messageMetadata(msg redis.XMessage) map[string]string {
+	if partitionKey, ok := msg.Values[pubsub.PartitionKeyMetadataKey].(string); ok {
+		return map[string]string{pubsub.PartitionKeyMetadataKey: partitionKey}
 	}
+
+	return nil
 }
 
 // worker runs in separate goroutine(s) and pull messages from a channel for processing.
@@ -298,9 +496,99 @@ func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, h
 	}
 }
 
+// pollBulkMessagesLoop reads batches of new messages with `readBulk` and
+// processes them with `processBulk`.
+func (r *redisStreams) pollBulkMessagesLoop(ctx context.Context, stream string, handler pubsub.BulkHandler, config pubsub.BulkSubscribeConfig) {
+	for {
+		// Return on cancelation
+		if ctx.Err() != nil {
+			return
+		}
+
+		msgs, err := r.readBulk(ctx, stream, config)
+		if err != nil {
+			r.logger.Errorf("redis streams: error reading from stream %s: %s", stream, err)
+		}
+		if len(msgs) > 0 {
+			r.processBulk(ctx, stream, handler, msgs)
+		}
+	}
+}
+
+// readBulk reads new messages until it has MaxMessagesCount messages or
+// MaxAwaitDuration is elapsed.
+func (r *redisStreams) readBulk(ctx context.Context, stream string, config pubsub.BulkSubscribeConfig) ([]redis.XMessage, error) {
+	deadline := time.Now().Add(config.MaxAwaitDuration)
+	msgs := make([]redis.XMessage, 0, config.MaxMessagesCount)
+	for len(msgs) < config.MaxMessagesCount {
+		// A block of 0 would block forever.
+		block := time.Until(deadline)
+		if block < time.Millisecond {
+			break
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    r.metadata.consumerID,
+			Consumer: r.metadata.consumerID,
+			Streams:  []string{stream, ">"},
+			Count:    int64(config.MaxMessagesCount - len(msgs)),
+			Block:    block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			break
+		}
+		if err != nil {
+			return msgs, err
+		}
+		for _, s := range streams {
+			msgs = append(msgs, s.Messages...)
+		}
+	}
+
+	return msgs, nil
+}
+
+// processBulk hands msgs to handler, and acknowledges the messages which
+// processed successfully.
+func (r *redisStreams) processBulk(ctx context.Context, stream string, handler pubsub.BulkHandler, msgs []redis.XMessage) {
+	r.logger.Debugf("Processing %d Redis messages", len(msgs))
+	bulk := &pubsub.BulkMessage{
+		Entries: make([]pubsub.BulkMessageEntry, len(msgs)),
+		Topic:   stream,
+	}
+	for i, msg := range msgs {
+		bulk.Entries[i] = pubsub.BulkMessageEntry{EntryID: msg.ID, Event: messageData(msg), Metadata: messageMetadata(msg)}
+	}
+
+	handlerCtx := ctx
+	if r.metadata.processingTimeout != 0 && r.metadata.redeliverInterval != 0 {
+		var cancel context.CancelFunc
+		handlerCtx, cancel = context.WithTimeout(ctx, r.metadata.processingTimeout)
+		defer cancel()
+	}
+	statuses, err := handler(handlerCtx, bulk)
+
+	ids := make([]string, 0, len(msgs))
+	for i, err := range pubsub.BulkEntryErrors(bulk, statuses, err) {
+		if err != nil {
+			r.logger.Errorf("Error processing Redis message %s: %v", msgs[i].ID, err)
+
+			continue
+		}
+		ids = append(ids, msgs[i].ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := r.client.XAck(ctx, stream, r.metadata.consumerID, ids...).Err(); err != nil {
+		r.logger.Errorf("Error acknowledging Redis messages %v: %v", ids, err)
+	}
+}
+
 // reclaimPendingMessagesLoop periodically reclaims pending messages
-// based on the `redeliverInterval` setting.
-func (r *redisStreams) reclaimPendingMessagesLoop(ctx context.Context, stream string, handler pubsub.Handler) {
+// based on the `redeliverInterval` setting, and hands them to process.
+func (r *redisStreams) reclaimPendingMessagesLoop(ctx context.Context, stream string, process func(msgs []redis.XMessage)) {
 	// Having a `processingTimeout` or `redeliverInterval` means that
 	// redelivery is disabled so we just return out of the goroutine.
 	if r.metadata.processingTimeout == 0 || r.metadata.redeliverInterval == 0 {
@@ -308,7 +596,7 @@ func (r *redisStreams) reclaimPendingMessagesLoop(ctx context.Context, stream st
 	}
 
 	// Do an initial reclaim call
-	r.reclaimPendingMessages(ctx, stream, handler)
+	r.reclaimPendingMessages(ctx, stream, process)
 
 	reclaimTicker := time.NewTicker(r.metadata.redeliverInterval)
 
@@ -318,14 +606,15 @@ func (r *redisStreams) reclaimPendingMessagesLoop(ctx context.Context, stream st
 			return
 
 		case <-reclaimTicker.C:
-			r.reclaimPendingMessages(ctx, stream, handler)
+			r.reclaimPendingMessages(ctx, stream, process)
 		}
 	}
 }
 
 // reclaimPendingMessages handles reclaiming messages that previously failed to process and
-// funneling them to the message channel by calling `enqueueMessages`.
-func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string, handler pubsub.Handler) {
+// handing them to process, which funnels them to the message channel by calling `enqueueMessages`
+// or delivers them in batches by calling `processBulk`.
+func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string, process func(msgs []redis.XMessage)) {
 	for {
 		// Retrieve pending messages for this stream and consumer
 		pendingResult, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
@@ -368,8 +657,8 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 			break
 		}
 
-		// Enqueue claimed messages
-		r.enqueueMessages(ctx, stream, handler, claimResult)
+		// Process claimed messages
+		process(claimResult)
 
 		// If the Redis nil error is returned, it means somes message in the pending
 		// state no longer exist. We need to acknowledge these messages to
@@ -385,14 +674,14 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 				delete(expectedMsgIDs, claimed.ID)
 			}
 
-			r.removeMessagesThatNoLongerExistFromPending(ctx, stream, expectedMsgIDs, handler)
+			r.removeMessagesThatNoLongerExistFromPending(ctx, stream, expectedMsgIDs, process)
 		}
 	}
 }
 
 // removeMessagesThatNoLongerExistFromPending attempts to claim messages individually so that messages in the pending list
 // that no longer exist can be removed from the pending list. This is done by calling `XACK`.
-func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Context, stream string, messageIDs map[string]struct{}, handler pubsub.Handler) {
+func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Context, stream string, messageIDs map[string]struct{}, process func(msgs []redis.XMessage)) {
 	// Check each message ID individually.
 	for pendingID := range messageIDs {
 		claimResultSingleMsg, err := r.client.XClaim(ctx, &redis.XClaimArgs{
@@ -416,11 +705,96 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Co
 			}
 		} else {
 			// This should not happen but if it does the message should be processed.
-			r.enqueueMessages(ctx, stream, handler, claimResultSingleMsg)
+			process(claimResultSingleMsg)
+		}
+	}
+}
+
+// deliverDelayedMessagesLoop periodically moves the delayed messages which
+// are due to their streams.
+func (r *redisStreams) deliverDelayedMessagesLoop(ctx context.Context) {
+	// A `delayedDeliveryInterval` of 0 means that the delayed messages
+	// are delivered by other instances.
+	if r.metadata.delayedDeliveryInterval == 0 {
+		return
+	}
+
+	deliverTicker := time.NewTicker(r.metadata.delayedDeliveryInterval)
+	defer deliverTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-deliverTicker.C:
+			r.deliverDelayedMessages(ctx)
+		}
+	}
+}
+
+// deliverDelayedMessages moves the delayed messages which are due to the
+// streams known to this instance.
+func (r *redisStreams) deliverDelayedMessages(ctx context.Context) {
+	r.delayedStreams.Range(func(key, _ interface{}) bool {
+		r.deliverStreamDelayedMessages(ctx, key.(string))
+
+		return ctx.Err() == nil
+	})
+}
+
+// deliverStreamDelayedMessages moves the delayed messages of stream which are
+// due to it. They are removed from the sorted set and added to the stream in
+// a single transaction, which is aborted if another instance changed the
+// sorted set meanwhile, so that a message is delivered exactly once even when
+// several instances poll the sorted set.
+func (r *redisStreams) deliverStreamDelayedMessages(ctx context.Context, stream string) {
+	key := delayedMessagesKey(stream)
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		members, err := tx.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Count: delayedMessagesCount,
+		}).Result()
+		if err != nil || len(members) == 0 {
+			return err
 		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, member := range members {
+				pipe.ZRem(ctx, key, member)
+
+				var msg delayedMessage
+				if err := json.Unmarshal([]byte(member), &msg); err != nil {
+					// The message is dropped since it could never be delivered.
+					r.logger.Errorf("redis streams: error parsing delayed message: %s", err)
+					continue
+				}
+				pipe.XAdd(ctx, &redis.XAddArgs{
+					Stream:       stream,
+					MaxLenApprox: r.metadata.maxLenApprox,
+					Values:       streamValues(msg.Data, msg.Metadata),
+				})
+			}
+
+			return nil
+		})
+
+		return err
+	}, key)
+	if err != nil && !errors.Is(err, redis.Nil) && !errors.Is(err, redis.TxFailedErr) {
+		// The messages are left in the sorted set and retried on the next tick.
+		r.logger.Errorf("redis streams: error delivering delayed messages to stream %s: %s", stream, err)
 	}
 }
 
+// delayedMessagesKey returns the key of the sorted set of the delayed messages
+// of stream. Its hash tag puts it in the cluster slot of the stream, which
+// lets them be updated in the same transaction.
+func delayedMessagesKey(stream string) string {
+	return delayedMessagesKeyPrefix + "{" + stream + "}"
+}
+
 func (r *redisStreams) Close() error {
 	r.cancel()
 
@@ -428,7 +802,7 @@ func (r *redisStreams) Close() error {
 }
 
 func (r *redisStreams) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureBulkPublish, pubsub.FeatureBulkSubscribe, pubsub.FeatureDelayedDelivery}
 }
 
 func (r *redisStreams) Ping() error {
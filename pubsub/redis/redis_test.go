@@ -19,11 +19,15 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/dispatcher"
 	"github.com/dapr/kit/logger"
 )
 
@@ -50,6 +54,27 @@ func TestParseRedisMetadata(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, fakeProperties[consumerID], m.consumerID)
 		assert.Equal(t, int64(1000), m.maxLenApprox)
+		assert.Equal(t, pubsub.Parallel, m.concurrencyMode)
+	})
+
+	t.Run("keyed concurrency mode", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[pubsub.ConcurrencyKey] = string(pubsub.Keyed)
+
+		m, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		assert.NoError(t, err)
+		assert.Equal(t, pubsub.Keyed, m.concurrencyMode)
+	})
+
+	t.Run("delayedDeliveryInterval is set", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[delayedDeliveryInterval] = "500ms"
+
+		m, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 500*time.Millisecond, m.delayedDeliveryInterval)
 	})
 
 	t.Run("consumerID is not given", func(t *testing.T) {
@@ -107,6 +132,196 @@ func TestProcessStreams(t *testing.T) {
 	assert.Equal(t, 3, messageCount)
 }
 
+func TestBulkPublish(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	testRedisStream := &redisStreams{
+		client: redis.NewClient(&redis.Options{Addr: s.Addr()}),
+		logger: logger.NewLogger("test"),
+		ctx:    context.Background(),
+	}
+
+	res, err := testRedisStream.BulkPublish(&pubsub.BulkPublishRequest{
+		Topic: "orders",
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryID: "1", Event: []byte("first")},
+			{EntryID: "2", Event: []byte("second"), Metadata: map[string]string{pubsub.PartitionKeyMetadataKey: "order-1"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pubsub.BulkPublishResponseEntry{
+		{EntryID: "1", Status: pubsub.PublishSucceeded},
+		{EntryID: "2", Status: pubsub.PublishSucceeded},
+	}, res.Statuses)
+
+	msgs, err := testRedisStream.client.XRange(context.Background(), "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "first", msgs[0].Values["data"])
+	assert.Equal(t, "second", msgs[1].Values["data"])
+	assert.Nil(t, messageMetadata(msgs[0]))
+	assert.Equal(t, map[string]string{pubsub.PartitionKeyMetadataKey: "order-1"}, messageMetadata(msgs[1]))
+}
+
+func TestPublishDelayed(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	testRedisStream := &redisStreams{
+		client: redis.NewClient(&redis.Options{Addr: s.Addr()}),
+		logger: logger.NewLogger("test"),
+		ctx:    context.Background(),
+	}
+
+	err = testRedisStream.Publish(&pubsub.PublishRequest{
+		Topic:    "orders",
+		Data:     []byte("later"),
+		Metadata: map[string]string{pubsub.DeliverAfterKey: "1h", pubsub.PartitionKeyMetadataKey: "order-1"},
+	})
+	require.NoError(t, err)
+	err = testRedisStream.Publish(&pubsub.PublishRequest{
+		Topic:    "orders",
+		Data:     []byte("now"),
+		Metadata: map[string]string{pubsub.ScheduledDeliveryTimeKey: "2022-03-01T10:00:00Z"},
+	})
+	require.NoError(t, err)
+	err = testRedisStream.Publish(&pubsub.PublishRequest{
+		Topic:    "orders",
+		Data:     []byte("invalid"),
+		Metadata: map[string]string{pubsub.DeliverAfterKey: "later"},
+	})
+	assert.Error(t, err)
+
+	msgs, err := testRedisStream.client.XRange(context.Background(), "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "now", msgs[0].Values["data"])
+
+	// Make the delayed message due.
+	members, err := testRedisStream.client.ZRange(context.Background(), delayedMessagesKey("orders"), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	require.NoError(t, testRedisStream.client.ZAdd(context.Background(), delayedMessagesKey("orders"), &redis.Z{Member: members[0]}).Err())
+
+	testRedisStream.deliverDelayedMessages(context.Background())
+
+	msgs, err = testRedisStream.client.XRange(context.Background(), "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "later", msgs[1].Values["data"])
+	assert.Equal(t, map[string]string{pubsub.PartitionKeyMetadataKey: "order-1"}, messageMetadata(msgs[1]))
+
+	count, err := testRedisStream.client.ZCard(context.Background(), delayedMessagesKey("orders")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// commandRecorder records the commands sent to Redis.
+type commandRecorder struct {
+	commands [][]interface{}
+}
+
+func (r *commandRecorder) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	r.commands = append(r.commands, cmd.Args())
+
+	return ctx, nil
+}
+
+func (r *commandRecorder) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (r *commandRecorder) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *commandRecorder) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestProcessBulk(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	recorder := &commandRecorder{}
+	client.AddHook(recorder)
+	testRedisStream := &redisStreams{
+		client:   client,
+		logger:   logger.NewLogger("test"),
+		metadata: metadata{consumerID: "fakeConsumer"},
+	}
+
+	var batch []string
+	testRedisStream.processBulk(context.Background(), "orders", func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+		assert.Equal(t, "orders", msg.Topic)
+		statuses := []pubsub.BulkSubscribeResponseEntry{}
+		for _, entry := range msg.Entries {
+			batch = append(batch, string(entry.Event))
+			if string(entry.Event) == "fail" {
+				statuses = append(statuses, pubsub.BulkSubscribeResponseEntry{EntryID: entry.EntryID, Error: errors.New("failed")})
+			}
+		}
+
+		return statuses, nil
+	}, []redis.XMessage{
+		{ID: "1-0", Values: map[string]interface{}{"data": "a"}},
+		{ID: "2-0", Values: map[string]interface{}{"data": "fail"}},
+		{ID: "3-0", Values: map[string]interface{}{"data": "c"}},
+	})
+
+	assert.Equal(t, []string{"a", "fail", "c"}, batch)
+	assert.Equal(t, [][]interface{}{{"xack", "orders", "fakeConsumer", "1-0", "3-0"}}, recorder.commands)
+}
+
+func TestProcessStreamsKeyed(t *testing.T) {
+	var (
+		lock      sync.Mutex
+		wg        sync.WaitGroup
+		processed = map[string][]string{}
+	)
+	fakeHandler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		defer wg.Done()
+
+		key := msg.Metadata[pubsub.PartitionKeyMetadataKey]
+		lock.Lock()
+		processed[key] = append(processed[key], string(msg.Data))
+		lock.Unlock()
+
+		// return fake error to skip executing redis client command
+		return errors.New("fake error")
+	}
+
+	testRedisStream := &redisStreams{logger: logger.NewLogger("test")}
+	testRedisStream.ctx, testRedisStream.cancel = context.WithCancel(context.Background())
+	defer testRedisStream.cancel()
+	testRedisStream.dispatcher = dispatcher.NewKeyed(testRedisStream.ctx, 4, 10)
+
+	msgs := make([]redis.XMessage, 30)
+	for i := range msgs {
+		msgs[i] = redis.XMessage{
+			ID: fmt.Sprintf("%d", i),
+			Values: map[string]interface{}{
+				"data":                         fmt.Sprintf("%02d", i),
+				pubsub.PartitionKeyMetadataKey: fmt.Sprintf("key-%d", i%3),
+			},
+		}
+	}
+	wg.Add(len(msgs))
+	testRedisStream.enqueueMessages(context.Background(), "orders", fakeHandler, msgs)
+	wg.Wait()
+
+	require.Len(t, processed, 3)
+	for key, data := range processed {
+		require.Len(t, data, 10, key)
+		assert.IsIncreasing(t, data, key)
+	}
+}
+
 func generateRedisStreamTestData(topicCount, messageCount int, data string) []redis.XMessage {
 	generateXMessage := func(id int) redis.XMessage {
 		return redis.XMessage{
@@ -35,3 +35,20 @@ type NewMessage struct {
 	Metadata    map[string]string `json:"metadata"`
 	ContentType *string           `json:"contentType,omitempty"`
 }
+
+// BulkMessageEntry is a message published as part of a BulkPublishRequest.
+type BulkMessageEntry struct {
+	EntryID     string            `json:"entryId"`
+	Event       []byte            `json:"event"`
+	ContentType *string           `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// BulkPublishRequest is the request to publish several messages to a topic at once.
+// The metadata of an entry takes precedence over the metadata of the request.
+type BulkPublishRequest struct {
+	Entries    []BulkMessageEntry `json:"entries"`
+	PubsubName string             `json:"pubsubname"`
+	Topic      string             `json:"topic"`
+	Metadata   map[string]string  `json:"metadata"`
+}
@@ -29,3 +29,26 @@ const (
 type AppResponse struct {
 	Status AppResponseStatus `json:"status"`
 }
+
+// BulkPublishStatus represents the status of an entry of a bulk publish request.
+type BulkPublishStatus string
+
+const (
+	// PublishSucceeded means the entry is published.
+	PublishSucceeded BulkPublishStatus = "SUCCESS"
+	// PublishFailed means the entry could not be published.
+	PublishFailed BulkPublishStatus = "FAILED"
+)
+
+// BulkPublishResponseEntry is the outcome of publishing an entry of a bulk publish request.
+type BulkPublishResponseEntry struct {
+	EntryID string            `json:"entryId"`
+	Status  BulkPublishStatus `json:"status"`
+	Error   error             `json:"-"`
+}
+
+// BulkPublishResponse is the response of a bulk publish request, with the
+// outcome of each entry in the order of the request.
+type BulkPublishResponse struct {
+	Statuses []BulkPublishResponseEntry `json:"statuses"`
+}
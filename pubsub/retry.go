@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+// RetryKeyPrefix is the prefix of the subscribe metadata keys configuring the
+// retries of the deliveries of a message to the handler, i.e. retryPolicy
+// (constant or exponential), retryDuration, retryInitialInterval,
+// retryMaxInterval, retryMaxElapsedTime and retryMaxRetries.
+const RetryKeyPrefix = "retry"
+
+// HasRetryConfig returns true if the subscribe metadata configures the
+// retries of the deliveries.
+func HasRetryConfig(metadata map[string]string) bool {
+	for k, v := range metadata {
+		if strings.HasPrefix(k, RetryKeyPrefix) && v != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewRetryConfig returns the retry policy configured in the subscribe
+// metadata. The settings which are not set are taken from defaults.
+func NewRetryConfig(metadata map[string]string, defaults retry.Config) (retry.Config, error) {
+	config := defaults
+	if err := retry.DecodeConfigWithPrefix(&config, metadata, RetryKeyPrefix); err != nil {
+		return config, fmt.Errorf("invalid retry policy: %w", err)
+	}
+
+	return config, nil
+}
+
+// NewRetryHandler wraps handler so that a failed delivery is retried as
+// configured by config. The error of the last delivery is returned once the
+// retries are exhausted or the context of the delivery is done, so that the
+// broker can redeliver the message.
+func NewRetryHandler(handler Handler, config retry.Config, logger logger.Logger) Handler {
+	if config.MaxRetries == 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, msg *NewMessage) error {
+		return retry.NotifyRecover(func() error {
+			return handler(ctx, msg)
+		}, config.NewBackOffWithContext(ctx), func(err error, d time.Duration) {
+			logger.Errorf("Error processing message of topic %s: %v. Retrying...", msg.Topic, err)
+		}, func() {
+			logger.Infof("Successfully processed message of topic %s after it previously failed", msg.Topic)
+		})
+	}
+}
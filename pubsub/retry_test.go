@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+func TestNewRetryConfig(t *testing.T) {
+	defaults := retry.DefaultConfigWithNoRetry()
+
+	t.Run("defaults", func(t *testing.T) {
+		config, err := NewRetryConfig(map[string]string{"other": "value"}, defaults)
+		require.NoError(t, err)
+		assert.Equal(t, defaults, config)
+		assert.False(t, HasRetryConfig(map[string]string{"other": "value"}))
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		metadata := map[string]string{
+			"retryPolicy":          "exponential",
+			"retryInitialInterval": "10ms",
+			"retryMaxInterval":     "1s",
+			"retryMaxElapsedTime":  "1m",
+			"retryMaxRetries":      "5",
+		}
+		config, err := NewRetryConfig(metadata, defaults)
+		require.NoError(t, err)
+		assert.True(t, HasRetryConfig(metadata))
+		assert.Equal(t, retry.PolicyExponential, config.Policy)
+		assert.Equal(t, 10*time.Millisecond, config.InitialInterval)
+		assert.Equal(t, time.Second, config.MaxInterval)
+		assert.Equal(t, time.Minute, config.MaxElapsedTime)
+		assert.Equal(t, int64(5), config.MaxRetries)
+		assert.Equal(t, defaults.Multiplier, config.Multiplier)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := NewRetryConfig(map[string]string{"retryPolicy": "linear"}, defaults)
+		assert.Error(t, err)
+	})
+}
+
+func TestRetryHandler(t *testing.T) {
+	log := logger.NewLogger("test")
+	config := retry.DefaultConfig()
+	config.Duration = time.Millisecond
+	config.MaxRetries = 2
+
+	t.Run("retries until success", func(t *testing.T) {
+		calls := 0
+		handler := NewRetryHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+			if calls < 3 {
+				return errors.New("handler error")
+			}
+
+			return nil
+		}, config, log)
+
+		assert.NoError(t, handler(context.Background(), &NewMessage{Topic: "orders"}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the last error", func(t *testing.T) {
+		calls := 0
+		handler := NewRetryHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+
+			return errors.New("handler error")
+		}, config, log)
+
+		assert.EqualError(t, handler(context.Background(), &NewMessage{Topic: "orders"}), "handler error")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("without retries", func(t *testing.T) {
+		calls := 0
+		handler := NewRetryHandler(func(ctx context.Context, msg *NewMessage) error {
+			calls++
+
+			return errors.New("handler error")
+		}, retry.DefaultConfigWithNoRetry(), log)
+
+		assert.Error(t, handler(context.Background(), &NewMessage{Topic: "orders"}))
+		assert.Equal(t, 1, calls)
+	})
+}
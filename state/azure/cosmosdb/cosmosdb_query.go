@@ -60,6 +60,51 @@ func (q *Query) VisitIN(f *query.IN) (string, error) {
 	return fmt.Sprintf("%s IN (%s)", replaceKeywords("c.value."+f.Key), strings.Join(names, ", ")), nil
 }
 
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	// <key> != <val>
+	return q.visitComparison("!=", f.Key, f.Val)
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	// <key> > <val>
+	return q.visitComparison(">", f.Key, f.Val)
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	// <key> >= <val>
+	return q.visitComparison(">=", f.Key, f.Val)
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	// <key> < <val>
+	return q.visitComparison("<", f.Key, f.Val)
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	// <key> <= <val>
+	return q.visitComparison("<=", f.Key, f.Val)
+}
+
+func (q *Query) VisitNIN(f *query.NIN) (string, error) {
+	// NOT (<key> IN ( <val1>, <val2>, ... , <valN> ))
+	str, err := q.VisitIN(&query.IN{Key: f.Key, Vals: f.Vals})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("NOT (%s)", str), nil
+}
+
+func (q *Query) visitComparison(op, key string, v interface{}) (string, error) {
+	val, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("unsupported type of value %#v; expected string", v)
+	}
+	name := q.setNextParameter(val)
+
+	return fmt.Sprintf("%s %s %s", replaceKeywords("c.value."+key), op, name), nil
+}
+
 func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
 	var (
 		arr []string
@@ -78,6 +123,36 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NEQ:
+			if str, err = q.VisitNEQ(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GT:
+			if str, err = q.VisitGT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GTE:
+			if str, err = q.VisitGTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LT:
+			if str, err = q.VisitLT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LTE:
+			if str, err = q.VisitLTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.NIN:
+			if str, err = q.VisitNIN(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		case *query.OR:
 			if str, err = q.VisitOR(f); err != nil {
 				return "", err
@@ -107,6 +182,12 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 }
 
 func (q *Query) Finalize(filters string, qq *query.Query) error {
+	if qq.Aggregate != nil {
+		return fmt.Errorf("aggregation queries are not supported")
+	}
+	if len(qq.Fields) > 0 {
+		return fmt.Errorf("field projection is not supported")
+	}
 	var filter, orderBy string
 	if len(filters) != 0 {
 		filter = fmt.Sprintf(" WHERE %s", filters)
@@ -125,6 +125,22 @@ func TestCosmosDbQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "../../../tests/state/query/q8.json",
+			query: documentdb.Query{
+				Query: "SELECT * FROM c WHERE c['value'].created >= @__param__0__ AND c['value'].created < @__param__1__",
+				Parameters: []documentdb.Parameter{
+					{
+						Name:  "@__param__0__",
+						Value: "2021-01-01",
+					},
+					{
+						Name:  "@__param__1__",
+						Value: "2022-01-01",
+					},
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		data, err := ioutil.ReadFile(test.input)
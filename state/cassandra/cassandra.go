@@ -111,7 +111,7 @@ func (c *Cassandra) Init(metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (c *Cassandra) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureTTL, state.FeatureTransactional}
 }
 
 func (c *Cassandra) tryCreateKeyspace(keyspace string, replicationFactor int) error {
@@ -231,7 +231,11 @@ func getCassandraMetadata(metadata state.Metadata) (*cassandraMetadata, error) {
 
 // Delete performs a delete operation.
 func (c *Cassandra) Delete(req *state.DeleteRequest) error {
-	return c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), req.Key).Exec()
+	return c.session.Query(c.deleteStatement(), req.Key).Exec()
+}
+
+func (c *Cassandra) deleteStatement() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table)
 }
 
 // Get retrieves state from cassandra with a key.
@@ -270,12 +274,9 @@ func (c *Cassandra) Get(req *state.GetRequest) (*state.GetResponse, error) {
 
 // Set saves state into cassandra.
 func (c *Cassandra) Set(req *state.SetRequest) error {
-	var bt []byte
-	b, ok := req.Value.([]byte)
-	if ok {
-		bt = b
-	} else {
-		bt, _ = jsoniter.ConfigFastest.Marshal(req.Value)
+	stmt, args, err := c.setStatement(req)
+	if err != nil {
+		return err
 	}
 
 	session := c.session
@@ -296,16 +297,69 @@ func (c *Cassandra) Set(req *state.SetRequest) error {
 		session = sess
 	}
 
+	return session.Query(stmt, args...).Exec()
+}
+
+func (c *Cassandra) setStatement(req *state.SetRequest) (string, []interface{}, error) {
+	var bt []byte
+	b, ok := req.Value.([]byte)
+	if ok {
+		bt = b
+	} else {
+		bt, _ = jsoniter.ConfigFastest.Marshal(req.Value)
+	}
+
 	ttl, err := parseTTL(req.Metadata)
 	if err != nil {
-		return fmt.Errorf("error parsing TTL from Metadata: %s", err)
+		return "", nil, fmt.Errorf("error parsing TTL from Metadata: %s", err)
 	}
 
 	if ttl != nil {
-		return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), req.Key, bt, *ttl).Exec()
+		return fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), []interface{}{req.Key, bt, *ttl}, nil
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), []interface{}{req.Key, bt}, nil
+}
+
+// Multi executes the operations of request in a logged batch.
+// Cassandra guarantees that either all or none of the statements of a logged
+// batch are eventually applied. The statements are isolated from concurrent
+// writes only if they modify a single partition, i.e. a single key.
+func (c *Cassandra) Multi(request *state.TransactionalStateRequest) error {
+	batch := c.session.NewBatch(gocql.LoggedBatch)
+	err := c.addBatchEntries(batch, request.Operations)
+	if err != nil {
+		return err
+	}
+
+	return c.session.ExecuteBatch(batch)
+}
+
+func (c *Cassandra) addBatchEntries(batch *gocql.Batch, operations []state.TransactionalStateOperation) error {
+	for _, o := range operations {
+		switch o.Operation {
+		case state.Upsert:
+			req, ok := o.Request.(state.SetRequest)
+			if !ok {
+				return fmt.Errorf("expecting set request")
+			}
+			stmt, args, err := c.setStatement(&req)
+			if err != nil {
+				return err
+			}
+			batch.Query(stmt, args...)
+		case state.Delete:
+			req, ok := o.Request.(state.DeleteRequest)
+			if !ok {
+				return fmt.Errorf("expecting delete request")
+			}
+			batch.Query(c.deleteStatement(), req.Key)
+		default:
+			return fmt.Errorf("unsupported operation: %s", o.Operation)
+		}
 	}
 
-	return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), req.Key, bt).Exec()
+	return nil
 }
 
 func (c *Cassandra) createSession(consistency gocql.Consistency) (*gocql.Session, error) {
@@ -17,7 +17,9 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 )
@@ -140,3 +142,32 @@ func TestParseTTL(t *testing.T) {
 		assert.Nil(t, ttl)
 	})
 }
+
+func TestAddBatchEntries(t *testing.T) {
+	c := &Cassandra{table: "dapr.items"}
+
+	t.Run("With upserts and deletes", func(t *testing.T) {
+		batch := &gocql.Batch{Type: gocql.LoggedBatch}
+		err := c.addBatchEntries(batch, []state.TransactionalStateOperation{
+			{Operation: state.Upsert, Request: state.SetRequest{Key: "k1", Value: []byte("v1")}},
+			{Operation: state.Upsert, Request: state.SetRequest{Key: "k2", Value: "v2", Metadata: map[string]string{"ttlInSeconds": "60"}}},
+			{Operation: state.Delete, Request: state.DeleteRequest{Key: "k3"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, batch.Entries, 3)
+		assert.Equal(t, "INSERT INTO dapr.items (key, value) VALUES (?, ?)", batch.Entries[0].Stmt)
+		assert.Equal(t, []interface{}{"k1", []byte("v1")}, batch.Entries[0].Args)
+		assert.Equal(t, "INSERT INTO dapr.items (key, value) VALUES (?, ?) USING TTL ?", batch.Entries[1].Stmt)
+		assert.Equal(t, []interface{}{"k2", []byte(`"v2"`), 60}, batch.Entries[1].Args)
+		assert.Equal(t, "DELETE FROM dapr.items WHERE key = ?", batch.Entries[2].Stmt)
+		assert.Equal(t, []interface{}{"k3"}, batch.Entries[2].Args)
+	})
+
+	t.Run("With invalid request", func(t *testing.T) {
+		batch := &gocql.Batch{Type: gocql.LoggedBatch}
+		err := c.addBatchEntries(batch, []state.TransactionalStateOperation{
+			{Operation: state.Delete, Request: state.SetRequest{Key: "k1"}},
+		})
+		assert.Error(t, err)
+	})
+}
@@ -79,8 +79,12 @@ func (c *CockroachDB) BulkDelete(req []state.DeleteRequest) error {
 
 // BulkGet performs a bulks get operations.
 func (c *CockroachDB) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
-	// TODO: replace with ExecuteMulti for performance.
-	return false, nil, nil
+	res, err := c.dbaccess.BulkGet(req)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return true, res, nil
 }
 
 // BulkSet adds/updates multiple entities on store.
@@ -14,19 +14,24 @@ limitations under the License.
 package cockroachdb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/agrea/ptr"
 
+	"github.com/dapr/components-contrib/internal/component/sql/migrations"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 
 	// Blank import for the underlying PostgreSQL driver.
 	_ "github.com/jackc/pgx/v4/stdlib"
@@ -36,6 +41,14 @@ const (
 	connectionStringKey        = "connectionString"
 	errMissingConnectionString = "missing connection string"
 	tableName                  = "state"
+	indexedPropertiesKey       = "indexedProperties"
+
+	// migrationAttempts is the number of times the migrations are run when the
+	// transactions of concurrent instances conflict.
+	migrationAttempts = 5
+	// serializationFailure is the SQLSTATE of the transactions aborted by a conflict,
+	// which must be retried.
+	serializationFailure = "40001"
 )
 
 // cockroachDBAccess implements dbaccess.
@@ -44,6 +57,9 @@ type cockroachDBAccess struct {
 	metadata         state.Metadata
 	db               *sql.DB
 	connectionString string
+	retryConfig      retry.Config
+
+	indexedProperties []IndexedProperty
 }
 
 // newCockroachDBAccess creates a new instance of cockroachDBAccess.
@@ -74,6 +90,17 @@ func (p *cockroachDBAccess) Init(metadata state.Metadata) error {
 		return fmt.Errorf(errMissingConnectionString)
 	}
 
+	retryConfig, err := state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	p.retryConfig = retryConfig
+
+	p.indexedProperties, err = parseIndexedProperties(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
 	databaseConn, err := sql.Open("pgx", p.connectionString)
 	if err != nil {
 		p.logger.Error(err)
@@ -91,12 +118,16 @@ func (p *cockroachDBAccess) Init(metadata state.Metadata) error {
 		return err
 	}
 
+	if err = p.ensureIndexes(tableName); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Set makes an insert or update to the database.
 func (p *cockroachDBAccess) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(p.setValue, req)
+	return state.SetWithRetries(p.setValue, req, p.retryConfig)
 }
 
 // setValue is an internal implementation of set to enable passing the logic to state.SetWithRetries as a func.
@@ -192,37 +223,83 @@ func (p *cockroachDBAccess) Get(req *state.GetRequest) (*state.GetResponse, erro
 		return nil, err
 	}
 
-	if isBinary {
-		var dataS string
-		var data []byte
+	data, err := utils.DecodeValue(value, isBinary)
+	if err != nil {
+		return nil, err
+	}
 
-		if err = json.Unmarshal([]byte(value), &dataS); err != nil {
-			return nil, err
+	return &state.GetResponse{
+		Data:        data,
+		ETag:        ptr.String(strconv.Itoa(etag)),
+		Metadata:    req.Metadata,
+		ContentType: nil,
+	}, nil
+}
+
+// BulkGet returns the data for multiple keys using a single query.
+// Keys that do not exist are returned with empty data, and keys whose value
+// cannot be decoded are returned with an error set on their response.
+func (p *cockroachDBAccess) BulkGet(req []state.GetRequest) ([]state.BulkGetResponse, error) {
+	p.logger.Debug("Getting bulk state values from CockroachDB")
+	if len(req) == 0 {
+		return []state.BulkGetResponse{}, nil
+	}
+
+	keys := make([]string, len(req))
+	for i, r := range req {
+		if r.Key == "" {
+			return nil, fmt.Errorf("missing key in bulk get operation")
 		}
+		keys[i] = r.Key
+	}
 
-		if data, err = base64.StdEncoding.DecodeString(dataS); err != nil {
+	// The keys are passed as a single array parameter, so that the statement is the same for any number of keys.
+	rows, err := p.db.Query(fmt.Sprintf("SELECT key, value, isbinary, etag FROM %s WHERE key = ANY($1)", tableName), keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]state.BulkGetResponse, len(req))
+	for rows.Next() {
+		var key, value string
+		var isBinary bool
+		var etag int
+		if err = rows.Scan(&key, &value, &isBinary, &etag); err != nil {
 			return nil, err
 		}
 
-		return &state.GetResponse{
-			Data:        data,
-			ETag:        ptr.String(strconv.Itoa(etag)),
-			Metadata:    req.Metadata,
-			ContentType: nil,
-		}, nil
+		item := state.BulkGetResponse{
+			Key:  key,
+			ETag: ptr.String(strconv.Itoa(etag)),
+		}
+		if item.Data, err = utils.DecodeValue(value, isBinary); err != nil {
+			item.ETag = nil
+			item.Error = err.Error()
+		}
+		found[key] = item
 	}
 
-	return &state.GetResponse{
-		Data:        []byte(value),
-		ETag:        ptr.String(strconv.Itoa(etag)),
-		Metadata:    req.Metadata,
-		ContentType: nil,
-	}, nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]state.BulkGetResponse, len(req))
+	for i, r := range req {
+		item, ok := found[r.Key]
+		if !ok {
+			item = state.BulkGetResponse{Key: r.Key}
+		}
+		item.Metadata = r.Metadata
+		res[i] = item
+	}
+
+	return res, nil
 }
 
 // Delete removes an item from the state store.
 func (p *cockroachDBAccess) Delete(req *state.DeleteRequest) error {
-	return state.DeleteWithOptions(p.deleteValue, req)
+	return state.DeleteWithRetries(p.deleteValue, req, p.retryConfig)
 }
 
 // deleteValue is an internal implementation of delete to enable passing the logic to state.DeleteWithRetries as a func.
@@ -360,6 +437,23 @@ func (p *cockroachDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse
 
 	p.logger.Debug("Query: " + stateQuery.query)
 
+	if stateQuery.aggregate != nil {
+		aggregations, err := stateQuery.executeAggregate(p.db)
+		if err != nil {
+			return &state.QueryResponse{
+				Results:  []state.QueryItem{},
+				Token:    "",
+				Metadata: map[string]string{},
+			}, err
+		}
+
+		return &state.QueryResponse{
+			Results:      []state.QueryItem{},
+			Metadata:     map[string]string{},
+			Aggregations: aggregations,
+		}, nil
+	}
+
 	data, token, err := stateQuery.execute(p.logger, p.db)
 	if err != nil {
 		return &state.QueryResponse{
@@ -390,35 +484,152 @@ func (p *cockroachDBAccess) Close() error {
 	return nil
 }
 
+// ensureStateTable creates the state table if it does not exist, and migrates
+// its schema to the latest version. CockroachDB has no advisory locks, so
+// concurrent migrations are serialized by the isolation of the transaction:
+// the instances whose transaction is aborted by a conflict run the migrations
+// again, and find them applied.
 func (p *cockroachDBAccess) ensureStateTable(stateTableName string) error {
-	exists, err := tableExists(p.db, stateTableName)
-	if err != nil {
-		return err
+	var err error
+	for attempt := 1; attempt <= migrationAttempts; attempt++ {
+		err = migrations.Migrate(context.Background(), p.db, migrations.Options{
+			Logger: p.logger,
+			Key:    stateTableName,
+		}, stateTableMigrations(stateTableName))
+		if !isSerializationFailure(err) {
+			return err
+		}
+		p.logger.Debugf("Migrations of %s conflicted with another instance (attempt %d/%d): %v", stateTableName, attempt, migrationAttempts, err)
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
 	}
 
-	if !exists {
-		p.logger.Info("Creating CockroachDB state table")
-		createTable := fmt.Sprintf(`CREATE TABLE %s (
+	return err
+}
+
+// isSerializationFailure reports whether err aborted a transaction which
+// conflicted with a concurrent one.
+func isSerializationFailure(err error) bool {
+	var pgErr interface{ SQLState() string }
+
+	return errors.As(err, &pgErr) && pgErr.SQLState() == serializationFailure
+}
+
+// stateTableMigrations returns the up-steps of the state table schema.
+// Released migrations must never be modified, only appended to.
+func stateTableMigrations(stateTableName string) []migrations.Migration {
+	return []migrations.Migration{
+		{
+			Description: "create the state table",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				// The table already exists if it was created before the migrations were introduced.
+				_, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
 									key text NOT NULL PRIMARY KEY,
 									value jsonb NOT NULL,
 									isbinary boolean NOT NULL,
 									etag INT,
 									insertdate TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName)
-		_, err = p.db.Exec(createTable)
-		if err != nil {
-			return err
+									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName))
+
+				return err
+			},
+		},
+	}
+}
+
+// IndexedProperty defines a property of the JSON values which is indexed, so that
+// the queries filtering or sorting on it do not scan the whole table.
+type IndexedProperty struct {
+	// Property is the path of the property, e.g. person.org.
+	Property string `json:"property"`
+	// Type is either text, the default, or numeric. Numeric properties are indexed
+	// as numbers, and speed up the range comparisons with numbers.
+	Type string `json:"type"`
+}
+
+const (
+	indexTypeText    = "text"
+	indexTypeNumeric = "numeric"
+)
+
+// parseIndexedProperties reads the properties to index from the component metadata.
+func parseIndexedProperties(properties map[string]string) ([]IndexedProperty, error) {
+	val, ok := properties[indexedPropertiesKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	var indexedProperties []IndexedProperty
+	if err := json.Unmarshal([]byte(val), &indexedProperties); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", indexedPropertiesKey, err)
+	}
+
+	for i, prop := range indexedProperties {
+		if !isValidPropertyPath(prop.Property) {
+			return nil, fmt.Errorf("invalid indexed property %q, accepted characters are (A-Z, a-z, 0-9, _) separated by dots", prop.Property)
+		}
+
+		switch prop.Type {
+		case "":
+			indexedProperties[i].Type = indexTypeText
+		case indexTypeText, indexTypeNumeric:
+		default:
+			return nil, fmt.Errorf("invalid type %q of indexed property %s, accepted types are text and numeric", prop.Type, prop.Property)
 		}
 	}
 
-	return nil
+	return indexedProperties, nil
 }
 
-func tableExists(db *sql.DB, tableName string) (bool, error) {
-	exists := false
-	err := db.QueryRow("SELECT EXISTS (SELECT * FROM pg_tables where tablename = $1)", tableName).Scan(&exists)
+func isValidPropertyPath(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+				return false
+			}
+		}
+	}
 
-	return exists, err
+	return true
+}
+
+func indexName(stateTableName string, prop IndexedProperty) string {
+	name := stateTableName + "_" + strings.ToLower(strings.ReplaceAll(prop.Property, ".", "_"))
+	if prop.Type == indexTypeNumeric {
+		name += "_num"
+	}
+
+	return name + "_idx"
+}
+
+// indexExpression returns the expression of the property used by the queries,
+// which the index must match exactly to be used.
+func indexExpression(prop IndexedProperty) string {
+	expr := translateFieldToFilter(prop.Property)
+	if prop.Type == indexTypeNumeric {
+		expr = fmt.Sprintf("(%s)::numeric", expr)
+	}
+
+	return expr
+}
+
+// ensureIndexes creates the indexes of the indexed properties which do not exist.
+// Indexes of properties removed from the metadata are not dropped.
+// Expression indexes require CockroachDB v21.2 or later. The indexes are built
+// online, without blocking the writes to the state table.
+func (p *cockroachDBAccess) ensureIndexes(stateTableName string) error {
+	for _, prop := range p.indexedProperties {
+		p.logger.Debugf("Ensuring the index of property %s exists", prop.Property)
+		_, err := p.db.Exec(fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s ((%s))",
+			indexName(stateTableName, prop), stateTableName, indexExpression(prop)))
+		if err != nil {
+			return fmt.Errorf("error creating the index of property %s: %w", prop.Property, err)
+		}
+	}
+
+	return nil
 }
 
 func validateAndReturnValue(request *state.SetRequest) (value string, isBinary bool, err error) {
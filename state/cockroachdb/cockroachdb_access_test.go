@@ -15,6 +15,8 @@ package cockroachdb
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -428,6 +430,48 @@ func TestValidBulkDelete(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestValidBulkGet(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	rows := sqlmock.NewRows([]string{"key", "value", "isbinary", "etag"}).
+		AddRow("key2", `"aGVsbG8="`, true, 7).
+		AddRow("key1", `{"a":1}`, false, 3)
+	m.mock.ExpectQuery(`SELECT key, value, isbinary, etag FROM state WHERE key = ANY\(\$1\)`).
+		WithArgs([]string{"key1", "key2"}).
+		WillReturnRows(rows)
+
+	// Act
+	res, err := m.roachDba.BulkGet([]state.GetRequest{
+		{Key: "key1"},
+		{Key: "key2"},
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "key1", res[0].Key)
+	assert.Equal(t, `{"a":1}`, string(res[0].Data))
+	assert.Equal(t, "3", *res[0].ETag)
+	assert.Equal(t, "key2", res[1].Key)
+	assert.Equal(t, "hello", string(res[1].Data))
+	assert.Equal(t, "7", *res[1].ETag)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestInvalidBulkGetNoKey(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	// Act
+	_, err := m.roachDba.BulkGet([]state.GetRequest{{Key: ""}})
+
+	// Assert
+	assert.NotNil(t, err)
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
@@ -441,10 +485,106 @@ func createDeleteRequest() state.DeleteRequest {
 	}
 }
 
+func TestParseIndexedProperties(t *testing.T) {
+	props, err := parseIndexedProperties(map[string]string{
+		indexedPropertiesKey: `[{"property": "person.org"}, {"property": "person.id", "type": "numeric"}]`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []IndexedProperty{
+		{Property: "person.org", Type: "text"},
+		{Property: "person.id", Type: "numeric"},
+	}, props)
+
+	_, err = parseIndexedProperties(map[string]string{indexedPropertiesKey: `[{"property": "person'->>'org"}]`})
+	assert.NotNil(t, err)
+
+	_, err = parseIndexedProperties(map[string]string{indexedPropertiesKey: `[{"property": "person.org", "type": "float"}]`})
+	assert.NotNil(t, err)
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.roachDba.indexedProperties = []IndexedProperty{
+		{Property: "person.org", Type: "text"},
+		{Property: "person.id", Type: "numeric"},
+	}
+	m.mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS state_person_org_idx ON state \(\(value->'person'->>'org'\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS state_person_id_num_idx ON state \(\(\(value->'person'->>'id'\)::numeric\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Act
+	err := m.roachDba.ensureIndexes("state")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+// sqlStateError is an error with a SQLSTATE, as returned by the driver.
+type sqlStateError string
+
+func (e sqlStateError) Error() string { return "error with SQLSTATE " + string(e) }
+
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestEnsureStateTableRetriesConflicts(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	// The first attempt conflicts with another instance.
+	m.mock.ExpectBegin()
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectQuery("SELECT value FROM dapr_metadata").WillReturnError(sqlStateError(serializationFailure))
+	m.mock.ExpectRollback()
+	// The other instance applied the migrations.
+	m.mock.ExpectBegin()
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectQuery("SELECT value FROM dapr_metadata").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+	m.mock.ExpectRollback()
+
+	// Act
+	err := m.roachDba.ensureStateTable("state")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestEnsureStateTableFailure(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.mock.ExpectBegin()
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnError(errors.New("permission denied"))
+	m.mock.ExpectRollback()
+
+	// Act
+	err := m.roachDba.ensureStateTable("state")
+
+	// Assert
+	assert.ErrorContains(t, err, "permission denied")
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+// arrayConverter passes the string arrays through like the pgx driver does,
+// and converts the other arguments like database/sql.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if a, ok := v.([]string); ok {
+		return a, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
 func mockDatabase(t *testing.T) (*mocks, error) {
 	logger := logger.NewLogger("test")
 
-	db, mock, err := sqlmock.New()
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
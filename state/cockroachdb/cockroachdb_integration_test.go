@@ -23,6 +23,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/dapr/components-contrib/internal/component/sql/migrations"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
@@ -186,15 +187,30 @@ func testCreateTable(t *testing.T, dba *cockroachDBAccess) {
 	assert.Nil(t, err)
 	assert.True(t, exists)
 
+	// Migrating an up to date table does nothing.
+	err = dba.ensureStateTable(tableName)
+	assert.Nil(t, err)
+
 	// Drop the state table.
 	dropTable(t, dba.db, tableName)
 }
 
+func tableExists(db *sql.DB, tableName string) (bool, error) {
+	exists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT * FROM pg_tables where tablename = $1)", tableName).Scan(&exists)
+
+	return exists, err
+}
+
 func dropTable(t *testing.T, db *sql.DB, tableName string) {
 	t.Helper()
 
 	_, err := db.Exec(fmt.Sprintf("DROP TABLE %s", tableName))
 	assert.Nil(t, err)
+
+	// Reset the schema version, so that the table is created again.
+	_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE key = $1", migrations.DefaultMetadataTableName), "migrations-"+tableName)
+	assert.Nil(t, err)
 }
 
 func deleteItemThatDoesNotExist(t *testing.T, pgs *CockroachDB) {
@@ -15,6 +15,7 @@ package cockroachdb
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -31,12 +32,34 @@ type Query struct {
 	params []interface{}
 	limit  int
 	skip   *int64
+
+	aggregate *query.Aggregate
 }
 
 func (q *Query) VisitEQ(filter *query.EQ) (string, error) {
 	return q.whereFieldEqual(filter.Key, filter.Val), nil
 }
 
+func (q *Query) VisitNEQ(filter *query.NEQ) (string, error) {
+	return q.whereFieldCompare(filter.Key, "<>", filter.Val, false), nil
+}
+
+func (q *Query) VisitGT(filter *query.GT) (string, error) {
+	return q.whereFieldCompare(filter.Key, ">", filter.Val, true), nil
+}
+
+func (q *Query) VisitGTE(filter *query.GTE) (string, error) {
+	return q.whereFieldCompare(filter.Key, ">=", filter.Val, true), nil
+}
+
+func (q *Query) VisitLT(filter *query.LT) (string, error) {
+	return q.whereFieldCompare(filter.Key, "<", filter.Val, true), nil
+}
+
+func (q *Query) VisitLTE(filter *query.LTE) (string, error) {
+	return q.whereFieldCompare(filter.Key, "<=", filter.Val, true), nil
+}
+
 func (q *Query) VisitIN(filter *query.IN) (string, error) {
 	if len(filter.Vals) == 0 {
 		return "", fmt.Errorf("empty IN operator for key %q", filter.Key)
@@ -53,6 +76,22 @@ func (q *Query) VisitIN(filter *query.IN) (string, error) {
 	return str, nil
 }
 
+func (q *Query) VisitNIN(filter *query.NIN) (string, error) {
+	if len(filter.Vals) == 0 {
+		return "", fmt.Errorf("empty NIN operator for key %q", filter.Key)
+	}
+
+	str := "("
+	str += q.whereFieldCompare(filter.Key, "<>", filter.Vals[0], false)
+
+	for _, v := range filter.Vals[1:] {
+		str += " AND "
+		str += q.whereFieldCompare(filter.Key, "<>", v, false)
+	}
+	str += ")"
+	return str, nil
+}
+
 func (q *Query) visitFilters(operation string, filters []query.Filter) (string, error) {
 	var (
 		str string
@@ -65,8 +104,20 @@ func (q *Query) visitFilters(operation string, filters []query.Filter) (string,
 		switch filterType := filter.(type) {
 		case *query.EQ:
 			str, err = q.VisitEQ(filterType)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(filterType)
+		case *query.GT:
+			str, err = q.VisitGT(filterType)
+		case *query.GTE:
+			str, err = q.VisitGTE(filterType)
+		case *query.LT:
+			str, err = q.VisitLT(filterType)
+		case *query.LTE:
+			str, err = q.VisitLTE(filterType)
 		case *query.IN:
 			str, err = q.VisitIN(filterType)
+		case *query.NIN:
+			str, err = q.VisitNIN(filterType)
 		case *query.OR:
 			str, err = q.VisitOR(filterType)
 		case *query.AND:
@@ -96,7 +147,15 @@ func (q *Query) VisitOR(filter *query.OR) (string, error) {
 }
 
 func (q *Query) Finalize(filters string, storeQuery *query.Query) error {
-	q.query = fmt.Sprintf("SELECT key, value, etag FROM %s", tableName)
+	if storeQuery.Aggregate != nil {
+		return q.finalizeAggregate(filters, storeQuery.Aggregate)
+	}
+
+	value := "value"
+	if len(storeQuery.Fields) > 0 {
+		value = projectFields(storeQuery.Fields)
+	}
+	q.query = fmt.Sprintf("SELECT key, %s, etag FROM %s", value, tableName)
 
 	if filters != "" {
 		q.query += fmt.Sprintf(" WHERE %s", filters)
@@ -133,6 +192,92 @@ func (q *Query) Finalize(filters string, storeQuery *query.Query) error {
 	return nil
 }
 
+// finalizeAggregate builds the query which computes the aggregations of each group.
+// The groups are selected as JSON values, followed by the aggregated values.
+func (q *Query) finalizeAggregate(filters string, aggregate *query.Aggregate) error {
+	columns := make([]string, 0, len(aggregate.GroupBy)+len(aggregate.Aggregations))
+	groups := make([]string, len(aggregate.GroupBy))
+	for i, key := range aggregate.GroupBy {
+		groups[i] = translateFieldToJSON(key)
+		columns = append(columns, groups[i])
+	}
+	for _, agg := range aggregate.Aggregations {
+		if agg.Op == query.COUNT {
+			columns = append(columns, "COUNT(*)")
+		} else {
+			columns = append(columns, fmt.Sprintf("%s((%s)::numeric)::float8", strings.ToUpper(agg.Op), translateFieldToFilter(agg.Key)))
+		}
+	}
+
+	q.query = fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), tableName)
+	if filters != "" {
+		q.query += fmt.Sprintf(" WHERE %s", filters)
+	}
+	if len(groups) > 0 {
+		q.query += fmt.Sprintf(" GROUP BY %s", strings.Join(groups, ", "))
+	}
+	q.aggregate = aggregate
+
+	return nil
+}
+
+func (q *Query) executeAggregate(db *sql.DB) ([]state.AggregationResult, error) {
+	rows, err := db.Query(q.query, q.params...)
+	if err != nil {
+		return nil, fmt.Errorf("query executes '%s' failed: %w", q.query, err)
+	}
+	defer rows.Close()
+
+	ret := []state.AggregationResult{}
+	for rows.Next() {
+		groups := make([][]byte, len(q.aggregate.GroupBy))
+		values := make([]sql.NullFloat64, len(q.aggregate.Aggregations))
+		dest := make([]interface{}, 0, len(groups)+len(values))
+		for i := range groups {
+			dest = append(dest, &groups[i])
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		result := state.AggregationResult{
+			Values: make(map[string]interface{}, len(values)),
+		}
+		if len(groups) > 0 {
+			result.Group = make(map[string]interface{}, len(groups))
+		}
+		for i, key := range q.aggregate.GroupBy {
+			var v interface{}
+			if groups[i] != nil {
+				if err = json.Unmarshal(groups[i], &v); err != nil {
+					return nil, err
+				}
+			}
+			result.Group[key] = v
+		}
+		for i, agg := range q.aggregate.Aggregations {
+			switch {
+			case !values[i].Valid:
+				result.Values[agg.Alias] = nil
+			case agg.Op == query.COUNT:
+				result.Values[agg.Alias] = int64(values[i].Float64)
+			default:
+				result.Values[agg.Alias] = values[i].Float64
+			}
+		}
+		ret = append(ret, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 func (q *Query) execute(logger logger.Logger, db *sql.DB) ([]state.QueryItem, string, error) {
 	rows, err := db.Query(q.query, q.params...)
 	if err != nil {
@@ -202,9 +347,79 @@ func translateFieldToFilter(key string) string {
 	return filterField
 }
 
+// projectionNode is a field of a projection, holding the projected fields it contains.
+type projectionNode struct {
+	names    []string
+	children map[string]*projectionNode
+}
+
+func (n *projectionNode) add(path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if n.children == nil {
+		n.children = map[string]*projectionNode{}
+	}
+	child, ok := n.children[path[0]]
+	if !ok {
+		child = &projectionNode{}
+		n.children[path[0]] = child
+		n.names = append(n.names, path[0])
+	}
+	child.add(path[1:])
+}
+
+func (n *projectionNode) expression(field string) string {
+	if len(n.names) == 0 {
+		return field
+	}
+
+	args := make([]string, len(n.names))
+	for i, name := range n.names {
+		literal := "'" + strings.ReplaceAll(name, "'", "''") + "'"
+		args[i] = literal + ", " + n.children[name].expression(field+"->"+literal)
+	}
+
+	return fmt.Sprintf("jsonb_build_object(%s)", strings.Join(args, ", "))
+}
+
+// projectFields returns the expression building a document which only holds the fields,
+// at the same paths as in the value.
+func projectFields(fields []string) string {
+	root := &projectionNode{}
+	for _, field := range fields {
+		root.add(strings.Split(field, "."))
+	}
+
+	return root.expression("value")
+}
+
+// translateFieldToJSON returns the JSON value of the field, rather than its text.
+func translateFieldToJSON(key string) string {
+	field := "value"
+	for _, part := range strings.Split(key, ".") {
+		field += fmt.Sprintf("->'%s'", part)
+	}
+
+	return field
+}
+
 func (q *Query) whereFieldEqual(key string, value interface{}) string {
 	position := q.addParamValueAndReturnPosition(value)
 	filterField := translateFieldToFilter(key)
 	query := fmt.Sprintf("%s=$%v", filterField, position)
 	return query
 }
+
+// whereFieldCompare returns the comparison of a field with a value. When numeric is
+// set and the value is a number, the field is cast so that it is compared as a number
+// rather than as text.
+func (q *Query) whereFieldCompare(key string, op string, value interface{}, numeric bool) string {
+	position := q.addParamValueAndReturnPosition(value)
+	filterField := translateFieldToFilter(key)
+	if _, ok := value.(float64); ok && numeric {
+		filterField = fmt.Sprintf("(%s)::numeric", filterField)
+	}
+	query := fmt.Sprintf("%s%s$%v", filterField, op, position)
+	return query
+}
@@ -55,6 +55,22 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 			input: "../../tests/state/query/q5.json",
 			query: "SELECT key, value, etag FROM state WHERE (value->'person'->>'org'=$1 AND (value->'person'->>'name'=$2 OR (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
 		},
+		{
+			input: "../../tests/state/query/q7.json",
+			query: "SELECT key, value, etag FROM state WHERE ((value->'person'->>'id')::numeric>=$1 AND (value->'person'->>'id')::numeric<$2 AND value->>'state'<>$3 AND ((value->'person'->>'id')::numeric>$4 OR (value->'person'->>'id')::numeric<=$5 OR (value->>'state'<>$6 AND value->>'state'<>$7))) ORDER BY value->'person'->>'id' LIMIT 2",
+		},
+		{
+			input: "../../tests/state/query/q8.json",
+			query: "SELECT key, value, etag FROM state WHERE (value->>'created'>=$1 AND value->>'created'<$2)",
+		},
+		{
+			input: "../../tests/state/query/q9.json",
+			query: "SELECT value->'state', COUNT(*), AVG((value->'person'->>'age')::numeric)::float8, MAX((value->'person'->>'age')::numeric)::float8 FROM state WHERE value->'person'->>'org'=$1 GROUP BY value->'state'",
+		},
+		{
+			input: "../../tests/state/query/q10.json",
+			query: "SELECT key, jsonb_build_object('state', value->'state', 'person', jsonb_build_object('org', value->'person'->'org', 'name', value->'person'->'name')), etag FROM state WHERE value->>'state'=$1 LIMIT 2",
+		},
 	}
 	for _, test := range tests {
 		data, err := ioutil.ReadFile(test.input)
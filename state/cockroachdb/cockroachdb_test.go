@@ -53,6 +53,10 @@ func (m *fakeDBaccess) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	return nil, nil
 }
 
+func (m *fakeDBaccess) BulkGet(req []state.GetRequest) ([]state.BulkGetResponse, error) {
+	return nil, nil
+}
+
 func (m *fakeDBaccess) Delete(req *state.DeleteRequest) error {
 	m.deleteExecuted = true
 
@@ -21,6 +21,7 @@ type dbAccess interface {
 	Set(req *state.SetRequest) error
 	BulkSet(req []state.SetRequest) error
 	Get(req *state.GetRequest) (*state.GetResponse, error)
+	BulkGet(req []state.GetRequest) ([]state.BulkGetResponse, error)
 	Delete(req *state.DeleteRequest) error
 	BulkDelete(req []state.DeleteRequest) error
 	ExecuteMulti(req *state.TransactionalStateRequest) error
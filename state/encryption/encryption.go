@@ -0,0 +1,334 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
+)
+
+// formatVersion is the first byte of the values encrypted by this package.
+const formatVersion byte = 1
+
+// Options configures an encrypted state store.
+type Options struct {
+	// SecretStore holds the encryption keys. Each secret must hold an AES key
+	// of 128, 192 or 256 bits, encoded in hex or base64.
+	SecretStore secretstores.SecretStore
+	// SecretStoreMetadata is passed to the secret store with each request.
+	SecretStoreMetadata map[string]string
+	// PrimaryKey is the name of the secret holding the key which encrypts new values.
+	PrimaryKey string
+	// SecondaryKeys are the names of the secrets holding previous keys.
+	// They are only used to decrypt the values encrypted before a key rotation.
+	SecondaryKeys []string
+}
+
+// Store is a state store decorator which encrypts the values with AES-GCM
+// before they are saved, and decrypts them when they are read.
+//
+// An encrypted value is saved as a base64 string holding the format version,
+// the name of the key which encrypted it, the nonce and the ciphertext.
+// The state key is authenticated along with the value, so that a value cannot
+// be moved to another key. Values which are not encrypted cannot be read.
+//
+// Queries are evaluated by the underlying store on the encrypted values, so
+// only the queries which list the values, without filters, sorting,
+// aggregations or fields, are supported.
+type Store struct {
+	state.Store
+
+	primaryKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+// NewEncryptedStore returns a decorator which encrypts the values of store.
+// The keys are read from the secret store once, when the decorator is created.
+func NewEncryptedStore(store state.Store, opts Options) (*Store, error) {
+	if opts.SecretStore == nil {
+		return nil, errors.New("encryption: missing secret store")
+	}
+	if opts.PrimaryKey == "" {
+		return nil, errors.New("encryption: missing primary key name")
+	}
+
+	s := &Store{
+		Store:        store,
+		primaryKeyID: opts.PrimaryKey,
+		keys:         make(map[string]cipher.AEAD, len(opts.SecondaryKeys)+1),
+	}
+	for _, name := range append([]string{opts.PrimaryKey}, opts.SecondaryKeys...) {
+		if len(name) > 255 {
+			return nil, fmt.Errorf("encryption: key name %q is longer than 255 bytes", name)
+		}
+		aead, err := loadKey(opts.SecretStore, name, opts.SecretStoreMetadata)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[name] = aead
+	}
+
+	return s, nil
+}
+
+func loadKey(secretStore secretstores.SecretStore, name string, metadata map[string]string) (cipher.AEAD, error) {
+	res, err := secretStore.GetSecret(secretstores.GetSecretRequest{Name: name, Metadata: metadata})
+	if err != nil {
+		return nil, fmt.Errorf("encryption: error reading key %s: %w", name, err)
+	}
+
+	secret, ok := res.Data[name]
+	if !ok && len(res.Data) == 1 {
+		for _, v := range res.Data {
+			secret = v
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, fmt.Errorf("encryption: key %s not found", name)
+	}
+
+	key, err := decodeKey(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: invalid key %s: %w", name, err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: invalid key %s: %w", name, err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decodeKey decodes an AES key encoded in hex or base64.
+func decodeKey(secret string) ([]byte, error) {
+	if b, err := hex.DecodeString(secret); err == nil && isValidKeySize(len(b)) {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(secret); err == nil && isValidKeySize(len(b)) {
+		return b, nil
+	}
+
+	return nil, errors.New("the key must be 16, 24 or 32 bytes encoded in hex or base64")
+}
+
+func isValidKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// encrypt returns the encrypted form of the value of key.
+func (s *Store) encrypt(key string, value interface{}) (string, error) {
+	plaintext, err := utils.Marshal(value, json.Marshal)
+	if err != nil {
+		return "", err
+	}
+
+	aead := s.keys[s.primaryKeyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	blob := make([]byte, 0, 2+len(s.primaryKeyID)+len(nonce)+len(plaintext)+aead.Overhead())
+	blob = append(blob, formatVersion, byte(len(s.primaryKeyID)))
+	blob = append(blob, s.primaryKeyID...)
+	blob = append(blob, nonce...)
+	blob = aead.Seal(blob, nonce, plaintext, []byte(key))
+
+	return base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// decrypt returns the original value of key. Stores which save string values
+// as JSON return them quoted.
+func (s *Store) decrypt(key string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	encoded := string(data)
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return nil, errors.New("encryption: value is not encrypted")
+		}
+	}
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(blob) < 2 || blob[0] != formatVersion || len(blob) < 2+int(blob[1]) {
+		return nil, errors.New("encryption: value is not encrypted")
+	}
+
+	keyID := string(blob[2 : 2+int(blob[1])])
+	aead, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption: unknown key %s", keyID)
+	}
+
+	blob = blob[2+len(keyID):]
+	if len(blob) < aead.NonceSize() {
+		return nil, errors.New("encryption: value is not encrypted")
+	}
+	plaintext, err := aead.Open(nil, blob[:aead.NonceSize()], blob[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: error decrypting value of key %s: %w", key, err)
+	}
+
+	return plaintext, nil
+}
+
+func (s *Store) encryptRequest(req *state.SetRequest) (*state.SetRequest, error) {
+	value, err := s.encrypt(req.Key, req.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	encReq := *req
+	encReq.Value = value
+
+	return &encReq, nil
+}
+
+// Get returns the decrypted value of the entity.
+func (s *Store) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	res, err := s.Store.Get(req)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	res.Data, err = s.decrypt(req.Key, res.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Set encrypts the value and saves it in the underlying store.
+func (s *Store) Set(req *state.SetRequest) error {
+	encReq, err := s.encryptRequest(req)
+	if err != nil {
+		return err
+	}
+
+	return s.Store.Set(encReq)
+}
+
+// BulkGet returns the decrypted values. A value which cannot be decrypted
+// is returned with an error set on its response.
+func (s *Store) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	supported, res, err := s.Store.BulkGet(req)
+	if err != nil || !supported {
+		return supported, res, err
+	}
+
+	for i := range res {
+		if res[i].Error != "" {
+			continue
+		}
+		if res[i].Data, err = s.decrypt(res[i].Key, res[i].Data); err != nil {
+			res[i].ETag = nil
+			res[i].Error = err.Error()
+		}
+	}
+
+	return true, res, nil
+}
+
+// BulkSet encrypts the values and saves them in the underlying store.
+func (s *Store) BulkSet(req []state.SetRequest) error {
+	encReqs := make([]state.SetRequest, len(req))
+	for i := range req {
+		encReq, err := s.encryptRequest(&req[i])
+		if err != nil {
+			return err
+		}
+		encReqs[i] = *encReq
+	}
+
+	return s.Store.BulkSet(encReqs)
+}
+
+// Multi encrypts the values of the upsert operations and executes the
+// transaction in the underlying store.
+func (s *Store) Multi(request *state.TransactionalStateRequest) error {
+	transactionalStore, ok := s.Store.(state.TransactionalStore)
+	if !ok {
+		return errors.New("encryption: the state store does not support transactions")
+	}
+
+	encRequest := *request
+	encRequest.Operations = make([]state.TransactionalStateOperation, len(request.Operations))
+	for i, o := range request.Operations {
+		if o.Operation == state.Upsert {
+			var setReq state.SetRequest
+			switch r := o.Request.(type) {
+			case state.SetRequest:
+				setReq = r
+			case *state.SetRequest:
+				setReq = *r
+			default:
+				return fmt.Errorf("encryption: unexpected request type %T for upsert operation", o.Request)
+			}
+			encReq, err := s.encryptRequest(&setReq)
+			if err != nil {
+				return err
+			}
+			o.Request = *encReq
+		}
+		encRequest.Operations[i] = o
+	}
+
+	return transactionalStore.Multi(&encRequest)
+}
+
+// Query executes the query in the underlying store and decrypts the results.
+func (s *Store) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	querier, ok := s.Store.(state.Querier)
+	if !ok {
+		return nil, errors.New("encryption: the state store does not support queries")
+	}
+
+	q := req.Query
+	if q.Filter != nil || len(q.Filters) > 0 || len(q.Sort) > 0 || q.Aggregate != nil || len(q.Fields) > 0 {
+		return nil, errors.New("encryption: the queries of encrypted values cannot filter, sort, aggregate or select fields")
+	}
+
+	res, err := querier.Query(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range res.Results {
+		item := &res.Results[i]
+		if item.Error != "" {
+			continue
+		}
+		if item.Data, err = s.decrypt(item.Key, item.Data); err != nil {
+			item.ETag = nil
+			item.Error = err.Error()
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	key1 = "000102030405060708090a0b0c0d0e0f000102030405060708090a0b0c0d0e0f"
+	key2 = "AAECAwQFBgcICQoLDA0ODw=="
+)
+
+type fakeSecretStore struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretStore) Init(metadata secretstores.Metadata) error {
+	return nil
+}
+
+func (f *fakeSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	v, ok := f.secrets[req.Name]
+	if !ok {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("secret %s not found", req.Name)
+	}
+
+	return secretstores.GetSecretResponse{Data: map[string]string{req.Name: v}}, nil
+}
+
+func (f *fakeSecretStore) BulkGetSecret(req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{}, nil
+}
+
+func newStore(t *testing.T) (state.Store, *fakeSecretStore) {
+	inner := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, inner.Init(state.Metadata{}))
+
+	return inner, &fakeSecretStore{secrets: map[string]string{"key1": key1, "key2": key2}}
+}
+
+func TestNewEncryptedStore(t *testing.T) {
+	inner, secrets := newStore(t)
+
+	t.Run("valid keys", func(t *testing.T) {
+		_, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key1", SecondaryKeys: []string{"key2"}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing primary key", func(t *testing.T) {
+		_, err := NewEncryptedStore(inner, Options{SecretStore: secrets})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown secret", func(t *testing.T) {
+		_, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key3"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		secrets := &fakeSecretStore{secrets: map[string]string{"key1": "0001"}}
+		_, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key1"})
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptedStore(t *testing.T) {
+	inner, secrets := newStore(t)
+	store, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key1"})
+	require.NoError(t, err)
+
+	t.Run("set and get", func(t *testing.T) {
+		err := store.Set(&state.SetRequest{Key: "k1", Value: map[string]string{"name": "John"}})
+		require.NoError(t, err)
+
+		res, err := store.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"John"}`, string(res.Data))
+
+		raw, err := inner.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw.Data), "John")
+	})
+
+	t.Run("get a missing key", func(t *testing.T) {
+		res, err := store.Get(&state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Empty(t, res.Data)
+	})
+
+	t.Run("get a value which is not encrypted", func(t *testing.T) {
+		require.NoError(t, inner.Set(&state.SetRequest{Key: "plain", Value: "John"}))
+
+		_, err := store.Get(&state.GetRequest{Key: "plain"})
+		assert.Error(t, err)
+	})
+
+	t.Run("get a value moved to another key", func(t *testing.T) {
+		raw, err := inner.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		require.NoError(t, inner.Set(&state.SetRequest{Key: "k2", Value: raw.Data}))
+
+		_, err = store.Get(&state.GetRequest{Key: "k2"})
+		assert.Error(t, err)
+	})
+
+	t.Run("bulk set and bulk get", func(t *testing.T) {
+		err := store.BulkSet([]state.SetRequest{{Key: "b1", Value: "v1"}, {Key: "b2", Value: []byte("v2")}})
+		require.NoError(t, err)
+
+		res1, err := store.Get(&state.GetRequest{Key: "b1"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v1"`, string(res1.Data))
+
+		res2, err := store.Get(&state.GetRequest{Key: "b2"})
+		require.NoError(t, err)
+		assert.Equal(t, "v2", string(res2.Data))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		res, err := store.Query(&state.QueryRequest{Query: query.Query{}})
+		require.NoError(t, err)
+
+		found := map[string]string{}
+		for _, item := range res.Results {
+			found[item.Key] = string(item.Data)
+			if item.Key == "plain" || item.Key == "k2" {
+				assert.NotEmpty(t, item.Error)
+			} else {
+				assert.Empty(t, item.Error)
+			}
+		}
+		assert.JSONEq(t, `{"name":"John"}`, found["k1"])
+		assert.Equal(t, `"v1"`, found["b1"])
+	})
+
+	t.Run("query with filters", func(t *testing.T) {
+		for _, data := range []string{
+			`{"filter":{"EQ":{"name":"John"}}}`,
+			`{"sort":[{"key":"name"}]}`,
+		} {
+			var q query.Query
+			require.NoError(t, json.Unmarshal([]byte(data), &q))
+			_, err := store.Query(&state.QueryRequest{Query: q})
+			assert.Error(t, err, data)
+		}
+	})
+}
+
+type fakeTransactionalStore struct {
+	state.Store
+	request *state.TransactionalStateRequest
+}
+
+func (f *fakeTransactionalStore) Multi(request *state.TransactionalStateRequest) error {
+	f.request = request
+
+	return nil
+}
+
+func TestEncryptedStoreMulti(t *testing.T) {
+	inner, secrets := newStore(t)
+	fake := &fakeTransactionalStore{Store: inner}
+	store, err := NewEncryptedStore(fake, Options{SecretStore: secrets, PrimaryKey: "key1"})
+	require.NoError(t, err)
+
+	deleteReq := state.DeleteRequest{Key: "k2"}
+	err = store.Multi(&state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			{Operation: state.Upsert, Request: state.SetRequest{Key: "k1", Value: "v1"}},
+			{Operation: state.Delete, Request: deleteReq},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, fake.request.Operations, 2)
+	setReq := fake.request.Operations[0].Request.(state.SetRequest)
+	assert.Equal(t, "k1", setReq.Key)
+	data, err := store.decrypt("k1", []byte(setReq.Value.(string)))
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(data))
+	assert.Equal(t, deleteReq, fake.request.Operations[1].Request)
+}
+
+func TestKeyRotation(t *testing.T) {
+	inner, secrets := newStore(t)
+
+	oldStore, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key2"})
+	require.NoError(t, err)
+	require.NoError(t, oldStore.Set(&state.SetRequest{Key: "k1", Value: "v1"}))
+
+	t.Run("values encrypted with a secondary key can be read", func(t *testing.T) {
+		store, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key1", SecondaryKeys: []string{"key2"}})
+		require.NoError(t, err)
+
+		res, err := store.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v1"`, string(res.Data))
+
+		// values are saved again with the primary key
+		require.NoError(t, store.Set(&state.SetRequest{Key: "k1", Value: "v2"}))
+		_, err = oldStore.Get(&state.GetRequest{Key: "k1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("values encrypted with a removed key cannot be read", func(t *testing.T) {
+		require.NoError(t, oldStore.Set(&state.SetRequest{Key: "k2", Value: "v1"}))
+		store, err := NewEncryptedStore(inner, Options{SecretStore: secrets, PrimaryKey: "key1"})
+		require.NoError(t, err)
+
+		_, err = store.Get(&state.GetRequest{Key: "k2"})
+		assert.Error(t, err)
+	})
+}
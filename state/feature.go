@@ -20,6 +20,10 @@ const (
 	FeatureTransactional Feature = "TRANSACTIONAL"
 	// FeatureQueryAPI is the feature that performs query operations.
 	FeatureQueryAPI Feature = "QUERY_API"
+	// FeatureListKeys is the feature that lists the keys of the store.
+	FeatureListKeys Feature = "LIST_KEYS"
+	// FeatureTTL is the feature that expires values based on the ttlInSeconds metadata.
+	FeatureTTL Feature = "TTL"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
@@ -25,6 +25,7 @@ import (
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const defaultEntityKind = "DaprState"
@@ -32,8 +33,9 @@ const defaultEntityKind = "DaprState"
 // Firestore State Store.
 type Firestore struct {
 	state.DefaultBulkStore
-	client     *datastore.Client
-	entityKind string
+	client      *datastore.Client
+	entityKind  string
+	retryConfig retry.Config
 
 	logger logger.Logger
 }
@@ -69,6 +71,10 @@ func (f *Firestore) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	f.retryConfig, err = state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		return err
+	}
 	b, err := json.Marshal(meta)
 	if err != nil {
 		return err
@@ -142,7 +148,7 @@ func (f *Firestore) setValue(req *state.SetRequest) error {
 
 // Set saves state into Firestore with retry.
 func (f *Firestore) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(f.setValue, req)
+	return state.SetWithRetries(f.setValue, req, f.retryConfig)
 }
 
 func (f *Firestore) deleteValue(req *state.DeleteRequest) error {
@@ -159,7 +165,7 @@ func (f *Firestore) deleteValue(req *state.DeleteRequest) error {
 
 // Delete performs a delete operation.
 func (f *Firestore) Delete(req *state.DeleteRequest) error {
-	return state.DeleteWithOptions(f.deleteValue, req)
+	return state.DeleteWithRetries(f.deleteValue, req, f.retryConfig)
 }
 
 func getFirestoreMetadata(metadata state.Metadata) (*firestoreMetadata, error) {
@@ -23,6 +23,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 )
 
@@ -87,7 +88,7 @@ func (store *Hazelcast) Init(metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (store *Hazelcast) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag}
 }
 
 // Set stores value for a key to Hazelcast.
@@ -107,7 +108,28 @@ func (store *Hazelcast) Set(req *state.SetRequest) error {
 			return fmt.Errorf("hazelcast error: failed to set key %s: %s", req.Key, err)
 		}
 	}
-	_, err = store.hzMap.Put(req.Key, value)
+
+	switch {
+	case hasETag(req.ETag) && req.Options.Concurrency != state.LastWrite:
+		var current string
+		current, err = store.getWithETag(req.Key, *req.ETag)
+		if err != nil {
+			return err
+		}
+		var replaced bool
+		replaced, err = store.hzMap.ReplaceIfSame(req.Key, current, value)
+		if err == nil && !replaced {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+	case req.Options.Concurrency == state.FirstWrite:
+		var previous interface{}
+		previous, err = store.hzMap.PutIfAbsent(req.Key, value)
+		if err == nil && previous != nil {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+	default:
+		_, err = store.hzMap.Put(req.Key, value)
+	}
 
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to set key %s: %s", req.Key, err)
@@ -127,22 +149,67 @@ func (store *Hazelcast) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	if resp == nil {
 		return &state.GetResponse{}, nil
 	}
-	value, err := store.json.Marshal(&resp)
+	value, err := store.valueBytes(resp)
 	if err != nil {
 		return nil, fmt.Errorf("hazelcast error: %v", err)
 	}
+	etag := utils.ValueETag(value)
 
 	return &state.GetResponse{
 		Data: value,
+		ETag: &etag,
 	}, nil
 }
 
+// getWithETag returns the value of key if its ETag is etag.
+func (store *Hazelcast) getWithETag(key string, etag string) (string, error) {
+	resp, err := store.hzMap.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("hazelcast error: failed to get value for %s: %s", key, err)
+	}
+	if resp == nil {
+		return "", state.NewETagError(state.ETagMismatch, nil)
+	}
+	current, ok := resp.(string)
+	if !ok || utils.ValueETag([]byte(current)) != etag {
+		return "", state.NewETagError(state.ETagMismatch, nil)
+	}
+
+	return current, nil
+}
+
+// valueBytes returns the content of a value saved by Set, which saves the
+// values as strings.
+func (store *Hazelcast) valueBytes(resp interface{}) ([]byte, error) {
+	if value, ok := resp.(string); ok {
+		return []byte(value), nil
+	}
+
+	return store.json.Marshal(&resp)
+}
+
 // Delete performs a delete operation.
 func (store *Hazelcast) Delete(req *state.DeleteRequest) error {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
 	}
+	if hasETag(req.ETag) && req.Options.Concurrency != state.LastWrite {
+		current, err := store.getWithETag(req.Key, *req.ETag)
+		if err != nil {
+			return err
+		}
+		removed, err := store.hzMap.RemoveIfSame(req.Key, current)
+		if err != nil {
+			return fmt.Errorf("hazelcast error: failed to delete key - %s", req.Key)
+		}
+		if !removed {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+
+		return nil
+	}
+
 	err = store.hzMap.Delete(req.Key)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to delete key - %s", req.Key)
@@ -150,3 +217,7 @@ func (store *Hazelcast) Delete(req *state.DeleteRequest) error {
 
 	return nil
 }
+
+func hasETag(etag *string) bool {
+	return etag != nil && *etag != ""
+}
@@ -16,11 +16,64 @@ package hazelcast
 import (
 	"testing"
 
+	"github.com/hazelcast/hazelcast-go-client/core"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
 )
 
+// fakeMap implements the operations of core.Map used by the state store.
+type fakeMap struct {
+	core.Map
+	entries map[interface{}]interface{}
+}
+
+func (m *fakeMap) Get(key interface{}) (interface{}, error) {
+	return m.entries[key], nil
+}
+
+func (m *fakeMap) Put(key interface{}, value interface{}) (interface{}, error) {
+	old := m.entries[key]
+	m.entries[key] = value
+
+	return old, nil
+}
+
+func (m *fakeMap) PutIfAbsent(key interface{}, value interface{}) (interface{}, error) {
+	old, ok := m.entries[key]
+	if !ok {
+		m.entries[key] = value
+	}
+
+	return old, nil
+}
+
+func (m *fakeMap) ReplaceIfSame(key interface{}, oldValue interface{}, newValue interface{}) (bool, error) {
+	if m.entries[key] != oldValue {
+		return false, nil
+	}
+	m.entries[key] = newValue
+
+	return true, nil
+}
+
+func (m *fakeMap) RemoveIfSame(key interface{}, value interface{}) (bool, error) {
+	if m.entries[key] != value {
+		return false, nil
+	}
+	delete(m.entries, key)
+
+	return true, nil
+}
+
+func (m *fakeMap) Delete(key interface{}) error {
+	delete(m.entries, key)
+
+	return nil
+}
+
 func TestValidateMetadata(t *testing.T) {
 	t.Run("without required configuration", func(t *testing.T) {
 		properties := map[string]string{}
@@ -65,3 +118,46 @@ func TestValidateMetadata(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestETag(t *testing.T) {
+	store := NewHazelcastStore(logger.NewLogger("test"))
+	store.hzMap = &fakeMap{entries: map[interface{}]interface{}{}}
+
+	requireETagError := func(t *testing.T, err error) {
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	}
+
+	firstWrite := state.SetStateOption{Concurrency: state.FirstWrite}
+	err := store.Set(&state.SetRequest{Key: "key", Value: []byte("value1"), Options: firstWrite})
+	require.NoError(t, err)
+	err = store.Set(&state.SetRequest{Key: "key", Value: []byte("value2"), Options: firstWrite})
+	requireETagError(t, err)
+
+	res, err := store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "value1", string(res.Data))
+	require.NotNil(t, res.ETag)
+	etag := res.ETag
+
+	wrongETag := "wrong"
+	err = store.Set(&state.SetRequest{Key: "key", Value: []byte("value2"), ETag: &wrongETag})
+	requireETagError(t, err)
+	err = store.Set(&state.SetRequest{Key: "key", Value: []byte("value2"), ETag: etag})
+	require.NoError(t, err)
+
+	res, err = store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "value2", string(res.Data))
+	assert.NotEqual(t, *etag, *res.ETag)
+
+	err = store.Delete(&state.DeleteRequest{Key: "key", ETag: etag})
+	requireETagError(t, err)
+	err = store.Delete(&state.DeleteRequest{Key: "key", ETag: res.ETag})
+	require.NoError(t, err)
+
+	res, err = store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+}
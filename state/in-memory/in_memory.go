@@ -16,7 +16,9 @@ package inmemory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,18 +27,31 @@ import (
 	"github.com/dapr/kit/logger"
 
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
 )
 
 type inMemStateStoreItem struct {
-	data   []byte
+	data []byte
+	// binary is true if data holds the bytes of a value saved as is.
+	binary bool
 	etag   *string
 	expire int64
 }
 
+// value returns the value of the item, as returned by Get.
+func (item *inMemStateStoreItem) value() []byte {
+	if item.binary {
+		return item.data
+	}
+
+	return unmarshal(item.data)
+}
+
 type inMemoryStore struct {
-	items map[string]*inMemStateStoreItem
-	lock  *sync.RWMutex
-	log   logger.Logger
+	items    map[string]*inMemStateStoreItem
+	watchers map[*inMemWatcher]struct{}
+	lock     *sync.RWMutex
+	log      logger.Logger
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -44,9 +59,10 @@ type inMemoryStore struct {
 
 func NewInMemoryStateStore(logger logger.Logger) state.Store {
 	return &inMemoryStore{
-		items: map[string]*inMemStateStoreItem{},
-		lock:  &sync.RWMutex{},
-		log:   logger,
+		items:    map[string]*inMemStateStoreItem{},
+		watchers: map[*inMemWatcher]struct{}{},
+		lock:     &sync.RWMutex{},
+		log:      logger,
 	}
 }
 
@@ -70,7 +86,7 @@ func (store *inMemoryStore) Close() error {
 }
 
 func (store *inMemoryStore) Features() []state.Feature {
-	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureListKeys, state.FeatureTTL}
 }
 
 func (store *inMemoryStore) Delete(req *state.DeleteRequest) error {
@@ -94,6 +110,15 @@ func (store *inMemoryStore) Delete(req *state.DeleteRequest) error {
 	return nil
 }
 
+// DeleteWithContext performs a delete operation, honoring the cancellation of ctx.
+func (store *inMemoryStore) DeleteWithContext(ctx context.Context, req *state.DeleteRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.Delete(req)
+}
+
 func (store *inMemoryStore) doDeleteValidateParameters(req *state.DeleteRequest) error {
 	return state.CheckRequestOptions(req.Options)
 }
@@ -118,7 +143,13 @@ func (store *inMemoryStore) doValidateEtag(key string, etag *string, concurrency
 }
 
 func (store *inMemoryStore) doDelete(key string) {
+	if _, ok := store.items[key]; !ok {
+		return
+	}
 	delete(store.items, key)
+	if len(store.watchers) != 0 {
+		store.doNotify(&state.WatchEvent{Key: key, Operation: state.Delete})
+	}
 }
 
 func (store *inMemoryStore) BulkDelete(req []state.DeleteRequest) error {
@@ -153,6 +184,15 @@ func (store *inMemoryStore) BulkDelete(req []state.DeleteRequest) error {
 	return nil
 }
 
+// BulkDeleteWithContext performs a bulk delete operation, honoring the cancellation of ctx.
+func (store *inMemoryStore) BulkDeleteWithContext(ctx context.Context, req []state.DeleteRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.BulkDelete(req)
+}
+
 func (store *inMemoryStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	item := store.doGetWithReadLock(req.Key)
 	if item != nil && isExpired(item.expire) {
@@ -162,7 +202,22 @@ func (store *inMemoryStore) Get(req *state.GetRequest) (*state.GetResponse, erro
 	if item == nil {
 		return &state.GetResponse{Data: nil, ETag: nil}, nil
 	}
-	return &state.GetResponse{Data: unmarshal(item.data), ETag: item.etag}, nil
+	res := &state.GetResponse{Data: item.value(), ETag: item.etag}
+	if item.expire > 0 {
+		// report the remaining ttl, e.g. to export it with the value
+		res.Metadata = utils.WithTTLMetadata(nil, time.Until(time.UnixMilli(item.expire)))
+	}
+
+	return res, nil
+}
+
+// GetWithContext retrieves a value, honoring the cancellation of ctx.
+func (store *inMemoryStore) GetWithContext(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return store.Get(req)
 }
 
 func (store *inMemoryStore) doGetWithReadLock(key string) *inMemStateStoreItem {
@@ -198,6 +253,32 @@ func (store *inMemoryStore) BulkGet(req []state.GetRequest) (bool, []state.BulkG
 	return false, nil, nil
 }
 
+// BulkGetWithContext performs a bulk get operation, honoring the cancellation of ctx.
+func (store *inMemoryStore) BulkGetWithContext(ctx context.Context, req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	return store.BulkGet(req)
+}
+
+// ListKeys returns the keys starting with req.Prefix in lexical order.
+// The token is the last key of the previous page.
+func (store *inMemoryStore) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	store.lock.RLock()
+	keys := []string{}
+	for key, item := range store.items {
+		if strings.HasPrefix(key, req.Prefix) && key > req.Token && !isExpired(item.expire) {
+			keys = append(keys, key)
+		}
+	}
+	store.lock.RUnlock()
+
+	sort.Strings(keys)
+
+	return state.NewListKeysResponse(keys, req.Limit), nil
+}
+
 func (store *inMemoryStore) Set(req *state.SetRequest) error {
 	// step1: validate parameters
 	ttlInSeconds, err := store.doSetValidateParameters(req)
@@ -205,7 +286,7 @@ func (store *inMemoryStore) Set(req *state.SetRequest) error {
 		return err
 	}
 
-	b, _ := marshal(req.Value)
+	b, binary := marshal(req.Value)
 	// step2 and step3 should be protected by write-lock
 	store.lock.Lock()
 	defer store.lock.Unlock()
@@ -217,10 +298,19 @@ func (store *inMemoryStore) Set(req *state.SetRequest) error {
 
 	// step3: do really set
 	// this operation won't fail
-	store.doSet(req.Key, b, req.ETag, ttlInSeconds)
+	store.doSet(req.Key, b, binary, req.ETag, ttlInSeconds)
 	return nil
 }
 
+// SetWithContext saves a value, honoring the cancellation of ctx.
+func (store *inMemoryStore) SetWithContext(ctx context.Context, req *state.SetRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.Set(req)
+}
+
 func (store *inMemoryStore) doSetValidateParameters(req *state.SetRequest) (int, error) {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
@@ -253,11 +343,21 @@ func doParseTTLInSeconds(metadata map[string]string) (int, error) {
 	return i, nil
 }
 
-func (store *inMemoryStore) doSet(key string, data []byte, etag *string, ttlInSeconds int) {
-	store.items[key] = &inMemStateStoreItem{
+func (store *inMemoryStore) doSet(key string, data []byte, binary bool, etag *string, ttlInSeconds int) {
+	// items without a ttl never expire
+	var expire int64
+	if ttlInSeconds > 0 {
+		expire = time.Now().UnixMilli() + int64(ttlInSeconds)*1000
+	}
+	item := &inMemStateStoreItem{
 		data:   data,
+		binary: binary,
 		etag:   etag,
-		expire: time.Now().UnixMilli() + int64(ttlInSeconds)*1000,
+		expire: expire,
+	}
+	store.items[key] = item
+	if len(store.watchers) != 0 {
+		store.doNotify(&state.WatchEvent{Key: key, Operation: state.Upsert, Value: item.value(), ETag: etag})
 	}
 }
 
@@ -266,6 +366,7 @@ type innerSetRequest struct {
 	req          state.SetRequest
 	ttlInSeconds int
 	data         []byte
+	binary       bool
 }
 
 func (store *inMemoryStore) BulkSet(req []state.SetRequest) error {
@@ -281,11 +382,12 @@ func (store *inMemoryStore) BulkSet(req []state.SetRequest) error {
 			return err
 		}
 
-		b, _ := marshal(req[i].Value)
+		b, binary := marshal(req[i].Value)
 		innerSetRequest := &innerSetRequest{
 			req:          req[i],
 			ttlInSeconds: ttlInSeconds,
 			data:         b,
+			binary:       binary,
 		}
 		innerSetRequestList = append(innerSetRequestList, innerSetRequest)
 	}
@@ -305,11 +407,20 @@ func (store *inMemoryStore) BulkSet(req []state.SetRequest) error {
 	// step3: do really set
 	// these operations won't fail
 	for _, innerSetRequest := range innerSetRequestList {
-		store.doSet(innerSetRequest.req.Key, innerSetRequest.data, innerSetRequest.req.ETag, innerSetRequest.ttlInSeconds)
+		store.doSet(innerSetRequest.req.Key, innerSetRequest.data, innerSetRequest.binary, innerSetRequest.req.ETag, innerSetRequest.ttlInSeconds)
 	}
 	return nil
 }
 
+// BulkSetWithContext performs a bulk set operation, honoring the cancellation of ctx.
+func (store *inMemoryStore) BulkSetWithContext(ctx context.Context, req []state.SetRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.BulkSet(req)
+}
+
 func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) error {
 	if len(request.Operations) == 0 {
 		return nil
@@ -323,11 +434,12 @@ func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) erro
 			if err != nil {
 				return err
 			}
-			b, _ := marshal(s.Value)
+			b, binary := marshal(s.Value)
 			innerSetRequest := &innerSetRequest{
 				req:          s,
 				ttlInSeconds: ttlInSeconds,
 				data:         b,
+				binary:       binary,
 			}
 			// replace with innerSetRequest
 			o.Request = innerSetRequest
@@ -366,7 +478,7 @@ func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) erro
 	for _, o := range request.Operations {
 		if o.Operation == state.Upsert {
 			s := o.Request.(innerSetRequest)
-			store.doSet(s.req.Key, s.data, s.req.ETag, s.ttlInSeconds)
+			store.doSet(s.req.Key, s.data, s.binary, s.req.ETag, s.ttlInSeconds)
 		} else if o.Operation == state.Delete {
 			d := o.Request.(state.DeleteRequest)
 			store.doDelete(d.Key)
@@ -375,10 +487,28 @@ func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) erro
 	return nil
 }
 
-func marshal(value interface{}) ([]byte, error) {
+// MultiWithContext performs a transactional operation, honoring the cancellation of ctx.
+func (store *inMemoryStore) MultiWithContext(ctx context.Context, request *state.TransactionalStateRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.Multi(request)
+}
+
+// marshal returns the data saved for value, and whether it holds the bytes of
+// value as is. Byte values are kept as is, like the other stores do, so that
+// they are returned unchanged, even if they are not valid UTF-8.
+func marshal(value interface{}) ([]byte, bool) {
+	if b, ok := value.([]byte); ok {
+		data := make([]byte, len(b))
+		copy(data, b)
+
+		return data, true
+	}
 	v, _ := jsoniter.MarshalToString(value)
 
-	return []byte(v), nil
+	return []byte(v), false
 }
 
 func unmarshal(val interface{}) []byte {
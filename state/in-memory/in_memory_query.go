@@ -0,0 +1,441 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// queryResult is a stored item selected by a query, along with its decoded document.
+type queryResult struct {
+	key  string
+	doc  interface{}
+	data []byte
+	etag *string
+}
+
+// Query evaluates the filter, sorting and pagination of the query over the
+// JSON values in the store. The pagination token is the number of items
+// to skip, as in the PostgreSQL state store. The documents are projected on
+// the fields of the query, if any, or replaced by its aggregations.
+func (store *inMemoryStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	var skip int
+	if len(req.Query.Page.Token) != 0 {
+		var err error
+		if skip, err = strconv.Atoi(req.Query.Page.Token); err != nil || skip < 0 {
+			return nil, fmt.Errorf("invalid pagination token %q", req.Query.Page.Token)
+		}
+	}
+
+	results, err := store.doQueryWithReadLock(req.Query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	sortResults(results, req.Query.Sort)
+
+	if req.Query.Aggregate != nil {
+		return &state.QueryResponse{
+			Aggregations: aggregateResults(results, req.Query.Aggregate),
+		}, nil
+	}
+
+	if skip > len(results) {
+		skip = len(results)
+	}
+	results = results[skip:]
+	if limit := req.Query.Page.Limit; limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+
+	resp := &state.QueryResponse{
+		Results: make([]state.QueryItem, len(results)),
+	}
+	for i, res := range results {
+		data := res.data
+		if len(req.Query.Fields) > 0 {
+			if data, err = projectFields(res.doc, req.Query.Fields); err != nil {
+				return nil, err
+			}
+		}
+		resp.Results[i] = state.QueryItem{
+			Key:  res.key,
+			Data: data,
+			ETag: res.etag,
+		}
+	}
+	if req.Query.Page.Limit > 0 {
+		resp.Token = strconv.Itoa(skip + len(results))
+	}
+
+	return resp, nil
+}
+
+// QueryWithContext performs a query, honoring the cancellation of ctx.
+func (store *inMemoryStore) QueryWithContext(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return store.Query(req)
+}
+
+func (store *inMemoryStore) doQueryWithReadLock(filter query.Filter) ([]queryResult, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	results := []queryResult{}
+	for key, item := range store.items {
+		if isExpired(item.expire) {
+			continue
+		}
+		doc, data := decodeDocument(item.data)
+		if filter != nil {
+			match, err := matchFilter(filter, doc)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		results = append(results, queryResult{key: key, doc: doc, data: data, etag: item.etag})
+	}
+
+	return results, nil
+}
+
+// decodeDocument decodes a stored value. Values saved as strings holding a
+// JSON document are queried as that document.
+func decodeDocument(data []byte) (interface{}, []byte) {
+	var doc interface{}
+	if err := jsoniter.Unmarshal(data, &doc); err != nil {
+		return nil, data
+	}
+	if str, ok := doc.(string); ok {
+		var inner interface{}
+		if err := jsoniter.UnmarshalFromString(str, &inner); err == nil {
+			return inner, []byte(str)
+		}
+	}
+
+	return doc, data
+}
+
+// projectFields returns a document which only holds the fields of doc, at the
+// same paths. The fields missing from doc are left out.
+func projectFields(doc interface{}, fields []string) ([]byte, error) {
+	projection := map[string]interface{}{}
+	for _, field := range fields {
+		val, ok := lookupField(doc, field)
+		if !ok {
+			continue
+		}
+		names := strings.Split(field, ".")
+		parent := projection
+		for _, name := range names[:len(names)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[name] = child
+			}
+			parent = child
+		}
+		parent[names[len(names)-1]] = val
+	}
+
+	return jsoniter.Marshal(projection)
+}
+
+// aggregationGroup holds the documents with the same values of the group by keys.
+type aggregationGroup struct {
+	values []interface{}
+	docs   []interface{}
+}
+
+// aggregateResults computes the aggregations over the results, for each group
+// of results with the same values of the group by keys, in the order in which
+// the groups first appear. Without group by keys the aggregations are computed
+// over all the results, even if there are none.
+func aggregateResults(results []queryResult, aggregate *query.Aggregate) []state.AggregationResult {
+	groups := []*aggregationGroup{}
+	index := map[string]*aggregationGroup{}
+	if len(aggregate.GroupBy) == 0 {
+		groups = append(groups, &aggregationGroup{})
+	}
+	for _, res := range results {
+		if len(aggregate.GroupBy) == 0 {
+			groups[0].docs = append(groups[0].docs, res.doc)
+
+			continue
+		}
+		values := make([]interface{}, len(aggregate.GroupBy))
+		for i, key := range aggregate.GroupBy {
+			values[i], _ = lookupField(res.doc, key)
+		}
+		// the values were decoded from JSON, so they can be encoded back
+		id, _ := jsoniter.MarshalToString(values)
+		group, ok := index[id]
+		if !ok {
+			group = &aggregationGroup{values: values}
+			index[id] = group
+			groups = append(groups, group)
+		}
+		group.docs = append(group.docs, res.doc)
+	}
+
+	ret := make([]state.AggregationResult, len(groups))
+	for i, group := range groups {
+		ret[i].Values = make(map[string]interface{}, len(aggregate.Aggregations))
+		if len(aggregate.GroupBy) > 0 {
+			ret[i].Group = make(map[string]interface{}, len(aggregate.GroupBy))
+			for j, key := range aggregate.GroupBy {
+				ret[i].Group[key] = group.values[j]
+			}
+		}
+		for _, agg := range aggregate.Aggregations {
+			ret[i].Values[agg.Alias] = aggregateDocuments(agg, group.docs)
+		}
+	}
+
+	return ret
+}
+
+// aggregateDocuments computes an aggregation over documents. As in SQL, the
+// operators other than count ignore the documents whose field is missing or
+// is not a number, and are nil if there is no such number.
+func aggregateDocuments(agg query.Aggregation, docs []interface{}) interface{} {
+	if agg.Op == query.COUNT {
+		return int64(len(docs))
+	}
+
+	var count int
+	var sum, min, max float64
+	for _, doc := range docs {
+		val, _ := lookupField(doc, agg.Key)
+		num, ok := val.(float64)
+		if !ok {
+			continue
+		}
+		if count == 0 || num < min {
+			min = num
+		}
+		if count == 0 || num > max {
+			max = num
+		}
+		sum += num
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	switch agg.Op {
+	case query.SUM:
+		return sum
+	case query.MIN:
+		return min
+	case query.MAX:
+		return max
+	default:
+		return sum / float64(count)
+	}
+}
+
+// sortResults orders the results by the sorting keys. Ties are broken by
+// the state key so that pagination is stable.
+func sortResults(results []queryResult, sorting []query.Sorting) {
+	sort.Slice(results, func(i, j int) bool {
+		for _, item := range sorting {
+			a, _ := lookupField(results[i].doc, item.Key)
+			b, _ := lookupField(results[j].doc, item.Key)
+			c := compareSortValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if item.Order == query.DESC {
+				return c > 0
+			}
+
+			return c < 0
+		}
+
+		return results[i].key < results[j].key
+	})
+}
+
+// compareSortValues compares two values of any type. Values of different
+// types are ordered missing values first, then numbers, strings and the rest.
+func compareSortValues(a, b interface{}) int {
+	if c, ok := compareValues(a, b); ok {
+		return c
+	}
+	ra, rb := sortRank(a), sortRank(b)
+	switch {
+	case ra < rb:
+		return -1
+	case ra > rb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func sortRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case float64:
+		return 1
+	case string:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// matchFilter evaluates the filter against a JSON document decoded into
+// interface{} values. A field which is missing from the document never
+// matches EQ, IN or a range filter, and always matches NEQ and NIN.
+func matchFilter(filter query.Filter, doc interface{}) (bool, error) {
+	switch f := filter.(type) {
+	case *query.EQ:
+		val, ok := lookupField(doc, f.Key)
+
+		return ok && reflect.DeepEqual(val, f.Val), nil
+	case *query.NEQ:
+		val, ok := lookupField(doc, f.Key)
+
+		return !ok || !reflect.DeepEqual(val, f.Val), nil
+	case *query.GT:
+		return matchRange(doc, f.Key, f.Val, func(c int) bool { return c > 0 })
+	case *query.GTE:
+		return matchRange(doc, f.Key, f.Val, func(c int) bool { return c >= 0 })
+	case *query.LT:
+		return matchRange(doc, f.Key, f.Val, func(c int) bool { return c < 0 })
+	case *query.LTE:
+		return matchRange(doc, f.Key, f.Val, func(c int) bool { return c <= 0 })
+	case *query.IN:
+		if len(f.Vals) == 0 {
+			return false, fmt.Errorf("empty IN operator for key %q", f.Key)
+		}
+		val, ok := lookupField(doc, f.Key)
+
+		return ok && containsValue(f.Vals, val), nil
+	case *query.NIN:
+		if len(f.Vals) == 0 {
+			return false, fmt.Errorf("empty NIN operator for key %q", f.Key)
+		}
+		val, ok := lookupField(doc, f.Key)
+
+		return !ok || !containsValue(f.Vals, val), nil
+	case *query.AND:
+		for _, fil := range f.Filters {
+			match, err := matchFilter(fil, doc)
+			if err != nil || !match {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case *query.OR:
+		for _, fil := range f.Filters {
+			match, err := matchFilter(fil, doc)
+			if err != nil || match {
+				return match, err
+			}
+		}
+
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported filter type %#v", f)
+	}
+}
+
+// lookupField resolves a dot-separated key, e.g. "person.org", in the document.
+func lookupField(doc interface{}, key string) (interface{}, bool) {
+	val := doc
+	for _, name := range strings.Split(key, ".") {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if val, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return val, true
+}
+
+func containsValue(vals []interface{}, val interface{}) bool {
+	for _, v := range vals {
+		if reflect.DeepEqual(v, val) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchRange(doc interface{}, key string, bound interface{}, cmp func(int) bool) (bool, error) {
+	val, ok := lookupField(doc, key)
+	if !ok {
+		return false, nil
+	}
+	c, ok := compareValues(val, bound)
+	if !ok {
+		return false, nil
+	}
+
+	return cmp(c), nil
+}
+
+// compareValues compares two numbers or two strings. It reports false
+// if the values are not comparable.
+func compareValues(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(x, y), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestMatchFilter(t *testing.T) {
+	docs := map[string]string{
+		"1": `{"person":{"id":100,"org":"A"},"state":"WA"}`,
+		"2": `{"person":{"id":120,"org":"B"},"state":"WA"}`,
+		"3": `{"person":{"id":130,"org":"B"},"state":"TX"}`,
+		"4": `{"person":{"id":160,"org":"A"},"state":"CA"}`,
+		"5": `{"person":{"id":170,"org":"A"},"state":"OR"}`,
+		"6": `{"person":{"id":250,"org":"B"},"state":"WA"}`,
+		"7": `{"person":{"org":"B"},"state":"WA","created":"2021-06-15"}`,
+		"8": `{"person":{"id":"110","org":"B"},"state":"NY","created":"2022-01-01"}`,
+	}
+	tests := []struct {
+		input   string
+		matches []string
+	}{
+		{
+			input:   "../../tests/state/query/q2.json",
+			matches: []string{"4"},
+		},
+		{
+			input:   "../../tests/state/query/q4.json",
+			matches: []string{"1", "2", "4", "5", "6", "7"},
+		},
+		{
+			input:   "../../tests/state/query/q7.json",
+			matches: []string{"1", "3", "5"},
+		},
+		{
+			input:   "../../tests/state/query/q8.json",
+			matches: []string{"7"},
+		},
+	}
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(test.input)
+		assert.NoError(t, err)
+		var qq query.Query
+		err = json.Unmarshal(data, &qq)
+		assert.NoError(t, err)
+
+		matches := []string{}
+		for _, key := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+			var doc interface{}
+			err = json.Unmarshal([]byte(docs[key]), &doc)
+			assert.NoError(t, err)
+			match, err := matchFilter(qq.Filter, doc)
+			assert.NoError(t, err)
+			if match {
+				matches = append(matches, key)
+			}
+		}
+		assert.Equal(t, test.matches, matches, test.input)
+	}
+}
+
+func TestMatchFilterEmptyList(t *testing.T) {
+	_, err := matchFilter(&query.NIN{Key: "state"}, map[string]interface{}{"state": "CA"})
+	assert.Error(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test"))
+	store.Init(state.Metadata{})
+	querier, ok := store.(state.Querier)
+	require.True(t, ok)
+	assert.Contains(t, store.Features(), state.FeatureQueryAPI)
+
+	err := store.BulkSet([]state.SetRequest{
+		{Key: "1", Value: map[string]interface{}{"person": map[string]interface{}{"id": 100, "org": "A"}, "state": "WA"}},
+		{Key: "2", Value: map[string]interface{}{"person": map[string]interface{}{"id": 120, "org": "B"}, "state": "WA"}},
+		{Key: "3", Value: `{"person":{"id":130,"org":"B"},"state":"TX"}`},
+		{Key: "4", Value: map[string]interface{}{"person": map[string]interface{}{"id": 160, "org": "A"}, "state": "CA"}},
+		{Key: "5", Value: map[string]interface{}{"person": map[string]interface{}{"id": 170, "org": "A"}, "state": "OR"}},
+		{Key: "6", Value: "not a document"},
+	})
+	require.NoError(t, err)
+
+	queryKeys := func(t *testing.T, input string, token string) ([]string, string) {
+		data, err := ioutil.ReadFile(input)
+		require.NoError(t, err)
+		var qq query.Query
+		require.NoError(t, json.Unmarshal(data, &qq))
+		qq.Page.Token = token
+
+		resp, err := querier.Query(&state.QueryRequest{Query: qq})
+		require.NoError(t, err)
+		keys := make([]string, len(resp.Results))
+		for i, item := range resp.Results {
+			keys[i] = item.Key
+		}
+
+		return keys, resp.Token
+	}
+
+	t.Run("filter, sort and paginate", func(t *testing.T) {
+		keys, token := queryKeys(t, "../../tests/state/query/q7.json", "")
+		assert.Equal(t, []string{"1", "3"}, keys)
+		assert.Equal(t, "2", token)
+
+		keys, token = queryKeys(t, "../../tests/state/query/q7.json", token)
+		assert.Equal(t, []string{"5"}, keys)
+		assert.Equal(t, "3", token)
+	})
+
+	t.Run("sort descending", func(t *testing.T) {
+		keys, token := queryKeys(t, "../../tests/state/query/q4.json", "")
+		assert.Equal(t, []string{"1", "2"}, keys)
+		assert.Equal(t, "2", token)
+
+		keys, _ = queryKeys(t, "../../tests/state/query/q4.json", token)
+		assert.Equal(t, []string{"5", "4"}, keys)
+	})
+
+	t.Run("no filter returns all items", func(t *testing.T) {
+		resp, err := querier.Query(&state.QueryRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Results, 6)
+		assert.Empty(t, resp.Token)
+	})
+
+	t.Run("data is the JSON document", func(t *testing.T) {
+		resp, err := querier.Query(&state.QueryRequest{
+			Query: query.Query{Filter: &query.EQ{Key: "state", Val: "TX"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 1)
+		assert.JSONEq(t, `{"person":{"id":130,"org":"B"},"state":"TX"}`, string(resp.Results[0].Data))
+	})
+
+	t.Run("fields project the documents", func(t *testing.T) {
+		resp, err := querier.Query(&state.QueryRequest{
+			Query: query.Query{
+				Filter: &query.EQ{Key: "state", Val: "TX"},
+				Fields: []string{"person.org", "state", "missing"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 1)
+		assert.JSONEq(t, `{"person":{"org":"B"},"state":"TX"}`, string(resp.Results[0].Data))
+	})
+
+	t.Run("aggregate by group", func(t *testing.T) {
+		resp, err := querier.Query(&state.QueryRequest{
+			Query: query.Query{
+				Aggregate: &query.Aggregate{
+					GroupBy: []string{"person.org"},
+					Aggregations: []query.Aggregation{
+						{Op: query.COUNT, Alias: "count"},
+						{Op: query.SUM, Key: "person.id", Alias: "sum"},
+						{Op: query.MIN, Key: "person.id", Alias: "min"},
+						{Op: query.MAX, Key: "person.id", Alias: "max"},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Results)
+		assert.Equal(t, []state.AggregationResult{
+			{
+				Group:  map[string]interface{}{"person.org": "A"},
+				Values: map[string]interface{}{"count": int64(3), "sum": float64(430), "min": float64(100), "max": float64(170)},
+			},
+			{
+				Group:  map[string]interface{}{"person.org": "B"},
+				Values: map[string]interface{}{"count": int64(2), "sum": float64(250), "min": float64(120), "max": float64(130)},
+			},
+			{
+				Group:  map[string]interface{}{"person.org": nil},
+				Values: map[string]interface{}{"count": int64(1), "sum": nil, "min": nil, "max": nil},
+			},
+		}, resp.Aggregations)
+	})
+
+	t.Run("aggregate the filtered documents", func(t *testing.T) {
+		resp, err := querier.Query(&state.QueryRequest{
+			Query: query.Query{
+				Filter: &query.EQ{Key: "state", Val: "WA"},
+				Aggregate: &query.Aggregate{
+					Aggregations: []query.Aggregation{
+						{Op: query.COUNT, Alias: "count"},
+						{Op: query.AVG, Key: "person.id", Alias: "avg"},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []state.AggregationResult{
+			{Values: map[string]interface{}{"count": int64(2), "avg": float64(110)}},
+		}, resp.Aggregations)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := querier.Query(&state.QueryRequest{
+			Query: query.Query{Page: query.Pagination{Limit: 2, Token: "abc"}},
+		})
+		assert.Error(t, err)
+	})
+}
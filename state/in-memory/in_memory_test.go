@@ -14,6 +14,8 @@ limitations under the License.
 package inmemory
 
 import (
+	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -55,6 +57,20 @@ func TestReadAndWrite(t *testing.T) {
 		assert.Equal(t, valueA, string(resp.Data))
 	})
 
+	t.Run("get reports the remaining ttl", func(t *testing.T) {
+		setReq := &state.SetRequest{
+			Key:      keyA,
+			Value:    valueA,
+			Metadata: map[string]string{"ttlInSeconds": "60"},
+		}
+		err := store.Set(setReq)
+		assert.Nil(t, err)
+
+		resp, err := store.Get(&state.GetRequest{Key: keyA})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"ttlInSeconds": "60"}, resp.Metadata)
+	})
+
 	t.Run("get nothing when expired", func(t *testing.T) {
 		// set with LWW
 		setReq := &state.SetRequest{
@@ -127,3 +143,114 @@ func TestReadAndWrite(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestReadAndWriteWithContext(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test")).(state.StoreWithContext)
+	store.Init(state.Metadata{})
+
+	t.Run("set and get with a live context", func(t *testing.T) {
+		err := store.SetWithContext(context.Background(), &state.SetRequest{
+			Key:   "theKey",
+			Value: "value of key",
+		})
+		assert.Nil(t, err)
+
+		resp, err := store.GetWithContext(context.Background(), &state.GetRequest{
+			Key: "theKey",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "value of key", string(resp.Data))
+	})
+
+	t.Run("operations fail with a canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := store.SetWithContext(ctx, &state.SetRequest{
+			Key:   "theKey",
+			Value: "another value",
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = store.GetWithContext(ctx, &state.GetRequest{
+			Key: "theKey",
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		err = store.DeleteWithContext(ctx, &state.DeleteRequest{
+			Key: "theKey",
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestListKeys(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test"))
+	store.Init(state.Metadata{})
+	lister, ok := store.(state.KeyLister)
+	assert.True(t, ok)
+	assert.Contains(t, store.Features(), state.FeatureListKeys)
+
+	for _, key := range []string{"app||c", "app||a", "other||a", "app||b"} {
+		assert.Nil(t, store.Set(&state.SetRequest{Key: key, Value: "v"}))
+	}
+
+	resp, err := lister.ListKeys(&state.ListKeysRequest{Prefix: "app||", Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app||a", "app||b"}, resp.Keys)
+	assert.Equal(t, "app||b", resp.Token)
+
+	resp, err = lister.ListKeys(&state.ListKeysRequest{Prefix: "app||", Limit: 2, Token: resp.Token})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app||c"}, resp.Keys)
+	assert.Empty(t, resp.Token)
+
+	resp, err = lister.ListKeys(&state.ListKeysRequest{})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Keys, 4)
+	assert.Empty(t, resp.Token)
+}
+
+func TestBinaryValue(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test"))
+	store.Init(state.Metadata{})
+
+	value := []byte{0xff, 0x00, 0xfe, 'a'}
+	assert.Nil(t, store.Set(&state.SetRequest{Key: "binary", Value: value}))
+	resp, err := store.Get(&state.GetRequest{Key: "binary"})
+	assert.Nil(t, err)
+	assert.Equal(t, value, resp.Data)
+
+	assert.Nil(t, store.BulkSet([]state.SetRequest{{Key: "binary", Value: value[1:]}}))
+	resp, err = store.Get(&state.GetRequest{Key: "binary"})
+	assert.Nil(t, err)
+	assert.Equal(t, value[1:], resp.Data)
+}
+
+func TestSnapshotKeepsTTL(t *testing.T) {
+	source := NewInMemoryStateStore(logger.NewLogger("test"))
+	source.Init(state.Metadata{})
+	assert.Nil(t, source.Set(&state.SetRequest{Key: "app||a", Value: "a", Metadata: map[string]string{"ttlInSeconds": "60"}}))
+	assert.Nil(t, source.Set(&state.SetRequest{Key: "app||b", Value: "b"}))
+
+	var buf bytes.Buffer
+	count, err := state.Export(source, &buf, state.ExportOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	target := NewInMemoryStateStore(logger.NewLogger("test"))
+	target.Init(state.Metadata{})
+	count, err = state.Import(target, &buf, state.ImportOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	resp, err := target.Get(&state.GetRequest{Key: "app||a"})
+	assert.Nil(t, err)
+	assert.Equal(t, "a", string(resp.Data))
+	assert.Equal(t, map[string]string{"ttlInSeconds": "60"}, resp.Metadata)
+
+	resp, err = target.Get(&state.GetRequest{Key: "app||b"})
+	assert.Nil(t, err)
+	assert.Equal(t, "b", string(resp.Data))
+	assert.Nil(t, resp.Metadata)
+}
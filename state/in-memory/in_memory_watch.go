@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// inMemWatcher queues the events of a single subscription, so that
+// the store never blocks on a slow handler.
+type inMemWatcher struct {
+	prefix  string
+	handler state.WatchHandler
+
+	lock   sync.Mutex
+	queue  []*state.WatchEvent
+	signal chan struct{}
+}
+
+// Watch delivers the changes of the keys starting with req.KeyPrefix to the handler
+// until ctx is canceled or the store is closed. Events are delivered in order.
+func (store *inMemoryStore) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	w := &inMemWatcher{
+		prefix:  req.KeyPrefix,
+		handler: handler,
+		signal:  make(chan struct{}, 1),
+	}
+
+	store.lock.Lock()
+	store.watchers[w] = struct{}{}
+	store.lock.Unlock()
+
+	go store.runWatcher(ctx, w)
+
+	return nil
+}
+
+func (store *inMemoryStore) runWatcher(ctx context.Context, w *inMemWatcher) {
+	defer func() {
+		store.lock.Lock()
+		delete(store.watchers, w)
+		store.lock.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-store.ctx.Done():
+			return
+		case <-w.signal:
+		}
+
+		w.lock.Lock()
+		events := w.queue
+		w.queue = nil
+		w.lock.Unlock()
+
+		for _, e := range events {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := w.handler(ctx, e); err != nil {
+				store.log.Errorf("error handling state change for key %s: %s", e.Key, err)
+			}
+		}
+	}
+}
+
+func (w *inMemWatcher) push(e *state.WatchEvent) {
+	w.lock.Lock()
+	w.queue = append(w.queue, e)
+	w.lock.Unlock()
+
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+// doNotify sends the event to the matching watchers.
+// It must be called with the write-lock held.
+func (store *inMemoryStore) doNotify(e *state.WatchEvent) {
+	for w := range store.watchers {
+		if strings.HasPrefix(e.Key, w.prefix) {
+			w.push(e)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agrea/ptr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+func TestWatch(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test"))
+	store.Init(state.Metadata{})
+	watcher, ok := store.(state.Watcher)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan *state.WatchEvent, 10)
+	err := watcher.Watch(ctx, &state.WatchRequest{KeyPrefix: "order||"}, func(_ context.Context, e *state.WatchEvent) error {
+		events <- e
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	receive := func(t *testing.T) *state.WatchEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a state change")
+
+			return nil
+		}
+	}
+
+	t.Run("set and delete are delivered in order", func(t *testing.T) {
+		require.NoError(t, store.Set(&state.SetRequest{Key: "order||1", Value: "created", ETag: ptr.String("1")}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "customer||1", Value: "ignored"}))
+		require.NoError(t, store.Delete(&state.DeleteRequest{Key: "order||1"}))
+
+		e := receive(t)
+		assert.Equal(t, "order||1", e.Key)
+		assert.Equal(t, state.Upsert, e.Operation)
+		assert.Equal(t, "created", string(e.Value))
+		assert.Equal(t, ptr.String("1"), e.ETag)
+
+		e = receive(t)
+		assert.Equal(t, "order||1", e.Key)
+		assert.Equal(t, state.Delete, e.Operation)
+		assert.Nil(t, e.Value)
+	})
+
+	t.Run("deleting a missing key is not delivered", func(t *testing.T) {
+		require.NoError(t, store.Delete(&state.DeleteRequest{Key: "order||2"}))
+		select {
+		case e := <-events:
+			t.Fatalf("unexpected event %#v", e)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("nothing is delivered after cancel", func(t *testing.T) {
+		cancel()
+		assert.Eventually(t, func() bool {
+			s := store.(*inMemoryStore)
+			s.lock.RLock()
+			defer s.lock.RUnlock()
+
+			return len(s.watchers) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, store.Set(&state.SetRequest{Key: "order||3", Value: "created"}))
+		select {
+		case e := <-events:
+			t.Fatalf("unexpected event %#v", e)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
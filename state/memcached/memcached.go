@@ -26,6 +26,7 @@ import (
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const (
@@ -40,9 +41,10 @@ const (
 
 type Memcached struct {
 	state.DefaultBulkStore
-	client *memcache.Client
-	json   jsoniter.API
-	logger logger.Logger
+	client      *memcache.Client
+	json        jsoniter.API
+	retryConfig retry.Config
+	logger      logger.Logger
 }
 
 type memcachedMetadata struct {
@@ -67,6 +69,11 @@ func (m *Memcached) Init(metadata state.Metadata) error {
 		return err
 	}
 
+	m.retryConfig, err = state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
 	client := memcache.New(meta.hosts...)
 	client.Timeout = meta.timeout
 	client.MaxIdleConns = meta.maxIdleConnections
@@ -83,7 +90,7 @@ func (m *Memcached) Init(metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (m *Memcached) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag, state.FeatureTTL}
 }
 
 func getMemcachedMetadata(metadata state.Metadata) (*memcachedMetadata, error) {
@@ -139,27 +146,95 @@ func (m *Memcached) setValue(req *state.SetRequest) error {
 	}
 
 	bt, _ = utils.Marshal(req.Value, m.json.Marshal)
+	item := &memcache.Item{Key: req.Key, Value: bt}
 	if ttl != nil {
-		err = m.client.Set(&memcache.Item{Key: req.Key, Value: bt, Expiration: *ttl})
-	} else {
-		err = m.client.Set(&memcache.Item{Key: req.Key, Value: bt})
+		item.Expiration = *ttl
+	}
+
+	switch {
+	case hasETag(req.ETag) && req.Options.Concurrency != state.LastWrite:
+		current, err := m.getWithETag(req.Key, *req.ETag)
+		if err != nil {
+			return err
+		}
+		current.Value = item.Value
+		current.Expiration = item.Expiration
+		err = m.compareAndSwap(current)
+		if err != nil {
+			return err
+		}
+	case req.Options.Concurrency == state.FirstWrite:
+		err = m.client.Add(item)
+		if errors.Is(err, memcache.ErrNotStored) {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+	default:
+		err = m.client.Set(item)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to set key %s: %s", req.Key, err)
+		return fmt.Errorf("failed to set key %s: %w", req.Key, err)
 	}
 
 	return nil
 }
 
-func (m *Memcached) Delete(req *state.DeleteRequest) error {
+func (m *Memcached) deleteValue(req *state.DeleteRequest) error {
+	if hasETag(req.ETag) && req.Options.Concurrency != state.LastWrite {
+		current, err := m.getWithETag(req.Key, *req.ETag)
+		if err != nil {
+			return err
+		}
+		// A negative expiration expires the value as soon as it is swapped,
+		// which deletes it only if it has not changed since it was read.
+		current.Expiration = -1
+
+		return m.compareAndSwap(current)
+	}
+
 	err := m.client.Delete(req.Key)
-	if err != nil {
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
 		return err
 	}
 
 	return nil
 }
 
+// getWithETag reads the value of key and checks that its ETag is etag.
+// The returned item holds the CAS token of the value, so that it can be
+// swapped only if it has not changed since it was read.
+func (m *Memcached) getWithETag(key string, etag string) (*memcache.Item, error) {
+	item, err := m.client.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, state.NewETagError(state.ETagMismatch, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if utils.ValueETag(item.Value) != etag {
+		return nil, state.NewETagError(state.ETagMismatch, nil)
+	}
+
+	return item, nil
+}
+
+func (m *Memcached) compareAndSwap(item *memcache.Item) error {
+	err := m.client.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) || errors.Is(err, memcache.ErrCacheMiss) {
+		return state.NewETagError(state.ETagMismatch, err)
+	}
+
+	return err
+}
+
+func (m *Memcached) Delete(req *state.DeleteRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+
+	return state.DeleteWithRetries(m.deleteValue, req, m.retryConfig)
+}
+
 func (m *Memcached) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	item, err := m.client.Get(req.Key)
 	if err != nil {
@@ -171,11 +246,18 @@ func (m *Memcached) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		return &state.GetResponse{}, err
 	}
 
+	etag := utils.ValueETag(item.Value)
+
 	return &state.GetResponse{
 		Data: item.Value,
+		ETag: &etag,
 	}, nil
 }
 
 func (m *Memcached) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(m.setValue, req)
+	return state.SetWithRetries(m.setValue, req, m.retryConfig)
+}
+
+func hasETag(etag *string) bool {
+	return etag != nil && *etag != ""
 }
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -104,7 +105,7 @@ type Item struct {
 // NewMongoDB returns a new MongoDB state store.
 func NewMongoDB(logger logger.Logger) *MongoDB {
 	s := &MongoDB{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureListKeys},
 		logger:   logger,
 	}
 	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
@@ -345,6 +346,17 @@ func (m *MongoDB) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
+	if q.aggregate != nil {
+		aggregations, err := q.executeAggregate(ctx, m.collection)
+		if err != nil {
+			return &state.QueryResponse{}, err
+		}
+
+		return &state.QueryResponse{
+			Results:      []state.QueryItem{},
+			Aggregations: aggregations,
+		}, nil
+	}
 	data, token, err := q.execute(ctx, m.collection)
 	if err != nil {
 		return &state.QueryResponse{}, err
@@ -356,6 +368,46 @@ func (m *MongoDB) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	}, nil
 }
 
+// ListKeys returns the keys starting with req.Prefix in lexical order.
+// The token is the last key of the previous page.
+func (m *MongoDB) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.operationTimeout)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{id: 1}).SetSort(bson.M{id: 1})
+	if req.Limit > 0 {
+		opts.SetLimit(int64(req.Limit + 1))
+	}
+	cur, err := m.collection.Find(ctx, listKeysFilter(req), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	keys := []string{}
+	for cur.Next(ctx) {
+		var item Item
+		if err = cur.Decode(&item); err != nil {
+			return nil, err
+		}
+		keys = append(keys, item.Key)
+	}
+	if err = cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return state.NewListKeysResponse(keys, req.Limit), nil
+}
+
+func listKeysFilter(req *state.ListKeysRequest) bson.M {
+	cond := bson.M{"$regex": "^" + regexp.QuoteMeta(req.Prefix)}
+	if req.Token != "" {
+		cond["$gt"] = req.Token
+	}
+
+	return bson.M{id: cond}
+}
+
 func getMongoURI(metadata *mongoDBMetadata) string {
 	if len(metadata.server) != 0 {
 		if metadata.username != "" && metadata.password != "" {
@@ -33,6 +33,9 @@ type Query struct {
 	query  string
 	filter interface{}
 	opts   *options.FindOptions
+
+	aggregate *query.Aggregate
+	pipeline  mongo.Pipeline
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -45,27 +48,74 @@ func (q *Query) VisitEQ(f *query.EQ) (string, error) {
 	}
 }
 
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	// { <key>: { $ne: <val> } }
+	return visitComparison("$ne", f.Key, f.Val), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	// { <key>: { $gt: <val> } }
+	return visitComparison("$gt", f.Key, f.Val), nil
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	// { <key>: { $gte: <val> } }
+	return visitComparison("$gte", f.Key, f.Val), nil
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	// { <key>: { $lt: <val> } }
+	return visitComparison("$lt", f.Key, f.Val), nil
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	// { <key>: { $lte: <val> } }
+	return visitComparison("$lte", f.Key, f.Val), nil
+}
+
 func (q *Query) VisitIN(f *query.IN) (string, error) {
 	// { $in: [ <val1>, <val2>, ... , <valN> ] }
 	if len(f.Vals) == 0 {
 		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
 	}
-	str := fmt.Sprintf(`{ "value.%s": { "$in": [ `, f.Key)
 
-	for i := 0; i < len(f.Vals); i++ {
+	return visitList("$in", f.Key, f.Vals), nil
+}
+
+func (q *Query) VisitNIN(f *query.NIN) (string, error) {
+	// { $nin: [ <val1>, <val2>, ... , <valN> ] }
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty NIN operator for key %q", f.Key)
+	}
+
+	return visitList("$nin", f.Key, f.Vals), nil
+}
+
+func visitComparison(op string, key string, val interface{}) string {
+	return fmt.Sprintf(`{ "value.%s": { "%s": %s } }`, key, op, formatValue(val))
+}
+
+func visitList(op string, key string, vals []interface{}) string {
+	str := fmt.Sprintf(`{ "value.%s": { "%s": [ `, key, op)
+
+	for i := 0; i < len(vals); i++ {
 		if i > 0 {
 			str += ", "
 		}
-		switch v := f.Vals[i].(type) {
-		case string:
-			str += fmt.Sprintf("%q", v)
-		default:
-			str += fmt.Sprintf("%v", v)
-		}
+		str += formatValue(vals[i])
 	}
 	str += " ] } }"
 
-	return str, nil
+	return str
+}
+
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
 }
 
 func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
@@ -81,11 +131,41 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NEQ:
+			if str, err = q.VisitNEQ(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GT:
+			if str, err = q.VisitGT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GTE:
+			if str, err = q.VisitGTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LT:
+			if str, err = q.VisitLT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LTE:
+			if str, err = q.VisitLTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		case *query.IN:
 			if str, err = q.VisitIN(f); err != nil {
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NIN:
+			if str, err = q.VisitNIN(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		case *query.OR:
 			if str, err = q.VisitOR(f); err != nil {
 				return "", err
@@ -123,6 +203,20 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	}
 	q.opts = options.Find()
 
+	if qq.Aggregate != nil {
+		q.finalizeAggregate(qq.Aggregate)
+
+		return nil
+	}
+
+	// projection; the key is the _id field, which is always returned
+	if len(qq.Fields) > 0 {
+		projection := bson.D{{Key: "_etag", Value: 1}}
+		for _, field := range qq.Fields {
+			projection = append(projection, bson.E{Key: "value." + field, Value: 1})
+		}
+		q.opts.SetProjection(projection)
+	}
 	// sorting
 	if len(qq.Sort) > 0 {
 		sort := bson.D{}
@@ -150,6 +244,106 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// finalizeAggregate builds the pipeline which groups the documents matching the filter.
+// The group by keys and the aggregated values are named by their position, since
+// the aliases may not be valid field names.
+func (q *Query) finalizeAggregate(aggregate *query.Aggregate) {
+	var id interface{}
+	if len(aggregate.GroupBy) > 0 {
+		groups := bson.D{}
+		for i, key := range aggregate.GroupBy {
+			groups = append(groups, bson.E{Key: fmt.Sprintf("g%d", i), Value: "$value." + key})
+		}
+		id = groups
+	}
+
+	group := bson.D{{Key: "_id", Value: id}}
+	for i, agg := range aggregate.Aggregations {
+		var value interface{} = "$value." + agg.Key
+		op := "$" + agg.Op
+		if agg.Op == query.COUNT {
+			op, value = "$sum", 1
+		}
+		group = append(group, bson.E{Key: fmt.Sprintf("a%d", i), Value: bson.D{{Key: op, Value: value}}})
+	}
+
+	q.aggregate = aggregate
+	q.pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: q.filter}},
+		{{Key: "$group", Value: group}},
+	}
+}
+
+func (q *Query) executeAggregate(ctx context.Context, collection *mongo.Collection) ([]state.AggregationResult, error) {
+	cur, err := collection.Aggregate(ctx, q.pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	ret := []state.AggregationResult{}
+	for cur.Next(ctx) {
+		var doc bson.M
+		if err = cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		result, err := q.aggregationResult(doc)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result)
+	}
+	if err = cur.Err(); err != nil {
+		return nil, err
+	}
+
+	// Without group by, the aggregations of no documents are returned like the other stores do,
+	// while the pipeline returns no group at all.
+	if len(ret) == 0 && len(q.aggregate.GroupBy) == 0 {
+		result, err := q.aggregationResult(bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result)
+	}
+
+	return ret, nil
+}
+
+// aggregationResult converts a document returned by the pipeline to an aggregation result.
+func (q *Query) aggregationResult(doc bson.M) (state.AggregationResult, error) {
+	// The document is converted to relaxed JSON, so that the values are plain JSON values.
+	data, err := bson.MarshalExtJSON(doc, false, true)
+	if err != nil {
+		return state.AggregationResult{}, err
+	}
+	var values map[string]interface{}
+	if err = json.Unmarshal(data, &values); err != nil {
+		return state.AggregationResult{}, err
+	}
+
+	result := state.AggregationResult{
+		Values: make(map[string]interface{}, len(q.aggregate.Aggregations)),
+	}
+	if len(q.aggregate.GroupBy) > 0 {
+		result.Group = make(map[string]interface{}, len(q.aggregate.GroupBy))
+		groups, _ := values["_id"].(map[string]interface{})
+		for i, key := range q.aggregate.GroupBy {
+			result.Group[key] = groups[fmt.Sprintf("g%d", i)]
+		}
+	}
+	for i, agg := range q.aggregate.Aggregations {
+		value := values[fmt.Sprintf("a%d", i)]
+		if agg.Op == query.COUNT {
+			count, _ := value.(float64)
+			value = int64(count)
+		}
+		result.Values[agg.Alias] = value
+	}
+
+	return result, nil
+}
+
 func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]state.QueryItem, string, error) {
 	cur, err := collection.Find(ctx, q.filter, []*options.FindOptions{q.opts}...)
 	if err != nil {
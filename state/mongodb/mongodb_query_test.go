@@ -14,12 +14,16 @@ limitations under the License.
 package mongodb
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 
+	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
 )
 
@@ -48,6 +52,14 @@ func TestMongoQuery(t *testing.T) {
 			input: "../../tests/state/query/q6.json",
 			query: `{ "$or": [ { "value.person.id": 123 }, { "$and": [ { "value.person.org": "B" }, { "value.person.id": { "$in": [ 567, 890 ] } } ] } ] }`,
 		},
+		{
+			input: "../../tests/state/query/q7.json",
+			query: `{ "$and": [ { "value.person.id": { "$gte": 100 } }, { "value.person.id": { "$lt": 200 } }, { "value.state": { "$ne": "CA" } }, { "$or": [ { "value.person.id": { "$gt": 150 } }, { "value.person.id": { "$lte": 110 } }, { "value.state": { "$nin": [ "WA", "OR" ] } } ] } ] }`,
+		},
+		{
+			input: "../../tests/state/query/q8.json",
+			query: `{ "$and": [ { "value.created": { "$gte": "2021-01-01" } }, { "value.created": { "$lt": "2022-01-01" } } ] }`,
+		},
 	}
 	for _, test := range tests {
 		data, err := ioutil.ReadFile(test.input)
@@ -63,3 +75,83 @@ func TestMongoQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestMongoQueryAggregate(t *testing.T) {
+	data, err := ioutil.ReadFile("../../tests/state/query/q9.json")
+	assert.NoError(t, err)
+	var qq query.Query
+	err = json.Unmarshal(data, &qq)
+	assert.NoError(t, err)
+
+	q := &Query{}
+	qbuilder := query.NewQueryBuilder(q)
+	err = qbuilder.BuildQuery(&qq)
+	assert.NoError(t, err)
+
+	pipeline, err := bson.MarshalExtJSON(bson.D{{Key: "pipeline", Value: q.pipeline}}, false, false)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pipeline": [
+		{"$match": {"value.person.org": "A"}},
+		{"$group": {
+			"_id": {"g0": "$value.state"},
+			"a0": {"$sum": 1},
+			"a1": {"$avg": "$value.person.age"},
+			"a2": {"$max": "$value.person.age"}
+		}}
+	]}`, string(pipeline))
+
+	result, err := q.aggregationResult(bson.M{
+		"_id": bson.M{"g0": "CA"},
+		"a0":  int32(3),
+		"a1":  float64(41.5),
+		"a2":  int32(60),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, state.AggregationResult{
+		Group:  map[string]interface{}{"state": "CA"},
+		Values: map[string]interface{}{"count": int64(3), "averageAge": 41.5, "max(person.age)": float64(60)},
+	}, result)
+}
+
+func TestMongoQueryAggregateWithoutGroupBy(t *testing.T) {
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"filter":{"EQ":{"state":"NV"}},"aggregate":{"aggregations":[{"op":"count"},{"op":"sum","key":"amount"}]}}`), &qq)
+	assert.NoError(t, err)
+
+	q := &Query{}
+	qbuilder := query.NewQueryBuilder(q)
+	err = qbuilder.BuildQuery(&qq)
+	assert.NoError(t, err)
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("no matching documents", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "dapr.state", mtest.FirstBatch))
+
+		result, err := q.executeAggregate(context.Background(), mt.Coll)
+		assert.NoError(t, err)
+		assert.Equal(t, []state.AggregationResult{
+			{Values: map[string]interface{}{"count": int64(0), "sum(amount)": nil}},
+		}, result)
+	})
+}
+
+func TestMongoQueryFields(t *testing.T) {
+	data, err := ioutil.ReadFile("../../tests/state/query/q10.json")
+	assert.NoError(t, err)
+	var qq query.Query
+	err = json.Unmarshal(data, &qq)
+	assert.NoError(t, err)
+
+	q := &Query{}
+	qbuilder := query.NewQueryBuilder(q)
+	err = qbuilder.BuildQuery(&qq)
+	assert.NoError(t, err)
+	assert.Equal(t, `{ "value.state": "CA" }`, q.query)
+	assert.Equal(t, bson.D{
+		{Key: "_etag", Value: 1},
+		{Key: "value.state", Value: 1},
+		{Key: "value.person.org", Value: 1},
+		{Key: "value.person.name", Value: 1},
+	}, q.opts.Projection)
+}
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/dapr/components-contrib/state"
 )
@@ -187,3 +188,15 @@ func TestGetMongoDBMetadata(t *testing.T) {
 		assert.Equal(t, expected, err.Error())
 	})
 }
+
+func TestListKeysFilter(t *testing.T) {
+	t.Run("prefix only", func(t *testing.T) {
+		filter := listKeysFilter(&state.ListKeysRequest{Prefix: "app||"})
+		assert.Equal(t, bson.M{"_id": bson.M{"$regex": `^app\|\|`}}, filter)
+	})
+
+	t.Run("prefix and token", func(t *testing.T) {
+		filter := listKeysFilter(&state.ListKeysRequest{Prefix: "app.", Token: "app.b"})
+		assert.Equal(t, bson.M{"_id": bson.M{"$regex": `^app\.`, "$gt": "app.b"}}, filter)
+	})
+}
@@ -14,12 +14,14 @@ limitations under the License.
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/agrea/ptr"
 	"github.com/google/uuid"
@@ -27,6 +29,7 @@ import (
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 // Optimistic Concurrency is implemented using a string column that stores
@@ -61,8 +64,28 @@ const (
 	// The connection string should be in the following format
 	// "%s:%s@tcp(%s:3306)/%s?allowNativePasswords=true&tls=custom",'myadmin@mydemoserver', 'yourpassword', 'mydemoserver.mysql.database.azure.com', 'targetdb'.
 	pemPathKey = "pemPath"
+
+	// The key in the metadata for the interval at which the rows whose ttl has
+	// elapsed are deleted, as a Go duration. A value of zero or less disables it.
+	cleanupIntervalKey = "cleanupInterval"
+
+	// Used if the user does not configure a cleanup interval in the metadata.
+	defaultCleanupInterval = time.Hour
+
+	// The condition which excludes the rows whose ttl has elapsed.
+	notExpired = "(expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)"
+
+	// The expiration date of a value saved with the ttl passed as parameter.
+	// It is NULL if the parameter is NULL.
+	expireDateExpr = "DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND)"
 )
 
+// querier executes the statements of the state operations, either directly
+// on the database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // MySQL state store.
 type MySQL struct {
 	// Name of the table to store state. If the table does not exist it will
@@ -75,6 +98,15 @@ type MySQL struct {
 
 	connectionString string
 
+	// Interval at which the rows whose ttl has elapsed are deleted.
+	cleanupInterval time.Duration
+
+	// Retries of the set and delete operations.
+	retryConfig retry.Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	// Instance of the database to issue commands to
 	db *sql.DB
 
@@ -100,7 +132,7 @@ func newMySQLStateStore(logger logger.Logger, factory iMySQLFactory) *MySQL {
 	// Store the provided logger and return the object. The rest of the
 	// properties will be populated in the Init function
 	return &MySQL{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureListKeys, state.FeatureTTL},
 		logger:   logger,
 		factory:  factory,
 	}
@@ -141,6 +173,27 @@ func (m *MySQL) Init(metadata state.Metadata) error {
 		return fmt.Errorf(errMissingConnectionString)
 	}
 
+	m.cleanupInterval = defaultCleanupInterval
+	val, ok = metadata.Properties[cleanupIntervalKey]
+
+	if ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			m.logger.Error(err)
+
+			return fmt.Errorf("invalid %s %q: %w", cleanupIntervalKey, val, err)
+		}
+		m.cleanupInterval = d
+	}
+
+	retryConfig, err := state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		m.logger.Error(err)
+
+		return err
+	}
+	m.retryConfig = retryConfig
+
 	val, ok = metadata.Properties[pemPathKey]
 
 	if ok && val != "" {
@@ -189,8 +242,17 @@ func (m *MySQL) finishInit(db *sql.DB, err error) error {
 		return pingErr
 	}
 
-	// will be nil if everything is good or an err that needs to be returned
-	return m.ensureStateTable(m.tableName)
+	err = m.ensureStateTable(m.tableName)
+	if err != nil {
+		return err
+	}
+
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	if m.cleanupInterval > 0 {
+		go m.startCleanupThread()
+	}
+
+	return nil
 }
 
 func (m *MySQL) ensureStateSchema() error {
@@ -251,17 +313,26 @@ func (m *MySQL) ensureStateTable(stateTableName string) error {
 			isbinary BOOLEAN NOT NULL,
 			insertDate TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updateDate TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			eTag VARCHAR(36) NOT NULL
+			eTag VARCHAR(36) NOT NULL,
+			expiredate TIMESTAMP NULL DEFAULT NULL
 			);`, stateTableName)
 
 		_, err = m.db.Exec(createTable)
 
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
-	return nil
+	// Tables created by previous versions do not have the expiredate column.
+	exists, err = columnExists(m.db, stateTableName, "expiredate")
+	if err != nil || exists {
+		return err
+	}
+
+	m.logger.Infof("Adding expiredate column to MySql state table '%s'", stateTableName)
+	_, err = m.db.Exec(fmt.Sprintf(
+		`ALTER TABLE %s ADD COLUMN expiredate TIMESTAMP NULL DEFAULT NULL`, stateTableName))
+
+	return err
 }
 
 func schemaExists(db *sql.DB, schemaName string) (bool, error) {
@@ -290,15 +361,70 @@ func tableExists(db *sql.DB, tableName string) (bool, error) {
 	return exists == "1", err
 }
 
+func columnExists(db *sql.DB, tableName string, columnName string) (bool, error) {
+	exists := ""
+
+	query := `SELECT EXISTS (
+		SELECT COLUMN_NAME FROM information_schema.columns
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+		) AS 'exists'`
+
+	// Returns 1 or 0 as a string if the column exists or not
+	err := db.QueryRow(query, tableName, columnName).Scan(&exists)
+
+	return exists == "1", err
+}
+
+// startCleanupThread periodically deletes the rows whose ttl has elapsed
+// until the store is closed.
+func (m *MySQL) startCleanupThread() {
+	ticker := time.NewTicker(m.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.deleteExpiredValues(m.ctx); err != nil {
+				m.logger.Errorf("error deleting expired values from MySql: %s", err)
+			}
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *MySQL) deleteExpiredValues(ctx context.Context) error {
+	res, err := m.db.ExecContext(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE expiredate IS NOT NULL AND expiredate <= CURRENT_TIMESTAMP`,
+		m.tableName))
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		m.logger.Debugf("Deleted %d expired values from MySql", n)
+	}
+
+	return nil
+}
+
 // Delete removes an entity from the store
 // Store Interface.
 func (m *MySQL) Delete(req *state.DeleteRequest) error {
-	return state.DeleteWithOptions(m.deleteValue, req)
+	return m.DeleteWithContext(context.Background(), req)
+}
+
+// DeleteWithContext removes an entity from the store, honoring the
+// cancellation of ctx.
+func (m *MySQL) DeleteWithContext(ctx context.Context, req *state.DeleteRequest) error {
+	return state.DeleteWithRetries(func(req *state.DeleteRequest) error {
+		return m.deleteValue(ctx, m.db, req)
+	}, req, m.retryConfig)
 }
 
 // deleteValue is an internal implementation of delete to enable passing the
 // logic to state.DeleteWithRetries as a func.
-func (m *MySQL) deleteValue(req *state.DeleteRequest) error {
+func (m *MySQL) deleteValue(ctx context.Context, db querier, req *state.DeleteRequest) error {
 	m.logger.Debug("Deleting state value from MySql")
 
 	if req.Key == "" {
@@ -309,11 +435,11 @@ func (m *MySQL) deleteValue(req *state.DeleteRequest) error {
 	var result sql.Result
 
 	if req.ETag == nil || *req.ETag == "" {
-		result, err = m.db.Exec(fmt.Sprintf(
+		result, err = db.ExecContext(ctx, fmt.Sprintf(
 			`DELETE FROM %s WHERE id = ?`,
 			m.tableName), req.Key)
 	} else {
-		result, err = m.db.Exec(fmt.Sprintf(
+		result, err = db.ExecContext(ctx, fmt.Sprintf(
 			`DELETE FROM %s WHERE id = ? and eTag = ?`,
 			m.tableName), req.Key, *req.ETag)
 	}
@@ -328,7 +454,9 @@ func (m *MySQL) deleteValue(req *state.DeleteRequest) error {
 	}
 
 	if rows != 1 && req.ETag != nil && *req.ETag != "" {
-		return state.NewETagError(state.ETagMismatch, nil)
+		err = fmt.Errorf(`rows affected error: no rows match given key '%s' and eTag '%s'`, req.Key, *req.ETag)
+
+		return state.NewETagError(state.ETagMismatch, err)
 	}
 
 	return nil
@@ -337,9 +465,15 @@ func (m *MySQL) deleteValue(req *state.DeleteRequest) error {
 // BulkDelete removes multiple entries from the store
 // Store Interface.
 func (m *MySQL) BulkDelete(req []state.DeleteRequest) error {
+	return m.BulkDeleteWithContext(context.Background(), req)
+}
+
+// BulkDeleteWithContext removes multiple entries from the store, honoring the
+// cancellation of ctx.
+func (m *MySQL) BulkDeleteWithContext(ctx context.Context, req []state.DeleteRequest) error {
 	m.logger.Debug("Executing BulkDelete request")
 
-	tx, err := m.db.Begin()
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -347,7 +481,7 @@ func (m *MySQL) BulkDelete(req []state.DeleteRequest) error {
 	if len(req) > 0 {
 		for _, d := range req {
 			da := d // Fix for goSec G601: Implicit memory aliasing in for loop.
-			err = m.Delete(&da)
+			err = m.DeleteWithContext(ctx, &da)
 			if err != nil {
 				tx.Rollback()
 
@@ -364,6 +498,12 @@ func (m *MySQL) BulkDelete(req []state.DeleteRequest) error {
 // Get returns an entity from store
 // Store Interface.
 func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	return m.GetWithContext(context.Background(), req)
+}
+
+// GetWithContext returns an entity from store, honoring the cancellation of
+// ctx.
+func (m *MySQL) GetWithContext(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
 	m.logger.Debug("Getting state value from MySql")
 
 	if req.Key == "" {
@@ -373,9 +513,9 @@ func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	var eTag, value string
 	var isBinary bool
 
-	err := m.db.QueryRow(fmt.Sprintf(
-		`SELECT value, eTag, isbinary FROM %s WHERE id = ?`,
-		m.tableName), req.Key).Scan(&value, &eTag, &isBinary)
+	err := m.db.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT value, eTag, isbinary FROM %s WHERE id = ? AND %s`,
+		m.tableName, notExpired), req.Key).Scan(&value, &eTag, &isBinary)
 	if err != nil {
 		// If no rows exist, return an empty response, otherwise return an error.
 		if errors.Is(err, sql.ErrNoRows) {
@@ -385,27 +525,13 @@ func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		return nil, err
 	}
 
-	if isBinary {
-		var s string
-		var data []byte
-
-		if err = json.Unmarshal([]byte(value), &s); err != nil {
-			return nil, err
-		}
-
-		if data, err = base64.StdEncoding.DecodeString(s); err != nil {
-			return nil, err
-		}
-
-		return &state.GetResponse{
-			Data:     data,
-			ETag:     ptr.String(eTag),
-			Metadata: req.Metadata,
-		}, nil
+	data, err := utils.DecodeValue(value, isBinary)
+	if err != nil {
+		return nil, err
 	}
 
 	return &state.GetResponse{
-		Data:     []byte(value),
+		Data:     data,
 		ETag:     ptr.String(eTag),
 		Metadata: req.Metadata,
 	}, nil
@@ -414,12 +540,20 @@ func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
 // Set adds/updates an entity on store
 // Store Interface.
 func (m *MySQL) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(m.setValue, req)
+	return m.SetWithContext(context.Background(), req)
+}
+
+// SetWithContext adds/updates an entity on store, honoring the cancellation
+// of ctx.
+func (m *MySQL) SetWithContext(ctx context.Context, req *state.SetRequest) error {
+	return state.SetWithRetries(func(req *state.SetRequest) error {
+		return m.setValue(ctx, m.db, req)
+	}, req, m.retryConfig)
 }
 
 // setValue is an internal implementation of set to enable passing the logic
 // to state.SetWithRetries as a func.
-func (m *MySQL) setValue(req *state.SetRequest) error {
+func (m *MySQL) setValue(ctx context.Context, db querier, req *state.SetRequest) error {
 	m.logger.Debug("Setting state value in MySql")
 
 	err := state.CheckRequestOptions(req.Options)
@@ -435,6 +569,11 @@ func (m *MySQL) setValue(req *state.SetRequest) error {
 		return fmt.Errorf("empty string is not allowed in set operation")
 	}
 
+	ttl, err := utils.ParseTTL(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("error parsing TTL: %w", err)
+	}
+
 	v := req.Value
 	byteArray, isBinary := req.Value.([]uint8)
 	if isBinary {
@@ -451,23 +590,24 @@ func (m *MySQL) setValue(req *state.SetRequest) error {
 	// Sprintf is required for table name because sql.DB does not substitute
 	// parameters for table names.
 	// Other parameters use sql.DB parameter substitution.
+	// The expiration date is NULL when ttl is nil, i.e. the value never expires.
 	if req.ETag == nil || *req.ETag == "" {
 		// If this is a duplicate MySQL returns that two rows affected
-		result, err = m.db.Exec(fmt.Sprintf(
-			`INSERT INTO %s (value, id, eTag, isbinary)
-			 VALUES (?, ?, ?, ?) on duplicate key update value=?, eTag=?, isbinary=?;`,
-			m.tableName), value, req.Key, eTag, isBinary, value, eTag, isBinary)
+		result, err = db.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %[1]s (value, id, eTag, isbinary, expiredate)
+			 VALUES (?, ?, ?, ?, %[2]s) on duplicate key update value=?, eTag=?, isbinary=?, expiredate=%[2]s;`,
+			m.tableName, expireDateExpr), value, req.Key, eTag, isBinary, ttl, value, eTag, isBinary, ttl)
 	} else {
 		// When an eTag is provided do an update - not insert
-		result, err = m.db.Exec(fmt.Sprintf(
-			`UPDATE %s SET value = ?, eTag = ?, isbinary = ?
-			 WHERE id = ? AND eTag = ?;`,
-			m.tableName), value, eTag, isBinary, req.Key, *req.ETag)
+		result, err = db.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET value = ?, eTag = ?, isbinary = ?, expiredate = %s
+			 WHERE id = ? AND eTag = ? AND %s;`,
+			m.tableName, expireDateExpr, notExpired), value, eTag, isBinary, ttl, req.Key, *req.ETag)
 	}
 
 	if err != nil {
 		if req.ETag != nil && *req.ETag != "" {
-			return state.NewETagError(state.ETagMismatch, err)
+			return state.NewETagError(state.ETagMismatch, fmt.Errorf("error updating key '%s': %w", req.Key, err))
 		}
 
 		return err
@@ -499,9 +639,15 @@ func (m *MySQL) setValue(req *state.SetRequest) error {
 // BulkSet adds/updates multiple entities on store
 // Store Interface.
 func (m *MySQL) BulkSet(req []state.SetRequest) error {
+	return m.BulkSetWithContext(context.Background(), req)
+}
+
+// BulkSetWithContext adds/updates multiple entities on store, honoring the
+// cancellation of ctx.
+func (m *MySQL) BulkSetWithContext(ctx context.Context, req []state.SetRequest) error {
 	m.logger.Debug("Executing BulkSet request")
 
-	tx, err := m.db.Begin()
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -509,7 +655,7 @@ func (m *MySQL) BulkSet(req []state.SetRequest) error {
 	if len(req) > 0 {
 		for _, s := range req {
 			sa := s // Fix for goSec G601: Implicit memory aliasing in for loop.
-			err = m.Set(&sa)
+			err = m.SetWithContext(ctx, &sa)
 			if err != nil {
 				tx.Rollback()
 
@@ -526,9 +672,17 @@ func (m *MySQL) BulkSet(req []state.SetRequest) error {
 // Multi handles multiple transactions.
 // TransactionalStore Interface.
 func (m *MySQL) Multi(request *state.TransactionalStateRequest) error {
+	return m.MultiWithContext(context.Background(), request)
+}
+
+// MultiWithContext handles multiple transactions, honoring the cancellation
+// of ctx. The operations run in a single database transaction, which is
+// rolled back if any of them fails. If the ETag of an operation does not
+// match, the returned ETagError names the key of the operation.
+func (m *MySQL) MultiWithContext(ctx context.Context, request *state.TransactionalStateRequest) error {
 	m.logger.Debug("Executing Multi request")
 
-	tx, err := m.db.Begin()
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -542,7 +696,7 @@ func (m *MySQL) Multi(request *state.TransactionalStateRequest) error {
 				return err
 			}
 
-			err = m.Set(&setReq)
+			err = m.setValue(ctx, tx, &setReq)
 			if err != nil {
 				tx.Rollback()
 				return err
@@ -555,13 +709,14 @@ func (m *MySQL) Multi(request *state.TransactionalStateRequest) error {
 				return err
 			}
 
-			err = m.Delete(&delReq)
+			err = m.deleteValue(ctx, tx, &delReq)
 			if err != nil {
 				tx.Rollback()
 				return err
 			}
 
 		default:
+			tx.Rollback()
 			return fmt.Errorf("unsupported operation: %s", req.Operation)
 		}
 	}
@@ -599,13 +754,110 @@ func (m *MySQL) getDeletes(req state.TransactionalStateOperation) (state.DeleteR
 
 // BulkGet performs a bulks get operations.
 func (m *MySQL) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
-	// by default, the store doesn't support bulk get
-	// return false so daprd will fallback to call get() method one by one
-	return false, nil, nil
+	return m.BulkGetWithContext(context.Background(), req)
+}
+
+// BulkGetWithContext returns the data for multiple keys using a single query,
+// honoring the cancellation of ctx.
+// Keys that do not exist are returned with empty data, and keys whose value
+// cannot be decoded are returned with an error set on their response.
+func (m *MySQL) BulkGetWithContext(ctx context.Context, req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	m.logger.Debug("Getting bulk state values from MySql")
+
+	if len(req) == 0 {
+		return true, []state.BulkGetResponse{}, nil
+	}
+
+	params := make([]interface{}, len(req))
+	placeholders := make([]string, len(req))
+	for i, r := range req {
+		if r.Key == "" {
+			return false, nil, fmt.Errorf("missing key in bulk get operation")
+		}
+		params[i] = r.Key
+		placeholders[i] = "?"
+	}
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT id, value, eTag, isbinary FROM %s WHERE id IN (%s) AND %s`,
+		m.tableName, strings.Join(placeholders, ", "), notExpired), params...)
+	if err != nil {
+		return false, nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]state.BulkGetResponse, len(req))
+	for rows.Next() {
+		var key, value, eTag string
+		var isBinary bool
+		if err = rows.Scan(&key, &value, &eTag, &isBinary); err != nil {
+			return false, nil, err
+		}
+
+		item := state.BulkGetResponse{
+			Key:  key,
+			ETag: ptr.String(eTag),
+		}
+		if item.Data, err = utils.DecodeValue(value, isBinary); err != nil {
+			item.ETag = nil
+			item.Error = err.Error()
+		}
+		found[key] = item
+	}
+
+	if err = rows.Err(); err != nil {
+		return false, nil, err
+	}
+
+	res := make([]state.BulkGetResponse, len(req))
+	for i, r := range req {
+		item, ok := found[r.Key]
+		if !ok {
+			item = state.BulkGetResponse{Key: r.Key}
+		}
+		item.Metadata = r.Metadata
+		res[i] = item
+	}
+
+	return true, res, nil
+}
+
+// ListKeys returns the keys starting with req.Prefix, ordered by the collation of the id column.
+// The token is the last key of the previous page.
+func (m *MySQL) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	m.logger.Debug("Listing state keys from MySql")
+
+	query := fmt.Sprintf(`SELECT id FROM %s WHERE id LIKE ? AND id > ? AND %s ORDER BY id`, m.tableName, notExpired)
+	if req.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+	rows, err := m.db.Query(query, utils.LikePrefixPattern(req.Prefix), req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return state.NewListKeysResponse(keys, req.Limit), nil
 }
 
 // Close implements io.Closer.
 func (m *MySQL) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+
 	if m.db != nil {
 		return m.db.Close()
 	}
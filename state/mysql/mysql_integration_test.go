@@ -13,6 +13,7 @@ limitations under the License.
 package mysql
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
@@ -22,6 +23,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
@@ -157,6 +159,39 @@ func TestMySQLIntegration(t *testing.T) {
 		t.Parallel()
 		multiWithSetOnly(t, mys)
 	})
+
+	t.Run("Set with ttl expires the item", func(t *testing.T) {
+		t.Parallel()
+		setWithTTLExpiresTheItem(t, mys)
+	})
+}
+
+// setWithTTLExpiresTheItem validates that an item is no longer returned once
+// its ttl has elapsed, and that it is deleted by the cleanup.
+func setWithTTLExpiresTheItem(t *testing.T, mys *MySQL) {
+	key := randomKey()
+	setReq := &state.SetRequest{
+		Key:      key,
+		Value:    &fakeItem{Color: "orange"},
+		Metadata: map[string]string{"ttlInSeconds": "1"},
+	}
+	err := mys.Set(setReq)
+	assert.Nil(t, err)
+
+	response, _ := getItem(t, mys, key)
+	assert.NotNil(t, response.Data)
+
+	time.Sleep(2 * time.Second)
+	response, _ = getItem(t, mys, key)
+	assert.Nil(t, response.Data)
+
+	err = mys.deleteExpiredValues(context.Background())
+	assert.Nil(t, err)
+
+	var count int
+	err = mys.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = ?", mys.tableName), key).Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
 }
 
 func multiWithSetOnly(t *testing.T, mys *MySQL) {
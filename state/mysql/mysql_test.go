@@ -13,6 +13,7 @@ limitations under the License.
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
@@ -248,6 +249,38 @@ func TestExecuteMultiCommitSetsAndDeletes(t *testing.T) {
 	assert.Nil(t, err, "error returned")
 }
 
+func TestExecuteMultiRollbackOnETagMismatch(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	m.mock1.ExpectBegin()
+	m.mock1.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock1.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock1.ExpectRollback()
+
+	eTag := "946af563"
+	deleteRequest := createDeleteRequest()
+	deleteRequest.ETag = &eTag
+
+	request := state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			{Request: createSetRequest(), Operation: state.Upsert},
+			{Request: deleteRequest, Operation: state.Delete},
+		},
+	}
+
+	// Act
+	err := m.mySQL.Multi(&request)
+
+	// Assert
+	var etagErr *state.ETagError
+	assert.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	assert.Contains(t, err.Error(), deleteRequest.Key)
+	assert.NoError(t, m.mock1.ExpectationsWereMet())
+}
+
 func TestSetHandlesOptionsError(t *testing.T) {
 	// Arrange
 	m, _ := mockDatabase(t)
@@ -258,7 +291,7 @@ func TestSetHandlesOptionsError(t *testing.T) {
 	request.Options.Consistency = "Invalid"
 
 	// Act
-	err := m.mySQL.setValue(&request)
+	err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 	// Assert
 	assert.NotNil(t, err)
@@ -293,7 +326,7 @@ func TestSetHandlesUpdate(t *testing.T) {
 	request.ETag = &eTag
 
 	// Act
-	err := m.mySQL.setValue(&request)
+	err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 	// Assert
 	assert.Nil(t, err)
@@ -312,7 +345,7 @@ func TestSetHandlesErr(t *testing.T) {
 		request.ETag = &eTag
 
 		// Act
-		err := m.mySQL.setValue(&request)
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -325,7 +358,7 @@ func TestSetHandlesErr(t *testing.T) {
 		request := createSetRequest()
 
 		// Act
-		err := m.mySQL.setValue(&request)
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -337,7 +370,7 @@ func TestSetHandlesErr(t *testing.T) {
 		request := createSetRequest()
 
 		// Act
-		err := m.mySQL.setValue(&request)
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.Nil(t, err)
@@ -348,7 +381,7 @@ func TestSetHandlesErr(t *testing.T) {
 		request := createSetRequest()
 
 		// Act
-		err := m.mySQL.setValue(&request)
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -362,7 +395,7 @@ func TestSetHandlesErr(t *testing.T) {
 		request.ETag = &eTag
 
 		// Act
-		err := m.mySQL.setValue(&request)
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -398,7 +431,7 @@ func TestDeleteWithETag(t *testing.T) {
 	request.ETag = &eTag
 
 	// Act
-	err := m.mySQL.deleteValue(&request)
+	err := m.mySQL.deleteValue(context.Background(), m.mySQL.db, &request)
 
 	// Assert
 	assert.Nil(t, err)
@@ -415,7 +448,7 @@ func TestDeleteWithErr(t *testing.T) {
 		request := createDeleteRequest()
 
 		// Act
-		err := m.mySQL.deleteValue(&request)
+		err := m.mySQL.deleteValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -430,7 +463,7 @@ func TestDeleteWithErr(t *testing.T) {
 		request.ETag = &eTag
 
 		// Act
-		err := m.mySQL.deleteValue(&request)
+		err := m.mySQL.deleteValue(context.Background(), m.mySQL.db, &request)
 
 		// Assert
 		assert.NotNil(t, err)
@@ -536,6 +569,79 @@ func TestGetSucceeds(t *testing.T) {
 	})
 }
 
+func TestBulkGetSucceeds(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	value, _ := utils.Marshal(base64.StdEncoding.EncodeToString([]byte("abcdefg")), json.Marshal)
+	rows := sqlmock.NewRows([]string{"id", "value", "eTag", "isbinary"}).
+		AddRow("k2", value, "e2", true).
+		AddRow("k1", "{}", "e1", false)
+	m.mock1.ExpectQuery(`SELECT id, value, eTag, isbinary FROM state WHERE id IN \(\?, \?, \?\)`).
+		WithArgs("k1", "k2", "k3").
+		WillReturnRows(rows)
+
+	// Act
+	supported, response, err := m.mySQL.BulkGet([]state.GetRequest{
+		{Key: "k1"},
+		{Key: "k2"},
+		{Key: "k3"},
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.True(t, supported)
+	assert.Len(t, response, 3)
+	assert.Equal(t, "k1", response[0].Key)
+	assert.Equal(t, "{}", string(response[0].Data))
+	assert.Equal(t, "e1", *response[0].ETag)
+	assert.Equal(t, "k2", response[1].Key)
+	assert.Equal(t, "abcdefg", string(response[1].Data))
+	assert.Equal(t, "e2", *response[1].ETag)
+	assert.Equal(t, "k3", response[2].Key)
+	assert.Nil(t, response[2].Data)
+	assert.Nil(t, response[2].ETag)
+}
+
+func TestListKeysSucceeds(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow("app||b").AddRow("app||c")
+	m.mock1.ExpectQuery(`SELECT id FROM state WHERE id LIKE \? AND id > \? AND \(expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP\) ORDER BY id LIMIT 3`).
+		WithArgs("app||%", "app||a").
+		WillReturnRows(rows)
+
+	// Act
+	response, err := m.mySQL.ListKeys(&state.ListKeysRequest{Prefix: "app||", Limit: 2, Token: "app||a"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app||b", "app||c"}, response.Keys)
+	assert.Empty(t, response.Token)
+}
+
+func TestBulkGetReturnsPerKeyError(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "value", "eTag", "isbinary"}).
+		AddRow("k1", "not json", "e1", true)
+	m.mock1.ExpectQuery("SELECT id, value, eTag, isbinary FROM state WHERE id IN").WillReturnRows(rows)
+
+	// Act
+	supported, response, err := m.mySQL.BulkGet([]state.GetRequest{{Key: "k1"}})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.True(t, supported)
+	assert.Len(t, response, 1)
+	assert.NotEmpty(t, response[0].Error)
+}
+
 // Verifies that the correct query is executed to test if the table
 // already exists in the database or not.
 func TestTableExists(t *testing.T) {
@@ -594,6 +700,110 @@ func TestEnsureStateTableCreatesTable(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// Verifies that ensureStateTable adds the expiredate column to a table
+// created by a previous version.
+func TestEnsureStateTableAddsExpireDateColumn(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	m.mock1.ExpectQuery("SELECT EXISTS").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	m.mock1.ExpectQuery("SELECT EXISTS").WithArgs("state", "expiredate").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(0))
+	m.mock1.ExpectExec("ALTER TABLE state ADD COLUMN expiredate").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Act
+	err := m.mySQL.ensureStateTable("state")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock1.ExpectationsWereMet())
+}
+
+func TestSetWithTTL(t *testing.T) {
+	t.Run("sets the expiration date", func(t *testing.T) {
+		// Arrange
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		m.mock1.ExpectExec(`INSERT INTO state \(value, id, eTag, isbinary, expiredate\)`).
+			WithArgs(sqlmock.AnyArg(), "key", sqlmock.AnyArg(), false, 60, sqlmock.AnyArg(), sqlmock.AnyArg(), false, 60).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		request := state.SetRequest{
+			Key:      "key",
+			Value:    "value",
+			Metadata: map[string]string{"ttlInSeconds": "60"},
+		}
+
+		// Act
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Nil(t, m.mock1.ExpectationsWereMet())
+	})
+
+	t.Run("clears the expiration date with ttl -1", func(t *testing.T) {
+		// Arrange
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		m.mock1.ExpectExec(`UPDATE state SET value = \?, eTag = \?, isbinary = \?, expiredate = DATE_ADD`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), false, nil, "key", "946af56e").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		eTag := "946af56e"
+		request := state.SetRequest{
+			Key:      "key",
+			Value:    "value",
+			ETag:     &eTag,
+			Metadata: map[string]string{"ttlInSeconds": "-1"},
+		}
+
+		// Act
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Nil(t, m.mock1.ExpectationsWereMet())
+	})
+
+	t.Run("rejects an invalid ttl", func(t *testing.T) {
+		// Arrange
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		request := state.SetRequest{
+			Key:      "key",
+			Value:    "value",
+			Metadata: map[string]string{"ttlInSeconds": "-2"},
+		}
+
+		// Act
+		err := m.mySQL.setValue(context.Background(), m.mySQL.db, &request)
+
+		// Assert
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDeleteExpiredValues(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	m.mock1.ExpectExec(`DELETE FROM state WHERE expiredate IS NOT NULL AND expiredate <= CURRENT_TIMESTAMP`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// Act
+	err := m.mySQL.deleteExpiredValues(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock1.ExpectationsWereMet())
+}
+
 // Verify that the call to MySQL init get passed through
 // to the DbAccess instance.
 func TestInitReturnsErrorOnNoConnectionString(t *testing.T) {
@@ -681,9 +891,9 @@ func TestInitSetsSchemaName(t *testing.T) {
 	assert.Equal(t, "stateStoreSchema", m.mySQL.schemaName, "table name did not default")
 }
 
-// This state store does not support BulkGet so it must return false and
-// nil nil.
-func TestBulkGetReturnsNil(t *testing.T) {
+// BulkGet without any keys must not query the database and return an empty
+// response.
+func TestBulkGetWithNoRequestsReturnsEmpty(t *testing.T) {
 	// Arrange
 	t.Parallel()
 	m, _ := mockDatabase(t)
@@ -693,8 +903,8 @@ func TestBulkGetReturnsNil(t *testing.T) {
 
 	// Assert
 	assert.Nil(t, err, `returned err`)
-	assert.Nil(t, response, `returned response`)
-	assert.False(t, supported, `returned supported`)
+	assert.Empty(t, response, `returned response`)
+	assert.True(t, supported, `returned supported`)
 }
 
 func TestMultiWithNoRequestsDoesNothing(t *testing.T) {
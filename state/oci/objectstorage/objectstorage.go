@@ -162,7 +162,7 @@ func (r *StateStore) Ping() error {
 func NewOCIObjectStorageStore(logger logger.Logger) *StateStore {
 	s := &StateStore{
 		json:     jsoniter.ConfigFastest,
-		features: []state.Feature{state.FeatureETag},
+		features: []state.Feature{state.FeatureETag, state.FeatureTTL},
 		logger:   logger,
 		client:   nil,
 	}
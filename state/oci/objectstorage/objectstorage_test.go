@@ -152,6 +152,7 @@ func TestFeatures(t *testing.T) {
 	t.Run("Test contents of Features", func(t *testing.T) {
 		features := s.Features()
 		assert.Contains(t, features, state.FeatureETag)
+		assert.Contains(t, features, state.FeatureTTL)
 	})
 }
 
@@ -26,6 +26,7 @@ import (
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 
 	// Blank import for the underlying Oracle Database driver.
 	_ "github.com/sijms/go-ora/v2"
@@ -46,6 +47,7 @@ type oracleDatabaseAccess struct {
 	db               *sql.DB
 	connectionString string
 	tx               *sql.Tx
+	retryConfig      retry.Config
 }
 
 // newOracleDatabaseAccess creates a new instance of oracleDatabaseAccess.
@@ -72,6 +74,11 @@ func (o *oracleDatabaseAccess) Init(metadata state.Metadata) error {
 
 		return fmt.Errorf(errMissingConnectionString)
 	}
+	retryConfig, err := state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	o.retryConfig = retryConfig
 	if val, ok := o.metadata.Properties[oracleWalletLocationKey]; ok && val != "" {
 		o.connectionString += "?TRACE FILE=trace.log&SSL=enable&SSL Verify=false&WALLET=" + url.QueryEscape(val)
 	}
@@ -97,7 +104,7 @@ func (o *oracleDatabaseAccess) Init(metadata state.Metadata) error {
 
 // Set makes an insert or update to the database.
 func (o *oracleDatabaseAccess) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(o.setValue, req)
+	return state.SetWithRetries(o.setValue, req, o.retryConfig)
 }
 
 func parseTTL(requestMetadata map[string]string) (*int, error) {
@@ -254,7 +261,7 @@ func (o *oracleDatabaseAccess) Get(req *state.GetRequest) (*state.GetResponse, e
 
 // Delete removes an item from the state store.
 func (o *oracleDatabaseAccess) Delete(req *state.DeleteRequest) error {
-	return state.DeleteWithOptions(o.deleteValue, req)
+	return state.DeleteWithRetries(o.deleteValue, req, o.retryConfig)
 }
 
 // deleteValue is an internal implementation of delete to enable passing the logic to state.DeleteWithRetries as a func.
@@ -14,38 +14,67 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/agrea/ptr"
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 
+	"github.com/dapr/components-contrib/internal/component/sql/migrations"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 
-	// Blank import for the underlying PostgreSQL driver.
-	_ "github.com/jackc/pgx/v4/stdlib"
+	// Import for the underlying PostgreSQL driver.
+	"github.com/jackc/pgx/v4/stdlib"
 )
 
 const (
 	connectionStringKey        = "connectionString"
 	errMissingConnectionString = "missing connection string"
-	tableName                  = "state"
+	tableNameKey               = "tableName"
+	schemaKey                  = "schema"
+	keyPrefixKey               = "keyPrefix"
+	metadataTableNameKey       = "metadataTableName"
+	indexedPropertiesKey       = "indexedProperties"
+	defaultTableName           = "state"
 	connMaxIdleTimeKey         = "connMaxIdleTime"
+	cleanupIntervalKey         = "cleanupInterval"
+	defaultCleanupInterval     = time.Hour
+
+	// notExpired is the condition which excludes the rows whose ttl has elapsed.
+	notExpired = "(expiredate IS NULL OR expiredate > NOW())"
+	// remainingTTL is the column holding the seconds left before a row expires, rounded up.
+	remainingTTL = "CEIL(EXTRACT(EPOCH FROM expiredate - NOW()))::bigint AS ttl"
 )
 
 // postgresDBAccess implements dbaccess.
 type postgresDBAccess struct {
-	logger           logger.Logger
-	metadata         state.Metadata
-	db               *sql.DB
-	connectionString string
+	logger            logger.Logger
+	metadata          state.Metadata
+	db                *sql.DB
+	connectionString  string
+	schema            string
+	tableName         string
+	metadataTableName string
+	indexedProperties []IndexedProperty
+	keyPrefix         string
+	cleanupInterval   time.Duration
+	compressor        *utils.Compressor
+	retryConfig       retry.Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // newPostgresDBAccess creates a new instance of postgresAccess.
@@ -70,6 +99,27 @@ func (p *postgresDBAccess) Init(metadata state.Metadata) error {
 		return fmt.Errorf(errMissingConnectionString)
 	}
 
+	compressor, err := utils.NewCompressor(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	p.compressor = compressor
+
+	p.retryConfig, err = state.NewRetryConfig(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
+	err = p.parseTableMetadata(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
+	p.indexedProperties, err = parseIndexedProperties(metadata.Properties, p.tableName)
+	if err != nil {
+		return err
+	}
+
 	db, err := sql.Open("pgx", p.connectionString)
 	if err != nil {
 		p.logger.Error(err)
@@ -78,6 +128,7 @@ func (p *postgresDBAccess) Init(metadata state.Metadata) error {
 	}
 
 	p.db = db
+	p.ctx, p.cancel = context.WithCancel(context.Background())
 
 	pingErr := db.Ping()
 	if pingErr != nil {
@@ -89,21 +140,40 @@ func (p *postgresDBAccess) Init(metadata state.Metadata) error {
 		return err
 	}
 
-	err = p.ensureStateTable(tableName)
+	p.cleanupInterval = defaultCleanupInterval
+	err = propertyToDuration(p.metadata.Properties, cleanupIntervalKey, func(d time.Duration) {
+		p.cleanupInterval = d
+	})
+	if err != nil {
+		return err
+	}
+
+	err = p.ensureStateTable()
 	if err != nil {
 		return err
 	}
 
+	err = p.ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	if p.cleanupInterval > 0 {
+		go p.startCleanupThread()
+	}
+
 	return nil
 }
 
 // Set makes an insert or update to the database.
-func (p *postgresDBAccess) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(p.setValue, req)
+func (p *postgresDBAccess) Set(ctx context.Context, req *state.SetRequest) error {
+	return state.SetWithRetries(func(req *state.SetRequest) error {
+		return p.setValue(ctx, req)
+	}, req, p.retryConfig)
 }
 
 // setValue is an internal implementation of set to enable passing the logic to state.SetWithRetries as a func.
-func (p *postgresDBAccess) setValue(req *state.SetRequest) error {
+func (p *postgresDBAccess) setValue(ctx context.Context, req *state.SetRequest) error {
 	p.logger.Debug("Setting state value in PostgreSQL")
 
 	err := state.CheckRequestOptions(req.Options)
@@ -119,6 +189,11 @@ func (p *postgresDBAccess) setValue(req *state.SetRequest) error {
 		return fmt.Errorf("empty string is not allowed in set operation")
 	}
 
+	ttl, err := utils.ParseTTL(req.Metadata)
+	if err != nil {
+		return err
+	}
+
 	v := req.Value
 	byteArray, isBinary := req.Value.([]uint8)
 	if isBinary {
@@ -129,15 +204,29 @@ func (p *postgresDBAccess) setValue(req *state.SetRequest) error {
 	bt, _ := utils.Marshal(v, json.Marshal)
 	value := string(bt)
 
+	// Compressed values are saved in the compressedvalue column, leaving a JSON null in the value column.
+	var compressed []byte
+	if p.compressor != nil {
+		if !isBinary {
+			byteArray = bt
+		}
+		compressed, err = p.compressor.Compress(byteArray)
+		if err != nil {
+			return err
+		}
+		value = "null"
+	}
+
 	var result sql.Result
 
 	// Sprintf is required for table name because sql.DB does not substitute parameters for table names.
 	// Other parameters use sql.DB parameter substitution.
+	// The expiration date is NULL when ttl is nil, i.e. the value never expires.
 	if req.ETag == nil {
-		result, err = p.db.Exec(fmt.Sprintf(
-			`INSERT INTO %s (key, value, isbinary) VALUES ($1, $2, $3)
-			ON CONFLICT (key) DO UPDATE SET value = $2, isbinary = $3, updatedate = NOW();`,
-			tableName), req.Key, value, isBinary)
+		result, err = p.db.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %s (tenant, key, value, isbinary, compressedvalue, expiredate) VALUES ($5, $1, $2, $3, $6, NOW() + make_interval(secs => $4))
+			ON CONFLICT (tenant, key) DO UPDATE SET value = $2, isbinary = $3, compressedvalue = $6, updatedate = NOW(), expiredate = NOW() + make_interval(secs => $4);`,
+			p.qualifiedTableName()), req.Key, value, isBinary, ttl, p.keyPrefix, compressed)
 	} else {
 		// Convert req.ETag to uint32 for postgres XID compatibility
 		var etag64 uint64
@@ -148,10 +237,10 @@ func (p *postgresDBAccess) setValue(req *state.SetRequest) error {
 		etag := uint32(etag64)
 
 		// When an etag is provided do an update - no insert
-		result, err = p.db.Exec(fmt.Sprintf(
-			`UPDATE %s SET value = $1, isbinary = $2, updatedate = NOW()
-			 WHERE key = $3 AND xmin = $4;`,
-			tableName), value, isBinary, req.Key, etag)
+		result, err = p.db.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET value = $1, isbinary = $2, compressedvalue = $7, updatedate = NOW(), expiredate = NOW() + make_interval(secs => $5)
+			 WHERE key = $3 AND xmin = $4 AND tenant = $6 AND %s;`,
+			p.qualifiedTableName(), notExpired), value, isBinary, req.Key, etag, ttl, p.keyPrefix, compressed)
 	}
 
 	if err != nil {
@@ -174,9 +263,9 @@ func (p *postgresDBAccess) setValue(req *state.SetRequest) error {
 	return nil
 }
 
-func (p *postgresDBAccess) BulkSet(req []state.SetRequest) error {
+func (p *postgresDBAccess) BulkSet(ctx context.Context, req []state.SetRequest) error {
 	p.logger.Debug("Executing BulkSet request")
-	tx, err := p.db.Begin()
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -184,7 +273,7 @@ func (p *postgresDBAccess) BulkSet(req []state.SetRequest) error {
 	if len(req) > 0 {
 		for _, s := range req {
 			sa := s // Fix for gosec  G601: Implicit memory aliasing in for loop.
-			err = p.Set(&sa)
+			err = p.Set(ctx, &sa)
 			if err != nil {
 				tx.Rollback()
 
@@ -199,7 +288,7 @@ func (p *postgresDBAccess) BulkSet(req []state.SetRequest) error {
 }
 
 // Get returns data from the database. If data does not exist for the key an empty state.GetResponse will be returned.
-func (p *postgresDBAccess) Get(req *state.GetRequest) (*state.GetResponse, error) {
+func (p *postgresDBAccess) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
 	p.logger.Debug("Getting state value from PostgreSQL")
 	if req.Key == "" {
 		return nil, fmt.Errorf("missing key in get operation")
@@ -207,8 +296,10 @@ func (p *postgresDBAccess) Get(req *state.GetRequest) (*state.GetResponse, error
 
 	var value string
 	var isBinary bool
+	var compressed []byte
 	var etag int
-	err := p.db.QueryRow(fmt.Sprintf("SELECT value, isbinary, xmin as etag FROM %s WHERE key = $1", tableName), req.Key).Scan(&value, &isBinary, &etag)
+	var ttl sql.NullInt64
+	err := p.db.QueryRowContext(ctx, fmt.Sprintf("SELECT value, isbinary, compressedvalue, xmin as etag, %s FROM %s WHERE key = $1 AND tenant = $2 AND %s", remainingTTL, p.qualifiedTableName(), notExpired), req.Key, p.keyPrefix).Scan(&value, &isBinary, &compressed, &etag, &ttl)
 	if err != nil {
 		// If no rows exist, return an empty response, otherwise return the error.
 		if err == sql.ErrNoRows {
@@ -218,39 +309,104 @@ func (p *postgresDBAccess) Get(req *state.GetRequest) (*state.GetResponse, error
 		return nil, err
 	}
 
-	if isBinary {
-		var s string
-		var data []byte
+	data, err := decodeValue(value, isBinary, compressed)
+	if err != nil {
+		return nil, err
+	}
 
-		if err = json.Unmarshal([]byte(value), &s); err != nil {
-			return nil, err
+	return &state.GetResponse{
+		Data:     data,
+		ETag:     ptr.String(strconv.Itoa(etag)),
+		Metadata: utils.WithTTLMetadata(req.Metadata, time.Duration(ttl.Int64)*time.Second),
+	}, nil
+}
+
+// BulkGet returns the data for multiple keys using a single query.
+// Keys that do not exist are returned with empty data, and keys whose value
+// cannot be decoded are returned with an error set on their response.
+func (p *postgresDBAccess) BulkGet(ctx context.Context, req []state.GetRequest) ([]state.BulkGetResponse, error) {
+	p.logger.Debug("Getting bulk state values from PostgreSQL")
+	if len(req) == 0 {
+		return []state.BulkGetResponse{}, nil
+	}
+
+	keys := make([]string, len(req))
+	for i, r := range req {
+		if r.Key == "" {
+			return nil, fmt.Errorf("missing key in bulk get operation")
 		}
+		keys[i] = r.Key
+	}
 
-		if data, err = base64.StdEncoding.DecodeString(s); err != nil {
+	// The keys are passed as a single array parameter, so that the statement is the same for any number of keys.
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT key, value, isbinary, compressedvalue, xmin as etag, %s FROM %s WHERE key = ANY($1) AND tenant = $2 AND %s",
+		remainingTTL, p.qualifiedTableName(), notExpired), keys, p.keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]state.BulkGetResponse, len(req))
+	ttls := make(map[string]time.Duration, len(req))
+	for rows.Next() {
+		var key, value string
+		var isBinary bool
+		var compressed []byte
+		var etag int
+		var ttl sql.NullInt64
+		if err = rows.Scan(&key, &value, &isBinary, &compressed, &etag, &ttl); err != nil {
 			return nil, err
 		}
 
-		return &state.GetResponse{
-			Data:     data,
-			ETag:     ptr.String(strconv.Itoa(etag)),
-			Metadata: req.Metadata,
-		}, nil
+		item := state.BulkGetResponse{
+			Key:  key,
+			ETag: ptr.String(strconv.Itoa(etag)),
+		}
+		if item.Data, err = decodeValue(value, isBinary, compressed); err != nil {
+			item.ETag = nil
+			item.Error = err.Error()
+		}
+		found[key] = item
+		ttls[key] = time.Duration(ttl.Int64) * time.Second
 	}
 
-	return &state.GetResponse{
-		Data:     []byte(value),
-		ETag:     ptr.String(strconv.Itoa(etag)),
-		Metadata: req.Metadata,
-	}, nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]state.BulkGetResponse, len(req))
+	for i, r := range req {
+		item, ok := found[r.Key]
+		if !ok {
+			item = state.BulkGetResponse{Key: r.Key}
+		}
+		item.Metadata = utils.WithTTLMetadata(r.Metadata, ttls[r.Key])
+		res[i] = item
+	}
+
+	return res, nil
+}
+
+// decodeValue returns the raw bytes of a value read from the state table.
+// Compressed values are stored in the compressedvalue column instead of the value column.
+func decodeValue(value string, isBinary bool, compressed []byte) ([]byte, error) {
+	if compressed != nil {
+		return utils.Decompress(compressed)
+	}
+
+	return utils.DecodeValue(value, isBinary)
 }
 
 // Delete removes an item from the state store.
-func (p *postgresDBAccess) Delete(req *state.DeleteRequest) error {
-	return state.DeleteWithOptions(p.deleteValue, req)
+func (p *postgresDBAccess) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	return state.DeleteWithRetries(func(req *state.DeleteRequest) error {
+		return p.deleteValue(ctx, req)
+	}, req, p.retryConfig)
 }
 
 // deleteValue is an internal implementation of delete to enable passing the logic to state.DeleteWithRetries as a func.
-func (p *postgresDBAccess) deleteValue(req *state.DeleteRequest) error {
+func (p *postgresDBAccess) deleteValue(ctx context.Context, req *state.DeleteRequest) error {
 	p.logger.Debug("Deleting state value from PostgreSQL")
 	if req.Key == "" {
 		return fmt.Errorf("missing key in delete operation")
@@ -260,7 +416,7 @@ func (p *postgresDBAccess) deleteValue(req *state.DeleteRequest) error {
 	var err error
 
 	if req.ETag == nil {
-		result, err = p.db.Exec("DELETE FROM state WHERE key = $1", req.Key)
+		result, err = p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE key = $1 AND tenant = $2", p.qualifiedTableName()), req.Key, p.keyPrefix)
 	} else {
 		// Convert req.ETag to uint32 for postgres XID compatibility
 		var etag64 uint64
@@ -270,7 +426,7 @@ func (p *postgresDBAccess) deleteValue(req *state.DeleteRequest) error {
 		}
 		etag := uint32(etag64)
 
-		result, err = p.db.Exec("DELETE FROM state WHERE key = $1 and xmin = $2", req.Key, etag)
+		result, err = p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE key = $1 AND xmin = $2 AND tenant = $3", p.qualifiedTableName()), req.Key, etag, p.keyPrefix)
 	}
 
 	if err != nil {
@@ -289,9 +445,9 @@ func (p *postgresDBAccess) deleteValue(req *state.DeleteRequest) error {
 	return nil
 }
 
-func (p *postgresDBAccess) BulkDelete(req []state.DeleteRequest) error {
+func (p *postgresDBAccess) BulkDelete(ctx context.Context, req []state.DeleteRequest) error {
 	p.logger.Debug("Executing BulkDelete request")
-	tx, err := p.db.Begin()
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -299,7 +455,7 @@ func (p *postgresDBAccess) BulkDelete(req []state.DeleteRequest) error {
 	if len(req) > 0 {
 		for _, d := range req {
 			da := d // Fix for gosec  G601: Implicit memory aliasing in for loop.
-			err = p.Delete(&da)
+			err = p.Delete(ctx, &da)
 			if err != nil {
 				tx.Rollback()
 
@@ -313,10 +469,10 @@ func (p *postgresDBAccess) BulkDelete(req []state.DeleteRequest) error {
 	return err
 }
 
-func (p *postgresDBAccess) ExecuteMulti(request *state.TransactionalStateRequest) error {
+func (p *postgresDBAccess) ExecuteMulti(ctx context.Context, request *state.TransactionalStateRequest) error {
 	p.logger.Debug("Executing PostgreSQL transaction")
 
-	tx, err := p.db.Begin()
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -332,7 +488,7 @@ func (p *postgresDBAccess) ExecuteMulti(request *state.TransactionalStateRequest
 				return err
 			}
 
-			err = p.Set(&setReq)
+			err = p.Set(ctx, &setReq)
 			if err != nil {
 				tx.Rollback()
 				return err
@@ -347,7 +503,7 @@ func (p *postgresDBAccess) ExecuteMulti(request *state.TransactionalStateRequest
 				return err
 			}
 
-			err = p.Delete(&delReq)
+			err = p.Delete(ctx, &delReq)
 			if err != nil {
 				tx.Rollback()
 				return err
@@ -365,17 +521,32 @@ func (p *postgresDBAccess) ExecuteMulti(request *state.TransactionalStateRequest
 }
 
 // Query executes a query against store.
-func (p *postgresDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+// The filters, sorting, aggregations and projected fields only apply to the
+// value column, so they do not match the values saved while compression was enabled.
+func (p *postgresDBAccess) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	p.logger.Debug("Getting query value from PostgreSQL")
 	q := &Query{
 		query:  "",
 		params: []interface{}{},
+		table:  p.qualifiedTableName(),
+		tenant: p.keyPrefix,
 	}
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
-	data, token, err := q.execute(p.logger, p.db)
+	if q.aggregate != nil {
+		aggregations, err := q.executeAggregate(ctx, p.db)
+		if err != nil {
+			return &state.QueryResponse{}, err
+		}
+
+		return &state.QueryResponse{
+			Results:      []state.QueryItem{},
+			Aggregations: aggregations,
+		}, nil
+	}
+	data, token, err := q.execute(ctx, p.logger, p.db)
 	if err != nil {
 		return &state.QueryResponse{}, err
 	}
@@ -388,6 +559,9 @@ func (p *postgresDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse,
 
 // Close implements io.Close.
 func (p *postgresDBAccess) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
 	if p.db != nil {
 		return p.db.Close()
 	}
@@ -395,36 +569,486 @@ func (p *postgresDBAccess) Close() error {
 	return nil
 }
 
-func (p *postgresDBAccess) ensureStateTable(stateTableName string) error {
-	exists, err := tableExists(p.db, stateTableName)
+// parseTableMetadata reads the name and schema of the state table, and the prefix
+// which isolates the keys of this store from the other stores sharing the table.
+func (p *postgresDBAccess) parseTableMetadata(properties map[string]string) error {
+	p.tableName = defaultTableName
+	if val, ok := properties[tableNameKey]; ok && val != "" {
+		if !isValidSQLName(val) {
+			return fmt.Errorf("invalid table name, accepted characters are (A-Z, a-z, 0-9, _) and it must not start with a digit")
+		}
+		p.tableName = strings.ToLower(val)
+	}
+
+	p.schema = ""
+	if val, ok := properties[schemaKey]; ok && val != "" {
+		if !isValidSQLName(val) {
+			return fmt.Errorf("invalid schema name, accepted characters are (A-Z, a-z, 0-9, _) and it must not start with a digit")
+		}
+		p.schema = strings.ToLower(val)
+	}
+
+	p.metadataTableName = migrations.DefaultMetadataTableName
+	if val, ok := properties[metadataTableNameKey]; ok && val != "" {
+		if !isValidSQLName(val) {
+			return fmt.Errorf("invalid metadata table name, accepted characters are (A-Z, a-z, 0-9, _) and it must not start with a digit")
+		}
+		p.metadataTableName = strings.ToLower(val)
+	}
+
+	p.keyPrefix = properties[keyPrefixKey]
+
+	return nil
+}
+
+// isValidSQLName reports whether s can be used as an unquoted PostgreSQL identifier.
+func isValidSQLName(s string) bool {
+	for i, c := range s {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// qualifiedTableName returns the name of the state table, prefixed by its schema if one is set.
+func (p *postgresDBAccess) qualifiedTableName() string {
+	return p.qualifiedName(p.tableName)
+}
+
+func (p *postgresDBAccess) qualifiedName(name string) string {
+	if p.schema == "" {
+		return name
+	}
+
+	return p.schema + "." + name
+}
+
+// IndexedProperty defines a property of the JSON values which is indexed, so that
+// the queries filtering or sorting on it do not scan the whole table.
+type IndexedProperty struct {
+	// Property is the path of the property, e.g. person.org.
+	Property string `json:"property"`
+	// Type is either text, the default, or numeric. Numeric properties are indexed
+	// as numbers, and speed up the range comparisons with numbers.
+	Type string `json:"type"`
+}
+
+const (
+	indexTypeText    = "text"
+	indexTypeNumeric = "numeric"
+)
+
+// parseIndexedProperties reads the properties to index from the component metadata.
+func parseIndexedProperties(properties map[string]string, stateTableName string) ([]IndexedProperty, error) {
+	val, ok := properties[indexedPropertiesKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	var indexedProperties []IndexedProperty
+	if err := json.Unmarshal([]byte(val), &indexedProperties); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", indexedPropertiesKey, err)
+	}
+
+	for i, prop := range indexedProperties {
+		if !isValidPropertyPath(prop.Property) {
+			return nil, fmt.Errorf("invalid indexed property %q, accepted characters are (A-Z, a-z, 0-9, _) separated by dots", prop.Property)
+		}
+
+		switch prop.Type {
+		case "":
+			indexedProperties[i].Type = indexTypeText
+		case indexTypeText, indexTypeNumeric:
+		default:
+			return nil, fmt.Errorf("invalid type %q of indexed property %s, accepted types are text and numeric", prop.Type, prop.Property)
+		}
+
+		// PostgreSQL truncates the longer names, which could then collide.
+		if name := indexName(stateTableName, indexedProperties[i]); len(name) > maxIdentifierLength {
+			return nil, fmt.Errorf("the name of the index %s is longer than %d characters", name, maxIdentifierLength)
+		}
+	}
+
+	return indexedProperties, nil
+}
+
+// maxIdentifierLength is the maximum length of the PostgreSQL identifiers.
+const maxIdentifierLength = 63
+
+func isValidPropertyPath(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func indexName(stateTableName string, prop IndexedProperty) string {
+	name := stateTableName + "_" + strings.ToLower(strings.ReplaceAll(prop.Property, ".", "_"))
+	if prop.Type == indexTypeNumeric {
+		name += "_num"
+	}
+
+	return name + "_idx"
+}
+
+// indexExpression returns the expression of the property used by the queries,
+// which the index must match exactly to be used.
+func indexExpression(prop IndexedProperty) string {
+	expr := translateFieldToFilter(prop.Property)
+	if prop.Type == indexTypeNumeric {
+		expr = fmt.Sprintf("(%s)::numeric", expr)
+	}
+
+	return expr
+}
+
+// ensureIndexes creates the indexes of the indexed properties which do not exist.
+// Indexes of properties removed from the metadata are not dropped.
+// The indexes are built concurrently, so that the writes to the state table
+// are not blocked while an index is built.
+func (p *postgresDBAccess) ensureIndexes() error {
+	for _, prop := range p.indexedProperties {
+		p.logger.Debugf("Ensuring the index of property %s exists", prop.Property)
+		name := indexName(p.tableName, prop)
+		err := p.dropInvalidIndex(name)
+		if err != nil {
+			return fmt.Errorf("error dropping the invalid index of property %s: %w", prop.Property, err)
+		}
+
+		// The queries are always filtered by tenant.
+		_, err = p.db.ExecContext(p.ctx, fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (tenant, (%s))",
+			name, p.qualifiedTableName(), indexExpression(prop)))
+		if err != nil {
+			return fmt.Errorf("error creating the index of property %s: %w", prop.Property, err)
+		}
+	}
+
+	return nil
+}
+
+// dropInvalidIndex drops the index left invalid by a concurrent build which failed,
+// since CREATE INDEX IF NOT EXISTS would not rebuild it.
+func (p *postgresDBAccess) dropInvalidIndex(name string) error {
+	var invalid bool
+	err := p.db.QueryRowContext(p.ctx, "SELECT NOT indisvalid FROM pg_index WHERE indexrelid = to_regclass($1)", p.qualifiedName(name)).Scan(&invalid)
+	if err == sql.ErrNoRows || (err == nil && !invalid) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		p.logger.Info("Creating PostgreSQL state table")
-		createTable := fmt.Sprintf(`CREATE TABLE %s (
+	_, err = p.db.ExecContext(p.ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", p.qualifiedName(name)))
+
+	return err
+}
+
+// ensureStateTable creates the state table if it does not exist, and migrates
+// its schema to the latest version.
+func (p *postgresDBAccess) ensureStateTable() error {
+	if p.schema != "" {
+		_, err := p.db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", p.schema))
+		if err != nil {
+			return err
+		}
+	}
+
+	return migrations.Migrate(p.ctx, p.db, migrations.Options{
+		Logger:            p.logger,
+		MetadataTableName: p.qualifiedName(p.metadataTableName),
+		Key:               p.qualifiedTableName(),
+		Lock:              migrations.PostgresAdvisoryLock,
+	}, p.migrations())
+}
+
+// migrations returns the up-steps of the state table schema.
+// Released migrations must never be modified, only appended to.
+func (p *postgresDBAccess) migrations() []migrations.Migration {
+	stateTableName := p.qualifiedTableName()
+
+	return []migrations.Migration{
+		{
+			Description: "create the state table",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				// The table already exists if it was created before the migrations were introduced.
+				_, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
 									key text NOT NULL PRIMARY KEY,
 									value jsonb NOT NULL,
 									isbinary boolean NOT NULL,
 									insertdate TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName)
-		_, err = p.db.Exec(createTable)
-		if err != nil {
-			return err
+									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName))
+
+				return err
+			},
+		},
+		{
+			Description: "add the expiredate column",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS expiredate TIMESTAMP WITH TIME ZONE NULL", stateTableName))
+
+				return err
+			},
+		},
+		{
+			Description: "add the tenant column to the primary key",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				exists, err := columnExists(ctx, tx, p.schema, p.tableName, "tenant")
+				if err != nil || exists {
+					return err
+				}
+
+				// The primary key may not have the default name if the table was created by hand.
+				pkey, err := primaryKeyName(ctx, tx, stateTableName)
+				if err != nil {
+					return err
+				}
+				dropPkey := ""
+				if pkey != "" {
+					dropPkey = fmt.Sprintf("DROP CONSTRAINT %s, ", pgx.Identifier{pkey}.Sanitize())
+				}
+
+				_, err = tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN tenant text NOT NULL DEFAULT '',
+					%sADD PRIMARY KEY (tenant, key)`, stateTableName, dropPkey))
+
+				return err
+			},
+		},
+		{
+			Description: "add the compressedvalue column",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS compressedvalue bytea NULL", stateTableName))
+
+				return err
+			},
+		},
+	}
+}
+
+// startCleanupThread periodically deletes the rows whose ttl has elapsed until the store is closed.
+func (p *postgresDBAccess) startCleanupThread() {
+	ticker := time.NewTicker(p.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.deleteExpiredValues(p.ctx); err != nil {
+				p.logger.Errorf("error deleting expired values from PostgreSQL: %s", err)
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *postgresDBAccess) deleteExpiredValues(ctx context.Context) error {
+	res, err := p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expiredate IS NOT NULL AND expiredate <= NOW()", p.qualifiedTableName()))
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		p.logger.Debugf("Deleted %d expired values from PostgreSQL", n)
+	}
+
+	return nil
+}
+
+// ListKeys returns the keys starting with req.Prefix in lexical order.
+// The token is the last key of the previous page.
+func (p *postgresDBAccess) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	p.logger.Debug("Listing state keys from PostgreSQL")
+
+	query := fmt.Sprintf("SELECT key FROM %s WHERE key LIKE $1 AND key > $2 AND tenant = $3 AND %s ORDER BY key", p.qualifiedTableName(), notExpired)
+	if req.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+	rows, err := p.db.QueryContext(ctx, query, utils.LikePrefixPattern(req.Prefix), req.Token, p.keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
 		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return state.NewListKeysResponse(keys, req.Limit), nil
+}
+
+// Watch delivers the changes of the keys starting with req.KeyPrefix to the handler
+// until ctx is canceled or the store is closed. Changes are published by a trigger
+// on the state table using NOTIFY; the trigger is created by the first call.
+// The value and ETag of a change are read when the notification is received,
+// so a handler may observe a later version than the one which triggered it.
+func (p *postgresDBAccess) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	err := p.ensureNotifyTrigger(ctx)
+	if err != nil {
+		return err
 	}
 
+	// LISTEN requires a dedicated connection which is held until the watch ends.
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("LISTEN %s", p.notifyChannel()))
+	if err != nil {
+		conn.Close()
+
+		return err
+	}
+
+	go p.listen(ctx, conn, req.KeyPrefix, handler)
+
 	return nil
 }
 
-func tableExists(db *sql.DB, tableName string) (bool, error) {
+func (p *postgresDBAccess) listen(ctx context.Context, conn *sql.Conn, keyPrefix string, handler state.WatchHandler) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.ctx.Done():
+			cancel()
+		}
+	}()
+
+	conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		for {
+			n, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.logger.Errorf("error waiting for PostgreSQL state changes: %s", err)
+				}
+
+				// the connection is still listening, so it must not be returned to the pool
+				return driver.ErrBadConn
+			}
+			p.handleNotification(ctx, n.Payload, keyPrefix, handler)
+		}
+	})
+}
+
+type stateChangeNotification struct {
+	Tenant    string              `json:"tenant"`
+	Key       string              `json:"key"`
+	Operation state.OperationType `json:"operation"`
+}
+
+func (p *postgresDBAccess) handleNotification(ctx context.Context, payload string, keyPrefix string, handler state.WatchHandler) {
+	var n stateChangeNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		p.logger.Errorf("invalid PostgreSQL state change notification %q: %s", payload, err)
+
+		return
+	}
+	if n.Tenant != p.keyPrefix || !strings.HasPrefix(n.Key, keyPrefix) {
+		return
+	}
+
+	e := &state.WatchEvent{Key: n.Key, Operation: n.Operation}
+	if n.Operation == state.Upsert {
+		res, err := p.Get(ctx, &state.GetRequest{Key: n.Key})
+		if err != nil {
+			p.logger.Errorf("error reading changed key %s: %s", n.Key, err)
+
+			return
+		}
+		if res.Data == nil {
+			// the key was deleted before it could be read; its delete notification follows
+			return
+		}
+		e.Value = res.Data
+		e.ETag = res.ETag
+	}
+
+	if err := handler(ctx, e); err != nil {
+		p.logger.Errorf("error handling state change for key %s: %s", n.Key, err)
+	}
+}
+
+// notifyChannel returns the channel on which the changes of the state table are published.
+func (p *postgresDBAccess) notifyChannel() string {
+	if p.schema == "" {
+		return p.tableName + "_changes"
+	}
+
+	return p.schema + "_" + p.tableName + "_changes"
+}
+
+// ensureNotifyTrigger creates the trigger which publishes the changes of the state table.
+func (p *postgresDBAccess) ensureNotifyTrigger(ctx context.Context) error {
+	// The function is created in the schema of the state table.
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s_notify() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM pg_notify('%[2]s', json_build_object('tenant', OLD.tenant, 'key', OLD.key, 'operation', 'delete')::text);
+    RETURN OLD;
+  END IF;
+  PERFORM pg_notify('%[2]s', json_build_object('tenant', NEW.tenant, 'key', NEW.key, 'operation', 'upsert')::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`, p.qualifiedTableName(), p.notifyChannel()))
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, fmt.Sprintf(`DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = '%[2]s_notify' AND tgrelid = '%[1]s'::regclass) THEN
+    CREATE TRIGGER %[2]s_notify AFTER INSERT OR UPDATE OR DELETE ON %[1]s
+      FOR EACH ROW EXECUTE PROCEDURE %[1]s_notify();
+  END IF;
+END;
+$$;`, p.qualifiedTableName(), p.tableName))
+
+	return err
+}
+
+func columnExists(ctx context.Context, tx *sql.Tx, schema string, tableName string, columnName string) (bool, error) {
 	exists := false
-	err := db.QueryRow("SELECT EXISTS (SELECT FROM pg_tables where tablename = $1)", tableName).Scan(&exists)
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT FROM information_schema.columns
+		WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2 AND column_name = $3)`,
+		schema, tableName, columnName).Scan(&exists)
 
 	return exists, err
 }
 
+// primaryKeyName returns the name of the primary key constraint of table,
+// or an empty string if it has no primary key.
+func primaryKeyName(ctx context.Context, tx *sql.Tx, table string) (string, error) {
+	var name string
+	err := tx.QueryRowContext(ctx, `SELECT conname FROM pg_constraint WHERE conrelid = $1::regclass AND contype = 'p'`, table).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return name, err
+}
+
 // Returns the set requests.
 func getSet(req state.TransactionalStateOperation) (state.SetRequest, error) {
 	setReq, ok := req.Request.(state.SetRequest)
@@ -13,13 +13,21 @@ limitations under the License.
 package postgresql
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agrea/ptr"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 )
 
@@ -108,7 +116,7 @@ func TestMultiWithNoRequests(t *testing.T) {
 	var operations []state.TransactionalStateOperation
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -132,7 +140,7 @@ func TestInvalidMultiInvalidAction(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -157,7 +165,7 @@ func TestValidSetRequest(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -181,7 +189,7 @@ func TestInvalidMultiSetRequest(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -205,7 +213,7 @@ func TestInvalidMultiSetRequestNoKey(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -230,7 +238,7 @@ func TestValidMultiDeleteRequest(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -254,7 +262,7 @@ func TestInvalidMultiDeleteRequest(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -278,7 +286,7 @@ func TestInvalidMultiDeleteRequestNoKey(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -310,7 +318,7 @@ func TestMultiOperationOrder(t *testing.T) {
 	)
 
 	// Act
-	err := m.pgDba.ExecuteMulti(&state.TransactionalStateRequest{
+	err := m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
 		Operations: operations,
 	})
 
@@ -333,7 +341,7 @@ func TestInvalidBulkSetNoKey(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.BulkSet(sets)
+	err := m.pgDba.BulkSet(context.Background(), sets)
 
 	// Assert
 	assert.NotNil(t, err)
@@ -355,7 +363,7 @@ func TestInvalidBulkSetEmptyValue(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.BulkSet(sets)
+	err := m.pgDba.BulkSet(context.Background(), sets)
 
 	// Assert
 	assert.NotNil(t, err)
@@ -378,7 +386,7 @@ func TestValidBulkSet(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.BulkSet(sets)
+	err := m.pgDba.BulkSet(context.Background(), sets)
 
 	// Assert
 	assert.Nil(t, err)
@@ -399,7 +407,7 @@ func TestInvalidBulkDeleteNoKey(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.BulkDelete(deletes)
+	err := m.pgDba.BulkDelete(context.Background(), deletes)
 
 	// Assert
 	assert.NotNil(t, err)
@@ -421,12 +429,79 @@ func TestValidBulkDelete(t *testing.T) {
 	})
 
 	// Act
-	err := m.pgDba.BulkDelete(deletes)
+	err := m.pgDba.BulkDelete(context.Background(), deletes)
 
 	// Assert
 	assert.Nil(t, err)
 }
 
+func TestValidBulkGet(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	rows := sqlmock.NewRows([]string{"key", "value", "isbinary", "compressedvalue", "etag", "ttl"}).
+		AddRow("key1", `{"a":1}`, false, nil, 10, 30).
+		AddRow("key3", `"aGVsbG8="`, true, nil, 12, nil)
+	m.mock.ExpectQuery(`SELECT key, value, isbinary, compressedvalue, xmin as etag, .+ AS ttl FROM state WHERE key = ANY\(\$1\) AND tenant = \$2`).
+		WithArgs([]string{"key1", "key2", "key3"}, "").
+		WillReturnRows(rows)
+
+	// Act
+	res, err := m.pgDba.BulkGet(context.Background(), []state.GetRequest{
+		{Key: "key1"},
+		{Key: "key2"},
+		{Key: "key3"},
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "key1", res[0].Key)
+	assert.Equal(t, `{"a":1}`, string(res[0].Data))
+	assert.Equal(t, "10", *res[0].ETag)
+	assert.Equal(t, map[string]string{"ttlInSeconds": "30"}, res[0].Metadata)
+	assert.Equal(t, "key2", res[1].Key)
+	assert.Nil(t, res[1].Data)
+	assert.Nil(t, res[1].ETag)
+	assert.Equal(t, "key3", res[2].Key)
+	assert.Equal(t, "hello", string(res[2].Data))
+	assert.Equal(t, "12", *res[2].ETag)
+	assert.Nil(t, res[2].Metadata)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestBulkGetInvalidBinaryValue(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	rows := sqlmock.NewRows([]string{"key", "value", "isbinary", "compressedvalue", "etag", "ttl"}).
+		AddRow("key1", `"not base64!"`, true, nil, 10, nil)
+	m.mock.ExpectQuery("SELECT key").WillReturnRows(rows)
+
+	// Act
+	res, err := m.pgDba.BulkGet(context.Background(), []state.GetRequest{{Key: "key1"}})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, res, 1)
+	assert.NotEmpty(t, res[0].Error)
+	assert.Nil(t, res[0].ETag)
+}
+
+func TestInvalidBulkGetNoKey(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	// Act
+	_, err := m.pgDba.BulkGet(context.Background(), []state.GetRequest{{Key: ""}})
+
+	// Assert
+	assert.NotNil(t, err)
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
@@ -440,17 +515,410 @@ func createDeleteRequest() state.DeleteRequest {
 	}
 }
 
+func TestSetWithTTL(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.mock.ExpectExec(`INSERT INTO state \(tenant, key, value, isbinary, compressedvalue, expiredate\)`).
+		WithArgs("key1", `"value1"`, false, 60, "", []byte(nil)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.mock.ExpectExec(`INSERT INTO state`).
+		WithArgs("key2", `"value2"`, false, nil, "", []byte(nil)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Act
+	err1 := m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key1", Value: "value1", Metadata: map[string]string{"ttlInSeconds": "60"}})
+	err2 := m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key2", Value: "value2", Metadata: map[string]string{"ttlInSeconds": "-1"}})
+	err3 := m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key3", Value: "value3", Metadata: map[string]string{"ttlInSeconds": "abc"}})
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.NotNil(t, err3)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+// capturedValue is a sqlmock argument matcher which records the value it matches.
+type capturedValue struct {
+	value []byte
+}
+
+func (c *capturedValue) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	c.value = b
+
+	return ok
+}
+
+func TestSetAndGetCompressed(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	compressor, err := utils.NewCompressor(map[string]string{utils.CompressionKey: utils.CompressionZstd})
+	assert.Nil(t, err)
+	m.pgDba.compressor = compressor
+
+	data := bytes.Repeat([]byte("blue"), 100)
+	doc := map[string]string{"color": strings.Repeat("blue", 100)}
+	binaryValue := &capturedValue{}
+	jsonValue := &capturedValue{}
+	m.mock.ExpectExec(`INSERT INTO state \(tenant, key, value, isbinary, compressedvalue, expiredate\)`).
+		WithArgs("key1", "null", true, nil, "", binaryValue).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.mock.ExpectExec(`INSERT INTO state`).
+		WithArgs("key2", "null", false, nil, "", jsonValue).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Act
+	err = m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key1", Value: data})
+	assert.Nil(t, err)
+	err = m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key2", Value: doc})
+	assert.Nil(t, err)
+	assert.Less(t, len(binaryValue.value), len(data))
+	assert.Less(t, len(jsonValue.value), len(doc["color"]))
+
+	m.mock.ExpectQuery(`SELECT value, isbinary, compressedvalue, xmin as etag, .+ AS ttl FROM state WHERE key = \$1 AND tenant = \$2`).
+		WithArgs("key1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary", "compressedvalue", "etag", "ttl"}).AddRow("null", true, binaryValue.value, 42, nil))
+	res1, err1 := m.pgDba.Get(context.Background(), &state.GetRequest{Key: "key1"})
+	m.mock.ExpectQuery(`SELECT value, isbinary, compressedvalue, xmin as etag`).
+		WithArgs("key2", "").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary", "compressedvalue", "etag", "ttl"}).AddRow("null", false, jsonValue.value, 43, nil))
+	res2, err2 := m.pgDba.Get(context.Background(), &state.GetRequest{Key: "key2"})
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Equal(t, data, res1.Data)
+	assert.Nil(t, err2)
+	expected, _ := json.Marshal(doc)
+	assert.Equal(t, expected, res2.Data)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestDeleteExpiredValues(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.mock.ExpectExec(`DELETE FROM state WHERE expiredate IS NOT NULL AND expiredate <= NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// Act
+	err := m.pgDba.deleteExpiredValues(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestListKeys(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.mock.ExpectQuery(`SELECT key FROM state WHERE key LIKE \$1 AND key > \$2 AND tenant = \$3 AND \(expiredate IS NULL OR expiredate > NOW\(\)\) ORDER BY key LIMIT 3`).
+		WithArgs(`app\_1||%`, "app_1||a", "").
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("app_1||b").AddRow("app_1||c").AddRow("app_1||d"))
+
+	// Act
+	resp, err := m.pgDba.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app_1||", Limit: 2, Token: "app_1||a"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app_1||b", "app_1||c"}, resp.Keys)
+	assert.Equal(t, "app_1||c", resp.Token)
+}
+
+func TestEnsureNotifyTrigger(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.schema = "team1"
+	m.mock.ExpectExec(`CREATE OR REPLACE FUNCTION team1.state_notify(.|\n)*pg_notify\('team1_state_changes'`).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec(`CREATE TRIGGER state_notify AFTER INSERT OR UPDATE OR DELETE ON team1.state\s+FOR EACH ROW EXECUTE PROCEDURE team1.state_notify\(\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Act
+	err := m.pgDba.ensureNotifyTrigger(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestHandleNotification(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.keyPrefix = "tenant1"
+	m.mock.ExpectQuery(`SELECT value, isbinary, compressedvalue, xmin as etag, .+ AS ttl FROM state WHERE key = \$1 AND tenant = \$2`).
+		WithArgs("order||1", "tenant1").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary", "compressedvalue", "etag", "ttl"}).AddRow(`{"id":1}`, false, nil, 42, 30))
+	var events []*state.WatchEvent
+	handler := func(_ context.Context, e *state.WatchEvent) error {
+		events = append(events, e)
+
+		return nil
+	}
+
+	// Act
+	m.pgDba.handleNotification(context.Background(), `{"tenant":"tenant1","key":"order||1","operation":"upsert"}`, "order||", handler)
+	m.pgDba.handleNotification(context.Background(), `{"tenant":"tenant1","key":"customer||1","operation":"upsert"}`, "order||", handler)
+	m.pgDba.handleNotification(context.Background(), `{"tenant":"tenant2","key":"order||1","operation":"upsert"}`, "order||", handler)
+	m.pgDba.handleNotification(context.Background(), `{"tenant":"tenant1","key":"order||1","operation":"delete"}`, "order||", handler)
+	m.pgDba.handleNotification(context.Background(), `not json`, "order||", handler)
+
+	// Assert
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+	assert.Len(t, events, 2)
+	assert.Equal(t, &state.WatchEvent{Key: "order||1", Operation: state.Upsert, Value: []byte(`{"id":1}`), ETag: ptr.String("42")}, events[0])
+	assert.Equal(t, &state.WatchEvent{Key: "order||1", Operation: state.Delete}, events[1])
+}
+
+func TestParseTableMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		p := &postgresDBAccess{}
+		err := p.parseTableMetadata(map[string]string{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "state", p.qualifiedTableName())
+		assert.Equal(t, "", p.keyPrefix)
+		assert.Equal(t, "state_changes", p.notifyChannel())
+	})
+
+	t.Run("custom table, schema and key prefix", func(t *testing.T) {
+		p := &postgresDBAccess{}
+		err := p.parseTableMetadata(map[string]string{
+			tableNameKey: "Orders_State",
+			schemaKey:    "team1",
+			keyPrefixKey: "tenant-1",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "team1.orders_state", p.qualifiedTableName())
+		assert.Equal(t, "tenant-1", p.keyPrefix)
+		assert.Equal(t, "team1_orders_state_changes", p.notifyChannel())
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{tableNameKey: "state; DROP TABLE state"},
+			{tableNameKey: "1state"},
+			{schemaKey: "public.state"},
+			{schemaKey: `"team1"`},
+		} {
+			p := &postgresDBAccess{}
+			assert.NotNil(t, p.parseTableMetadata(props), props)
+		}
+	})
+}
+
+func TestEnsureStateTable(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.ctx = context.Background()
+	m.pgDba.schema = "team1"
+	m.pgDba.metadataTableName = "dapr_metadata"
+	m.mock.ExpectExec("CREATE SCHEMA IF NOT EXISTS team1").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectBegin()
+	m.mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS team1.dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectQuery("SELECT value FROM team1.dapr_metadata").
+		WithArgs("migrations-team1.state").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+	m.mock.ExpectExec("ALTER TABLE team1.state ADD COLUMN IF NOT EXISTS expiredate").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectQuery("SELECT EXISTS \\(SELECT FROM information_schema.columns").
+		WithArgs("team1", "state", "tenant").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	m.mock.ExpectQuery("SELECT conname FROM pg_constraint WHERE conrelid = \\$1::regclass AND contype = 'p'").
+		WithArgs("team1.state").
+		WillReturnRows(sqlmock.NewRows([]string{"conname"}).AddRow("state_key"))
+	m.mock.ExpectExec(`ALTER TABLE team1.state ADD COLUMN tenant (.|\n)*DROP CONSTRAINT "state_key", ADD PRIMARY KEY \(tenant, key\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("ALTER TABLE team1.state ADD COLUMN IF NOT EXISTS compressedvalue bytea").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("INSERT INTO team1.dapr_metadata").
+		WithArgs("migrations-team1.state", "4").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectCommit()
+
+	// Act
+	err := m.pgDba.ensureStateTable()
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestParseIndexedProperties(t *testing.T) {
+	t.Run("valid properties", func(t *testing.T) {
+		props, err := parseIndexedProperties(map[string]string{
+			indexedPropertiesKey: `[{"property": "person.org"}, {"property": "person.id", "type": "numeric"}]`,
+		}, "state")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []IndexedProperty{
+			{Property: "person.org", Type: "text"},
+			{Property: "person.id", Type: "numeric"},
+		}, props)
+		assert.Equal(t, "state_person_org_idx", indexName("state", props[0]))
+		assert.Equal(t, "value->'person'->>'org'", indexExpression(props[0]))
+		assert.Equal(t, "state_person_id_num_idx", indexName("state", props[1]))
+		assert.Equal(t, "(value->'person'->>'id')::numeric", indexExpression(props[1]))
+	})
+
+	t.Run("no properties", func(t *testing.T) {
+		props, err := parseIndexedProperties(map[string]string{}, "state")
+
+		assert.Nil(t, err)
+		assert.Empty(t, props)
+	})
+
+	t.Run("invalid properties", func(t *testing.T) {
+		for _, val := range []string{
+			`{"property": "person.org"}`,
+			`[{"property": "person..org"}]`,
+			`[{"property": "person'); DROP TABLE state; --"}]`,
+			`[{"property": "person.org", "type": "float"}]`,
+			`[{"property": "` + strings.Repeat("a", 60) + `"}]`,
+		} {
+			_, err := parseIndexedProperties(map[string]string{indexedPropertiesKey: val}, "state")
+			assert.NotNil(t, err, val)
+		}
+	})
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.ctx = context.Background()
+	m.pgDba.schema = "team1"
+	m.pgDba.indexedProperties = []IndexedProperty{
+		{Property: "state", Type: "text"},
+		{Property: "person.id", Type: "numeric"},
+	}
+	m.mock.ExpectQuery(`SELECT NOT indisvalid FROM pg_index WHERE indexrelid = to_regclass\(\$1\)`).
+		WithArgs("team1.state_state_idx").
+		WillReturnRows(sqlmock.NewRows([]string{"invalid"}))
+	m.mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS state_state_idx ON team1.state \(tenant, \(value->>'state'\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// the index of a failed concurrent build is rebuilt
+	m.mock.ExpectQuery(`SELECT NOT indisvalid FROM pg_index WHERE indexrelid = to_regclass\(\$1\)`).
+		WithArgs("team1.state_person_id_num_idx").
+		WillReturnRows(sqlmock.NewRows([]string{"invalid"}).AddRow(true))
+	m.mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS team1.state_person_id_num_idx`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS state_person_id_num_idx ON team1.state \(tenant, \(\(value->'person'->>'id'\)::numeric\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Act
+	err := m.pgDba.ensureIndexes()
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestQueryAggregate(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"aggregate":{"groupBy":["state"],"aggregations":[{"op":"count"},{"op":"sum","key":"amount"}]}}`), &qq)
+	assert.Nil(t, err)
+	m.mock.ExpectQuery(`SELECT value->'state', COUNT\(\*\), SUM\(\(value->>'amount'\)::numeric\)::float8 FROM state WHERE tenant = \$1 AND (.*) GROUP BY value->'state'`).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"state", "count", "sum"}).
+			AddRow([]byte(`"CA"`), 2, 12.5).
+			AddRow(nil, 1, nil))
+
+	// Act
+	res, err := m.pgDba.Query(context.Background(), &state.QueryRequest{Query: qq})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Empty(t, res.Results)
+	assert.Equal(t, []state.AggregationResult{
+		{Group: map[string]interface{}{"state": "CA"}, Values: map[string]interface{}{"count": int64(2), "sum(amount)": 12.5}},
+		{Group: map[string]interface{}{"state": nil}, Values: map[string]interface{}{"count": int64(1), "sum(amount)": nil}},
+	}, res.Aggregations)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestQueryCompressed(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	compressor, err := utils.NewCompressor(map[string]string{utils.CompressionKey: utils.CompressionGzip})
+	assert.Nil(t, err)
+	doc, _ := compressor.Compress([]byte(`{"state":"CA"}`))
+	binary, _ := compressor.Compress([]byte("hello"))
+	m.mock.ExpectQuery(`SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = \$1`).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "isbinary", "compressedvalue", "etag"}).
+			AddRow("key1", []byte(`{"state":"WA"}`), false, nil, 10).
+			AddRow("key2", []byte("null"), false, doc, 11).
+			AddRow("key3", []byte("null"), true, binary, 12).
+			AddRow("key4", []byte("null"), false, []byte{0x00, 'd', 'c', 'g', 1}, 13))
+
+	// Act
+	res, err := m.pgDba.Query(context.Background(), &state.QueryRequest{})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, res.Results, 4)
+	assert.Equal(t, `{"state":"WA"}`, string(res.Results[0].Data))
+	assert.Equal(t, `{"state":"CA"}`, string(res.Results[1].Data))
+	assert.Equal(t, `"aGVsbG8="`, string(res.Results[2].Data))
+	assert.NotEmpty(t, res.Results[3].Error)
+	assert.Nil(t, res.Results[3].ETag)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestTenantIsolation(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.schema = "team1"
+	m.pgDba.keyPrefix = "tenant1"
+	m.mock.ExpectExec(`INSERT INTO team1.state \(tenant, key, value, isbinary, compressedvalue, expiredate\) VALUES \(\$5, \$1`).
+		WithArgs("key1", `"value1"`, false, nil, "tenant1", []byte(nil)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.mock.ExpectExec(`DELETE FROM team1.state WHERE key = \$1 AND tenant = \$2`).
+		WithArgs("key1", "tenant1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Act
+	err1 := m.pgDba.Set(context.Background(), &state.SetRequest{Key: "key1", Value: "value1"})
+	err2 := m.pgDba.Delete(context.Background(), &state.DeleteRequest{Key: "key1"})
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+// arrayConverter passes the string arrays through like the pgx driver does,
+// and converts the other arguments like database/sql.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if a, ok := v.([]string); ok {
+		return a, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
 func mockDatabase(t *testing.T) (*mocks, error) {
 	logger := logger.NewLogger("test")
 
-	db, mock, err := sqlmock.New()
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 
 	dba := &postgresDBAccess{
-		logger: logger,
-		db:     db,
+		logger:    logger,
+		db:        db,
+		tableName: defaultTableName,
 	}
 
 	return &mocks{
@@ -14,6 +14,8 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
+
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
@@ -36,7 +38,7 @@ func NewPostgreSQLStateStore(logger logger.Logger) *PostgreSQL {
 // This unexported constructor allows injecting a dbAccess instance for unit testing.
 func newPostgreSQLStateStore(logger logger.Logger, dba dbAccess) *PostgreSQL {
 	return &PostgreSQL{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureListKeys, state.FeatureTTL},
 		logger:   logger,
 		dbaccess: dba,
 	}
@@ -54,43 +56,98 @@ func (p *PostgreSQL) Features() []state.Feature {
 
 // Delete removes an entity from the store.
 func (p *PostgreSQL) Delete(req *state.DeleteRequest) error {
-	return p.dbaccess.Delete(req)
+	return p.DeleteWithContext(context.Background(), req)
+}
+
+// DeleteWithContext removes an entity from the store, honoring the cancellation of ctx.
+func (p *PostgreSQL) DeleteWithContext(ctx context.Context, req *state.DeleteRequest) error {
+	return p.dbaccess.Delete(ctx, req)
 }
 
 // BulkDelete removes multiple entries from the store.
 func (p *PostgreSQL) BulkDelete(req []state.DeleteRequest) error {
-	return p.dbaccess.BulkDelete(req)
+	return p.BulkDeleteWithContext(context.Background(), req)
+}
+
+// BulkDeleteWithContext removes multiple entries from the store, honoring the cancellation of ctx.
+func (p *PostgreSQL) BulkDeleteWithContext(ctx context.Context, req []state.DeleteRequest) error {
+	return p.dbaccess.BulkDelete(ctx, req)
 }
 
 // Get returns an entity from store.
 func (p *PostgreSQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	return p.dbaccess.Get(req)
+	return p.GetWithContext(context.Background(), req)
+}
+
+// GetWithContext returns an entity from store, honoring the cancellation of ctx.
+func (p *PostgreSQL) GetWithContext(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	return p.dbaccess.Get(ctx, req)
 }
 
 // BulkGet performs a bulks get operations.
 func (p *PostgreSQL) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
-	// TODO: replace with ExecuteMulti for performance
-	return false, nil, nil
+	return p.BulkGetWithContext(context.Background(), req)
+}
+
+// BulkGetWithContext performs a bulks get operations, honoring the cancellation of ctx.
+func (p *PostgreSQL) BulkGetWithContext(ctx context.Context, req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	res, err := p.dbaccess.BulkGet(ctx, req)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return true, res, nil
 }
 
 // Set adds/updates an entity on store.
 func (p *PostgreSQL) Set(req *state.SetRequest) error {
-	return p.dbaccess.Set(req)
+	return p.SetWithContext(context.Background(), req)
+}
+
+// SetWithContext adds/updates an entity on store, honoring the cancellation of ctx.
+func (p *PostgreSQL) SetWithContext(ctx context.Context, req *state.SetRequest) error {
+	return p.dbaccess.Set(ctx, req)
 }
 
 // BulkSet adds/updates multiple entities on store.
 func (p *PostgreSQL) BulkSet(req []state.SetRequest) error {
-	return p.dbaccess.BulkSet(req)
+	return p.BulkSetWithContext(context.Background(), req)
+}
+
+// BulkSetWithContext adds/updates multiple entities on store, honoring the cancellation of ctx.
+func (p *PostgreSQL) BulkSetWithContext(ctx context.Context, req []state.SetRequest) error {
+	return p.dbaccess.BulkSet(ctx, req)
 }
 
 // Multi handles multiple transactions. Implements TransactionalStore.
 func (p *PostgreSQL) Multi(request *state.TransactionalStateRequest) error {
-	return p.dbaccess.ExecuteMulti(request)
+	return p.MultiWithContext(context.Background(), request)
+}
+
+// MultiWithContext handles multiple transactions, honoring the cancellation of ctx.
+func (p *PostgreSQL) MultiWithContext(ctx context.Context, request *state.TransactionalStateRequest) error {
+	return p.dbaccess.ExecuteMulti(ctx, request)
 }
 
 // Query executes a query against store.
 func (p *PostgreSQL) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
-	return p.dbaccess.Query(req)
+	return p.QueryWithContext(context.Background(), req)
+}
+
+// QueryWithContext executes a query against store, honoring the cancellation of ctx.
+func (p *PostgreSQL) QueryWithContext(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	return p.dbaccess.Query(ctx, req)
+}
+
+// ListKeys returns the keys starting with req.Prefix in lexical order.
+func (p *PostgreSQL) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	return p.dbaccess.ListKeys(context.Background(), req)
+}
+
+// Watch delivers the changes of the keys starting with req.KeyPrefix to the handler
+// until ctx is canceled.
+func (p *PostgreSQL) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	return p.dbaccess.Watch(ctx, req, handler)
 }
 
 // Close implements io.Closer.
@@ -13,11 +13,13 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -137,6 +139,72 @@ func TestPostgreSQLIntegration(t *testing.T) {
 		t.Parallel()
 		multiWithSetOnly(t, pgs)
 	})
+
+	t.Run("Set with ttl expires the item", func(t *testing.T) {
+		t.Parallel()
+		setWithTTLExpiresTheItem(t, pgs)
+	})
+
+	t.Run("Watch receives changes", func(t *testing.T) {
+		t.Parallel()
+		watchReceivesChanges(t, pgs)
+	})
+}
+
+// setWithTTLExpiresTheItem validates that an item is not returned once its ttl has elapsed.
+func setWithTTLExpiresTheItem(t *testing.T, pgs *PostgreSQL) {
+	key := randomKey()
+	setReq := &state.SetRequest{
+		Key:      key,
+		Value:    &fakeItem{Color: "orange"},
+		Metadata: map[string]string{"ttlInSeconds": "1"},
+	}
+	err := pgs.Set(setReq)
+	assert.Nil(t, err)
+
+	response, _ := getItem(t, pgs, key)
+	assert.NotNil(t, response.Data)
+
+	time.Sleep(2 * time.Second)
+	response, _ = getItem(t, pgs, key)
+	assert.Nil(t, response.Data)
+
+	err = pgs.dbaccess.(*postgresDBAccess).deleteExpiredValues(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, storeItemExists(t, key))
+}
+
+// watchReceivesChanges validates that set and delete operations are delivered to a watcher.
+func watchReceivesChanges(t *testing.T, pgs *PostgreSQL) {
+	key := randomKey()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *state.WatchEvent, 10)
+	err := pgs.Watch(ctx, &state.WatchRequest{KeyPrefix: key}, func(_ context.Context, e *state.WatchEvent) error {
+		events <- e
+
+		return nil
+	})
+	assert.Nil(t, err)
+
+	value := &fakeItem{Color: "blue"}
+	setItem(t, pgs, key, value, nil)
+	getResponse, _ := getItem(t, pgs, key)
+	deleteItem(t, pgs, key, nil)
+
+	for _, op := range []state.OperationType{state.Upsert, state.Delete} {
+		select {
+		case e := <-events:
+			assert.Equal(t, key, e.Key)
+			assert.Equal(t, op, e.Operation)
+			if op == state.Upsert {
+				assert.Equal(t, getResponse.ETag, e.ETag)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", op)
+		}
+	}
 }
 
 // setGetUpdateDeleteOneItem validates setting one item, getting it, and deleting it.
@@ -157,30 +225,51 @@ func setGetUpdateDeleteOneItem(t *testing.T, pgs *PostgreSQL) {
 	deleteItem(t, pgs, key, getResponse.ETag)
 }
 
-// testCreateTable tests the ability to create the state table.
+// testCreateTable tests the ability to create the state table, in the current schema and in another schema.
 func testCreateTable(t *testing.T, dba *postgresDBAccess) {
-	tableName := "test_state"
+	for _, schema := range []string{"", "test_schema"} {
+		tableDba := *dba
+		tableDba.schema = schema
+		tableDba.tableName = "test_state"
+		tableDba.metadataTableName = "dapr_metadata"
+
+		// Drop the table if it already exists
+		exists, err := tableExists(tableDba.db, schema, tableDba.tableName)
+		assert.Nil(t, err)
+		if exists {
+			dropTable(t, &tableDba)
+		}
 
-	// Drop the table if it already exists
-	exists, err := tableExists(dba.db, tableName)
-	assert.Nil(t, err)
-	if exists {
-		dropTable(t, dba.db, tableName)
+		// Create the state table and test for its existence
+		err = tableDba.ensureStateTable()
+		assert.Nil(t, err)
+		exists, err = tableExists(tableDba.db, schema, tableDba.tableName)
+		assert.Nil(t, err)
+		assert.True(t, exists)
+
+		// Migrating an up to date table does nothing
+		err = tableDba.ensureStateTable()
+		assert.Nil(t, err)
+
+		// Drop the state table
+		dropTable(t, &tableDba)
 	}
+}
 
-	// Create the state table and test for its existence
-	err = dba.ensureStateTable(tableName)
-	assert.Nil(t, err)
-	exists, err = tableExists(dba.db, tableName)
-	assert.Nil(t, err)
-	assert.True(t, exists)
+// dropTable drops the state table and resets its schema version.
+// tableExists reports whether the table exists in schema, or in the current schema if schema is empty.
+func tableExists(db *sql.DB, schema string, tableName string) (bool, error) {
+	exists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM pg_tables WHERE schemaname = COALESCE(NULLIF($1, ''), current_schema()) AND tablename = $2)",
+		schema, tableName).Scan(&exists)
 
-	// Drop the state table
-	dropTable(t, dba.db, tableName)
+	return exists, err
 }
 
-func dropTable(t *testing.T, db *sql.DB, tableName string) {
-	_, err := db.Exec(fmt.Sprintf("DROP TABLE %s", tableName))
+func dropTable(t *testing.T, dba *postgresDBAccess) {
+	_, err := dba.db.Exec(fmt.Sprintf("DROP TABLE %s", dba.qualifiedTableName()))
+	assert.Nil(t, err)
+	_, err = dba.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE key = $1", dba.qualifiedName(dba.metadataTableName)), "migrations-"+dba.qualifiedTableName())
 	assert.Nil(t, err)
 }
 
@@ -557,7 +646,7 @@ func storeItemExists(t *testing.T, key string) bool {
 	defer db.Close()
 
 	exists := false
-	statement := fmt.Sprintf(`SELECT EXISTS (SELECT FROM %s WHERE key = $1)`, tableName)
+	statement := fmt.Sprintf(`SELECT EXISTS (SELECT FROM %s WHERE key = $1)`, defaultTableName)
 	err = db.QueryRow(statement, key).Scan(&exists)
 	assert.Nil(t, err)
 
@@ -569,7 +658,7 @@ func getRowData(t *testing.T, key string) (returnValue string, insertdate sql.Nu
 	assert.Nil(t, err)
 	defer db.Close()
 
-	err = db.QueryRow(fmt.Sprintf("SELECT value, insertdate, updatedate FROM %s WHERE key = $1", tableName), key).Scan(&returnValue, &insertdate, &updatedate)
+	err = db.QueryRow(fmt.Sprintf("SELECT value, insertdate, updatedate FROM %s WHERE key = $1", defaultTableName), key).Scan(&returnValue, &insertdate, &updatedate)
 	assert.Nil(t, err)
 
 	return returnValue, insertdate, updatedate
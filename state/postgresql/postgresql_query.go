@@ -13,7 +13,10 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -22,6 +25,7 @@ import (
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 )
 
@@ -30,12 +34,36 @@ type Query struct {
 	params []interface{}
 	limit  int
 	skip   *int64
+	table  string
+	tenant string
+
+	aggregate *query.Aggregate
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
 	return q.whereFieldEqual(f.Key, f.Val), nil
 }
 
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	return q.whereFieldCompare(f.Key, "<>", f.Val, false), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.whereFieldCompare(f.Key, ">", f.Val, true), nil
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.whereFieldCompare(f.Key, ">=", f.Val, true), nil
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.whereFieldCompare(f.Key, "<", f.Val, true), nil
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.whereFieldCompare(f.Key, "<=", f.Val, true), nil
+}
+
 func (q *Query) VisitIN(f *query.IN) (string, error) {
 	if len(f.Vals) == 0 {
 		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
@@ -52,6 +80,22 @@ func (q *Query) VisitIN(f *query.IN) (string, error) {
 	return str, nil
 }
 
+func (q *Query) VisitNIN(f *query.NIN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty NIN operator for key %q", f.Key)
+	}
+
+	str := "("
+	str += q.whereFieldCompare(f.Key, "<>", f.Vals[0], false)
+
+	for _, v := range f.Vals[1:] {
+		str += " AND "
+		str += q.whereFieldCompare(f.Key, "<>", v, false)
+	}
+	str += ")"
+	return str, nil
+}
+
 func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
 	var (
 		arr []string
@@ -66,11 +110,41 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NEQ:
+			if str, err = q.VisitNEQ(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GT:
+			if str, err = q.VisitGT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.GTE:
+			if str, err = q.VisitGTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LT:
+			if str, err = q.VisitLT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LTE:
+			if str, err = q.VisitLTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		case *query.IN:
 			if str, err = q.VisitIN(f); err != nil {
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NIN:
+			if str, err = q.VisitNIN(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		case *query.OR:
 			if str, err = q.VisitOR(f); err != nil {
 				return "", err
@@ -100,12 +174,24 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 }
 
 func (q *Query) Finalize(filters string, qq *query.Query) error {
-	q.query = fmt.Sprintf("SELECT key, value, xmin as etag FROM %s", tableName)
-
+	// The tenant is the last parameter, since the filters are numbered first.
+	q.params = append(q.params, q.tenant)
+	where := fmt.Sprintf("tenant = $%d AND %s", len(q.params), notExpired)
 	if filters != "" {
-		q.query += fmt.Sprintf(" WHERE %s", filters)
+		where += fmt.Sprintf(" AND %s", filters)
 	}
 
+	if qq.Aggregate != nil {
+		return q.finalizeAggregate(where, qq.Aggregate)
+	}
+
+	value, compressed := "value", "compressedvalue"
+	if len(qq.Fields) > 0 {
+		// Compressed values cannot be projected, so only their fields are returned.
+		value, compressed = projectFields(qq.Fields), "NULL"
+	}
+	q.query = fmt.Sprintf("SELECT key, %s, isbinary, %s, xmin as etag FROM %s WHERE %s", value, compressed, q.table, where)
+
 	if len(qq.Sort) > 0 {
 		q.query += " ORDER BY "
 
@@ -137,8 +223,103 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
-func (q *Query) execute(logger logger.Logger, db *sql.DB) ([]state.QueryItem, string, error) {
-	rows, err := db.Query(q.query, q.params...)
+// finalizeAggregate builds the query which computes the aggregations of each group.
+// The groups are selected as JSON values, followed by the aggregated values.
+func (q *Query) finalizeAggregate(where string, aggregate *query.Aggregate) error {
+	columns := make([]string, 0, len(aggregate.GroupBy)+len(aggregate.Aggregations))
+	groups := make([]string, len(aggregate.GroupBy))
+	for i, key := range aggregate.GroupBy {
+		groups[i] = translateFieldToJSON(key)
+		columns = append(columns, groups[i])
+	}
+	for _, agg := range aggregate.Aggregations {
+		if agg.Op == query.COUNT {
+			columns = append(columns, "COUNT(*)")
+		} else {
+			columns = append(columns, fmt.Sprintf("%s((%s)::numeric)::float8", strings.ToUpper(agg.Op), translateFieldToFilter(agg.Key)))
+		}
+	}
+
+	q.query = fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), q.table, where)
+	if len(groups) > 0 {
+		q.query += fmt.Sprintf(" GROUP BY %s", strings.Join(groups, ", "))
+	}
+	q.aggregate = aggregate
+
+	return nil
+}
+
+func (q *Query) executeAggregate(ctx context.Context, db *sql.DB) ([]state.AggregationResult, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := []state.AggregationResult{}
+	for rows.Next() {
+		groups := make([][]byte, len(q.aggregate.GroupBy))
+		values := make([]sql.NullFloat64, len(q.aggregate.Aggregations))
+		dest := make([]interface{}, 0, len(groups)+len(values))
+		for i := range groups {
+			dest = append(dest, &groups[i])
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		result := state.AggregationResult{
+			Values: make(map[string]interface{}, len(values)),
+		}
+		if len(groups) > 0 {
+			result.Group = make(map[string]interface{}, len(groups))
+		}
+		for i, key := range q.aggregate.GroupBy {
+			var v interface{}
+			if groups[i] != nil {
+				if err = json.Unmarshal(groups[i], &v); err != nil {
+					return nil, err
+				}
+			}
+			result.Group[key] = v
+		}
+		for i, agg := range q.aggregate.Aggregations {
+			switch {
+			case !values[i].Valid:
+				result.Values[agg.Alias] = nil
+			case agg.Op == query.COUNT:
+				result.Values[agg.Alias] = int64(values[i].Float64)
+			default:
+				result.Values[agg.Alias] = values[i].Float64
+			}
+		}
+		ret = append(ret, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// decodeQueryValue decompresses a value saved in the compressedvalue column.
+// Binary values are returned as a JSON string holding their base64 encoding,
+// like the uncompressed binary values.
+func decodeQueryValue(isBinary bool, compressed []byte) ([]byte, error) {
+	data, err := utils.Decompress(compressed)
+	if err != nil || !isBinary {
+		return data, err
+	}
+
+	return json.Marshal(base64.StdEncoding.EncodeToString(data))
+}
+
+func (q *Query) execute(ctx context.Context, logger logger.Logger, db *sql.DB) ([]state.QueryItem, string, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.params...)
 	if err != nil {
 		return nil, "", err
 	}
@@ -147,11 +328,13 @@ func (q *Query) execute(logger logger.Logger, db *sql.DB) ([]state.QueryItem, st
 	ret := []state.QueryItem{}
 	for rows.Next() {
 		var (
-			key  string
-			data []byte
-			etag int
+			key        string
+			data       []byte
+			isBinary   bool
+			compressed []byte
+			etag       int
 		)
-		if err = rows.Scan(&key, &data, &etag); err != nil {
+		if err = rows.Scan(&key, &data, &isBinary, &compressed, &etag); err != nil {
 			return nil, "", err
 		}
 		result := state.QueryItem{
@@ -159,6 +342,12 @@ func (q *Query) execute(logger logger.Logger, db *sql.DB) ([]state.QueryItem, st
 			Data: data,
 			ETag: ptr.String(strconv.Itoa(etag)),
 		}
+		if compressed != nil {
+			if result.Data, err = decodeQueryValue(isBinary, compressed); err != nil {
+				result.ETag = nil
+				result.Error = err.Error()
+			}
+		}
 		ret = append(ret, result)
 	}
 
@@ -204,9 +393,79 @@ func translateFieldToFilter(key string) string {
 	return filterField
 }
 
+// projectionNode is a field of a projection, holding the projected fields it contains.
+type projectionNode struct {
+	names    []string
+	children map[string]*projectionNode
+}
+
+func (n *projectionNode) add(path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if n.children == nil {
+		n.children = map[string]*projectionNode{}
+	}
+	child, ok := n.children[path[0]]
+	if !ok {
+		child = &projectionNode{}
+		n.children[path[0]] = child
+		n.names = append(n.names, path[0])
+	}
+	child.add(path[1:])
+}
+
+func (n *projectionNode) expression(field string) string {
+	if len(n.names) == 0 {
+		return field
+	}
+
+	args := make([]string, len(n.names))
+	for i, name := range n.names {
+		literal := "'" + strings.ReplaceAll(name, "'", "''") + "'"
+		args[i] = literal + ", " + n.children[name].expression(field+"->"+literal)
+	}
+
+	return fmt.Sprintf("jsonb_build_object(%s)", strings.Join(args, ", "))
+}
+
+// projectFields returns the expression building a document which only holds the fields,
+// at the same paths as in the value.
+func projectFields(fields []string) string {
+	root := &projectionNode{}
+	for _, field := range fields {
+		root.add(strings.Split(field, "."))
+	}
+
+	return root.expression("value")
+}
+
+// translateFieldToJSON returns the JSON value of the field, rather than its text.
+func translateFieldToJSON(key string) string {
+	field := "value"
+	for _, part := range strings.Split(key, ".") {
+		field += fmt.Sprintf("->'%s'", part)
+	}
+
+	return field
+}
+
 func (q *Query) whereFieldEqual(key string, value interface{}) string {
 	position := q.addParamValueAndReturnPosition(value)
 	filterField := translateFieldToFilter(key)
 	query := fmt.Sprintf("%s=$%v", filterField, position)
 	return query
 }
+
+// whereFieldCompare returns the comparison of a field with a value. When numeric is
+// set and the value is a number, the field is cast so that it is compared as a number
+// rather than as text.
+func (q *Query) whereFieldCompare(key string, op string, value interface{}, numeric bool) string {
+	position := q.addParamValueAndReturnPosition(value)
+	filterField := translateFieldToFilter(key)
+	if _, ok := value.(float64); ok && numeric {
+		filterField = fmt.Sprintf("(%s)::numeric", filterField)
+	}
+	query := fmt.Sprintf("%s%s$%v", filterField, op, position)
+	return query
+}
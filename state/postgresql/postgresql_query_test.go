@@ -30,27 +30,43 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 	}{
 		{
 			input: "../../tests/state/query/q1.json",
-			query: "SELECT key, value, xmin as etag FROM state LIMIT 2",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $1 AND (expiredate IS NULL OR expiredate > NOW()) LIMIT 2",
 		},
 		{
 			input: "../../tests/state/query/q2.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE value->>'state'=$1 LIMIT 2",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $2 AND (expiredate IS NULL OR expiredate > NOW()) AND value->>'state'=$1 LIMIT 2",
 		},
 		{
 			input: "../../tests/state/query/q2-token.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE value->>'state'=$1 LIMIT 2 OFFSET 2",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $2 AND (expiredate IS NULL OR expiredate > NOW()) AND value->>'state'=$1 LIMIT 2 OFFSET 2",
 		},
 		{
 			input: "../../tests/state/query/q3.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 AND (value->>'state'=$2 OR value->>'state'=$3)) ORDER BY value->>'state' DESC, value->'person'->>'name'",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $4 AND (expiredate IS NULL OR expiredate > NOW()) AND (value->'person'->>'org'=$1 AND (value->>'state'=$2 OR value->>'state'=$3)) ORDER BY value->>'state' DESC, value->'person'->>'name'",
 		},
 		{
 			input: "../../tests/state/query/q4.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 OR (value->'person'->>'org'=$2 AND (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $5 AND (expiredate IS NULL OR expiredate > NOW()) AND (value->'person'->>'org'=$1 OR (value->'person'->>'org'=$2 AND (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
 		},
 		{
 			input: "../../tests/state/query/q5.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 AND (value->'person'->>'name'=$2 OR (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $5 AND (expiredate IS NULL OR expiredate > NOW()) AND (value->'person'->>'org'=$1 AND (value->'person'->>'name'=$2 OR (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
+		},
+		{
+			input: "../../tests/state/query/q7.json",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $8 AND (expiredate IS NULL OR expiredate > NOW()) AND ((value->'person'->>'id')::numeric>=$1 AND (value->'person'->>'id')::numeric<$2 AND value->>'state'<>$3 AND ((value->'person'->>'id')::numeric>$4 OR (value->'person'->>'id')::numeric<=$5 OR (value->>'state'<>$6 AND value->>'state'<>$7))) ORDER BY value->'person'->>'id' LIMIT 2",
+		},
+		{
+			input: "../../tests/state/query/q8.json",
+			query: "SELECT key, value, isbinary, compressedvalue, xmin as etag FROM state WHERE tenant = $3 AND (expiredate IS NULL OR expiredate > NOW()) AND (value->>'created'>=$1 AND value->>'created'<$2)",
+		},
+		{
+			input: "../../tests/state/query/q9.json",
+			query: "SELECT value->'state', COUNT(*), AVG((value->'person'->>'age')::numeric)::float8, MAX((value->'person'->>'age')::numeric)::float8 FROM state WHERE tenant = $2 AND (expiredate IS NULL OR expiredate > NOW()) AND value->'person'->>'org'=$1 GROUP BY value->'state'",
+		},
+		{
+			input: "../../tests/state/query/q10.json",
+			query: "SELECT key, jsonb_build_object('state', value->'state', 'person', jsonb_build_object('org', value->'person'->'org', 'name', value->'person'->'name')), isbinary, NULL, xmin as etag FROM state WHERE tenant = $2 AND (expiredate IS NULL OR expiredate > NOW()) AND value->>'state'=$1 LIMIT 2",
 		},
 	}
 	for _, test := range tests {
@@ -60,7 +76,7 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 		err = json.Unmarshal(data, &qq)
 		assert.NoError(t, err)
 
-		q := &Query{}
+		q := &Query{table: "state"}
 		qbuilder := query.NewQueryBuilder(q)
 		err = qbuilder.BuildQuery(&qq)
 		assert.NoError(t, err)
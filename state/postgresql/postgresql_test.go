@@ -13,6 +13,7 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +33,7 @@ type fakeDBaccess struct {
 	setExecuted    bool
 	getExecuted    bool
 	deleteExecuted bool
+	watchExecuted  bool
 }
 
 func (m *fakeDBaccess) Init(metadata state.Metadata) error {
@@ -40,40 +42,54 @@ func (m *fakeDBaccess) Init(metadata state.Metadata) error {
 	return nil
 }
 
-func (m *fakeDBaccess) Set(req *state.SetRequest) error {
+func (m *fakeDBaccess) Set(ctx context.Context, req *state.SetRequest) error {
 	m.setExecuted = true
 
 	return nil
 }
 
-func (m *fakeDBaccess) Get(req *state.GetRequest) (*state.GetResponse, error) {
+func (m *fakeDBaccess) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
 	m.getExecuted = true
 
 	return nil, nil
 }
 
-func (m *fakeDBaccess) Delete(req *state.DeleteRequest) error {
+func (m *fakeDBaccess) BulkGet(ctx context.Context, req []state.GetRequest) ([]state.BulkGetResponse, error) {
+	return nil, nil
+}
+
+func (m *fakeDBaccess) Delete(ctx context.Context, req *state.DeleteRequest) error {
 	m.deleteExecuted = true
 
 	return nil
 }
 
-func (m *fakeDBaccess) BulkSet(req []state.SetRequest) error {
+func (m *fakeDBaccess) BulkSet(ctx context.Context, req []state.SetRequest) error {
 	return nil
 }
 
-func (m *fakeDBaccess) BulkDelete(req []state.DeleteRequest) error {
+func (m *fakeDBaccess) BulkDelete(ctx context.Context, req []state.DeleteRequest) error {
 	return nil
 }
 
-func (m *fakeDBaccess) ExecuteMulti(req *state.TransactionalStateRequest) error {
+func (m *fakeDBaccess) ExecuteMulti(ctx context.Context, req *state.TransactionalStateRequest) error {
 	return nil
 }
 
-func (m *fakeDBaccess) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+func (m *fakeDBaccess) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	return nil, nil
 }
 
+func (m *fakeDBaccess) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	return nil, nil
+}
+
+func (m *fakeDBaccess) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	m.watchExecuted = true
+
+	return nil
+}
+
 func (m *fakeDBaccess) Close() error {
 	return nil
 }
@@ -85,6 +101,17 @@ func TestInitRunsDBAccessInit(t *testing.T) {
 	assert.True(t, fake.initExecuted)
 }
 
+// Proves that Watch runs the dbaccess Watch method.
+func TestWatchRunsDBAccessWatch(t *testing.T) {
+	t.Parallel()
+	pgs, fake := createPostgreSQLWithFake(t)
+	err := pgs.Watch(context.Background(), &state.WatchRequest{}, func(context.Context, *state.WatchEvent) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, fake.watchExecuted)
+}
+
 func createPostgreSQLWithFake(t *testing.T) (*PostgreSQL, *fakeDBaccess) {
 	pgs := createPostgreSQL(t)
 	fake := pgs.dbaccess.(*fakeDBaccess)
@@ -35,11 +35,41 @@ func parseFilter(obj interface{}) (Filter, error) {
 			f := &EQ{}
 			err := f.Parse(v)
 
+			return f, err
+		case "NEQ":
+			f := &NEQ{}
+			err := f.Parse(v)
+
+			return f, err
+		case "GT":
+			f := &GT{}
+			err := f.Parse(v)
+
+			return f, err
+		case "GTE":
+			f := &GTE{}
+			err := f.Parse(v)
+
+			return f, err
+		case "LT":
+			f := &LT{}
+			err := f.Parse(v)
+
+			return f, err
+		case "LTE":
+			f := &LTE{}
+			err := f.Parse(v)
+
 			return f, err
 		case "IN":
 			f := &IN{}
 			err := f.Parse(v)
 
+			return f, err
+		case "NIN":
+			f := &NIN{}
+			err := f.Parse(v)
+
 			return f, err
 		case "AND":
 			f := &AND{}
@@ -80,6 +110,92 @@ func (f *EQ) Parse(obj interface{}) error {
 	return nil
 }
 
+type NEQ struct {
+	Key string
+	Val interface{}
+}
+
+func (f *NEQ) Parse(obj interface{}) (err error) {
+	f.Key, f.Val, err = parseKeyValue("NEQ", obj)
+
+	return
+}
+
+type GT struct {
+	Key string
+	Val interface{}
+}
+
+func (f *GT) Parse(obj interface{}) (err error) {
+	f.Key, f.Val, err = parseRangeKeyValue("GT", obj)
+
+	return
+}
+
+type GTE struct {
+	Key string
+	Val interface{}
+}
+
+func (f *GTE) Parse(obj interface{}) (err error) {
+	f.Key, f.Val, err = parseRangeKeyValue("GTE", obj)
+
+	return
+}
+
+type LT struct {
+	Key string
+	Val interface{}
+}
+
+func (f *LT) Parse(obj interface{}) (err error) {
+	f.Key, f.Val, err = parseRangeKeyValue("LT", obj)
+
+	return
+}
+
+type LTE struct {
+	Key string
+	Val interface{}
+}
+
+func (f *LTE) Parse(obj interface{}) (err error) {
+	f.Key, f.Val, err = parseRangeKeyValue("LTE", obj)
+
+	return
+}
+
+// parseKeyValue parses the single key/value pair of a comparison filter.
+func parseKeyValue(t string, obj interface{}) (string, interface{}, error) {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("%s filter must be a map", t)
+	}
+	if len(m) != 1 {
+		return "", nil, fmt.Errorf("%s filter must contain a single key/value pair", t)
+	}
+	for k, v := range m {
+		return k, v, nil
+	}
+
+	return "", nil, nil
+}
+
+// parseRangeKeyValue parses the single key/value pair of a range filter.
+// Range filters only compare numbers or strings.
+func parseRangeKeyValue(t string, obj interface{}) (string, interface{}, error) {
+	k, v, err := parseKeyValue(t, obj)
+	if err != nil {
+		return "", nil, err
+	}
+	switch v.(type) {
+	case float64, string:
+		return k, v, nil
+	default:
+		return "", nil, fmt.Errorf("%s filter value must be a number or a string", t)
+	}
+}
+
 type IN struct {
 	Key  string
 	Vals []interface{}
@@ -103,6 +219,29 @@ func (f *IN) Parse(obj interface{}) error {
 	return nil
 }
 
+type NIN struct {
+	Key  string
+	Vals []interface{}
+}
+
+func (f *NIN) Parse(obj interface{}) error {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("NIN filter must be a map")
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("NIN filter must contain a single key/value pair")
+	}
+	for k, v := range m {
+		f.Key = k
+		if f.Vals, ok = v.([]interface{}); !ok {
+			return fmt.Errorf("NIN filter value must be an array")
+		}
+	}
+
+	return nil
+}
+
 type AND struct {
 	Filters []Filter
 }
@@ -16,14 +16,26 @@ package query
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 const (
-	FILTER = "filter"
-	SORT   = "sort"
-	PAGE   = "page"
-	ASC    = "ASC"
-	DESC   = "DESC"
+	FILTER    = "filter"
+	SORT      = "sort"
+	PAGE      = "page"
+	AGGREGATE = "aggregate"
+	FIELDS    = "fields"
+	ASC       = "ASC"
+	DESC      = "DESC"
+)
+
+// Aggregation operators.
+const (
+	COUNT = "count"
+	SUM   = "sum"
+	MIN   = "min"
+	MAX   = "max"
+	AVG   = "avg"
 )
 
 type Sorting struct {
@@ -36,10 +48,32 @@ type Pagination struct {
 	Token string `json:"token,omitempty"`
 }
 
+// Aggregation computes a value over the documents matching the filter.
+// All the operators but count apply to numeric fields.
+type Aggregation struct {
+	Op  string `json:"op"`
+	Key string `json:"key,omitempty"`
+	// Alias is the name of the aggregated value in the results. It defaults to
+	// the operator for count, and to the operator and the key otherwise, e.g. "sum(person.age)".
+	Alias string `json:"alias,omitempty"`
+}
+
+// Aggregate replaces the documents matching the filter by aggregated values,
+// computed over all the documents, or for each group of documents with the same
+// values of the GroupBy keys.
+type Aggregate struct {
+	GroupBy      []string      `json:"groupBy,omitempty"`
+	Aggregations []Aggregation `json:"aggregations"`
+}
+
 type Query struct {
-	Filters map[string]interface{} `json:"filter"`
-	Sort    []Sorting              `json:"sort"`
-	Page    Pagination             `json:"page"`
+	Filters   map[string]interface{} `json:"filter"`
+	Sort      []Sorting              `json:"sort"`
+	Page      Pagination             `json:"page"`
+	Aggregate *Aggregate             `json:"aggregate,omitempty"`
+	// Fields are the paths of the fields returned for each document, e.g. "person.org".
+	// The whole documents are returned when it is empty.
+	Fields []string `json:"fields,omitempty"`
 
 	// derived from Filters
 	Filter Filter
@@ -48,8 +82,20 @@ type Query struct {
 type Visitor interface {
 	// returns "equal" expression
 	VisitEQ(*EQ) (string, error)
+	// returns "not equal" expression
+	VisitNEQ(*NEQ) (string, error)
+	// returns "greater than" expression
+	VisitGT(*GT) (string, error)
+	// returns "greater than or equal" expression
+	VisitGTE(*GTE) (string, error)
+	// returns "less than" expression
+	VisitLT(*LT) (string, error)
+	// returns "less than or equal" expression
+	VisitLTE(*LTE) (string, error)
 	// returns "in" expression
 	VisitIN(*IN) (string, error)
+	// returns "not in" expression
+	VisitNIN(*NIN) (string, error)
 	// returns "and" expression
 	VisitAND(*AND) (string, error)
 	// returns "or" expression
@@ -84,8 +130,20 @@ func (h *Builder) buildFilter(filter Filter) (string, error) {
 	switch f := filter.(type) {
 	case *EQ:
 		return h.visitor.VisitEQ(f)
+	case *NEQ:
+		return h.visitor.VisitNEQ(f)
+	case *GT:
+		return h.visitor.VisitGT(f)
+	case *GTE:
+		return h.visitor.VisitGTE(f)
+	case *LT:
+		return h.visitor.VisitLT(f)
+	case *LTE:
+		return h.visitor.VisitLTE(f)
 	case *IN:
 		return h.visitor.VisitIN(f)
+	case *NIN:
+		return h.visitor.VisitNIN(f)
 	case *OR:
 		return h.visitor.VisitOR(f)
 	case *AND:
@@ -135,6 +193,102 @@ func (q *Query) UnmarshalJSON(data []byte) error {
 			return err
 		}
 	}
+	// setting projection
+	if elem, ok := m[FIELDS]; ok {
+		arr, ok := elem.([]interface{})
+		if !ok {
+			return fmt.Errorf("%q must be an array", FIELDS)
+		}
+		jdata, err := json.Marshal(arr)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(jdata, &q.Fields); err != nil {
+			return err
+		}
+		if err = validateFields(q.Fields); err != nil {
+			return err
+		}
+	}
+	// setting aggregation
+	if elem, ok := m[AGGREGATE]; ok {
+		aggregate, ok := elem.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%q must be a map", AGGREGATE)
+		}
+		jdata, err := json.Marshal(aggregate)
+		if err != nil {
+			return err
+		}
+		q.Aggregate = &Aggregate{}
+		if err = json.Unmarshal(jdata, q.Aggregate); err != nil {
+			return err
+		}
+		if len(q.Sort) > 0 || q.Page.Limit > 0 || q.Page.Token != "" || len(q.Fields) > 0 {
+			return fmt.Errorf("%q cannot be combined with %q, %q or %q", AGGREGATE, SORT, PAGE, FIELDS)
+		}
+		if err = q.Aggregate.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateFields checks that the paths are valid, and that no path is the
+// prefix of another, so that the projected fields form a single document.
+func validateFields(fields []string) error {
+	for i, field := range fields {
+		for _, part := range strings.Split(field, ".") {
+			if part == "" {
+				return fmt.Errorf("invalid field %q", field)
+			}
+		}
+		for _, other := range fields[:i] {
+			if field == other || strings.HasPrefix(field, other+".") || strings.HasPrefix(other, field+".") {
+				return fmt.Errorf("field %q overlaps with field %q", field, other)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate checks the aggregations and sets their default aliases.
+func (a *Aggregate) validate() error {
+	if len(a.Aggregations) == 0 {
+		return fmt.Errorf("%q must have at least one aggregation", AGGREGATE)
+	}
+	for _, key := range a.GroupBy {
+		if key == "" {
+			return fmt.Errorf("%q keys cannot be empty", "groupBy")
+		}
+	}
+
+	aliases := make(map[string]struct{}, len(a.Aggregations))
+	for i := range a.Aggregations {
+		agg := &a.Aggregations[i]
+		switch agg.Op {
+		case COUNT:
+		case SUM, MIN, MAX, AVG:
+			if agg.Key == "" {
+				return fmt.Errorf("%s aggregation must have a key", agg.Op)
+			}
+		default:
+			return fmt.Errorf("unsupported aggregation operator %q", agg.Op)
+		}
+
+		if agg.Alias == "" {
+			agg.Alias = agg.Op
+			if agg.Key != "" {
+				agg.Alias = fmt.Sprintf("%s(%s)", agg.Op, agg.Key)
+			}
+		}
+		if _, ok := aliases[agg.Alias]; ok {
+			return fmt.Errorf("duplicate aggregation alias %q", agg.Alias)
+		}
+		aliases[agg.Alias] = struct{}{}
+	}
 
 	return nil
 }
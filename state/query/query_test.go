@@ -83,6 +83,61 @@ func TestQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "../../tests/state/query/q7.json",
+			query: Query{
+				Filters: nil,
+				Sort: []Sorting{
+					{Key: "person.id", Order: ""},
+				},
+				Page: Pagination{Limit: 2, Token: ""},
+				Filter: &AND{
+					Filters: []Filter{
+						&GTE{Key: "person.id", Val: float64(100)},
+						&LT{Key: "person.id", Val: float64(200)},
+						&NEQ{Key: "state", Val: "CA"},
+						&OR{
+							Filters: []Filter{
+								&GT{Key: "person.id", Val: float64(150)},
+								&LTE{Key: "person.id", Val: float64(110)},
+								&NIN{Key: "state", Vals: []interface{}{"WA", "OR"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "../../tests/state/query/q8.json",
+			query: Query{
+				Filters: nil,
+				Sort:    nil,
+				Page:    Pagination{Limit: 0, Token: ""},
+				Filter: &AND{
+					Filters: []Filter{
+						&GTE{Key: "created", Val: "2021-01-01"},
+						&LT{Key: "created", Val: "2022-01-01"},
+					},
+				},
+			},
+		},
+		{
+			input: "../../tests/state/query/q9.json",
+			query: Query{
+				Filters: nil,
+				Sort:    nil,
+				Page:    Pagination{Limit: 0, Token: ""},
+				Filter:  &EQ{Key: "person.org", Val: "A"},
+				Aggregate: &Aggregate{
+					GroupBy: []string{"state"},
+					Aggregations: []Aggregation{
+						{Op: "count", Alias: "count"},
+						{Op: "avg", Key: "person.age", Alias: "averageAge"},
+						{Op: "max", Key: "person.age", Alias: "max(person.age)"},
+					},
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		data, err := ioutil.ReadFile(test.input)
@@ -93,3 +148,46 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, test.query, q)
 	}
 }
+
+func TestRangeFilterValue(t *testing.T) {
+	var q Query
+	err := json.Unmarshal([]byte(`{"filter":{"GT":{"person.id":[1,2]}}}`), &q)
+	assert.EqualError(t, err, "GT filter value must be a number or a string")
+
+	err = json.Unmarshal([]byte(`{"filter":{"NIN":{"state":"CA"}}}`), &q)
+	assert.EqualError(t, err, "NIN filter value must be an array")
+}
+
+func TestAggregateValidation(t *testing.T) {
+	var q Query
+	err := json.Unmarshal([]byte(`{"aggregate":{"aggregations":[]}}`), &q)
+	assert.EqualError(t, err, `"aggregate" must have at least one aggregation`)
+
+	err = json.Unmarshal([]byte(`{"aggregate":{"aggregations":[{"op":"median","key":"age"}]}}`), &q)
+	assert.EqualError(t, err, `unsupported aggregation operator "median"`)
+
+	err = json.Unmarshal([]byte(`{"aggregate":{"aggregations":[{"op":"sum"}]}}`), &q)
+	assert.EqualError(t, err, "sum aggregation must have a key")
+
+	err = json.Unmarshal([]byte(`{"aggregate":{"aggregations":[{"op":"count"},{"op":"sum","key":"age","alias":"count"}]}}`), &q)
+	assert.EqualError(t, err, `duplicate aggregation alias "count"`)
+
+	err = json.Unmarshal([]byte(`{"page":{"limit":2},"aggregate":{"aggregations":[{"op":"count"}]}}`), &q)
+	assert.EqualError(t, err, `"aggregate" cannot be combined with "sort", "page" or "fields"`)
+}
+
+func TestFields(t *testing.T) {
+	var q Query
+	err := json.Unmarshal([]byte(`{"fields":["state","person.org","person.name"]}`), &q)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"state", "person.org", "person.name"}, q.Fields)
+
+	err = json.Unmarshal([]byte(`{"fields":"state"}`), &q)
+	assert.EqualError(t, err, `"fields" must be an array`)
+
+	err = json.Unmarshal([]byte(`{"fields":["person..org"]}`), &q)
+	assert.EqualError(t, err, `invalid field "person..org"`)
+
+	err = json.Unmarshal([]byte(`{"fields":["person.org","person"]}`), &q)
+	assert.EqualError(t, err, `field "person" overlaps with field "person.org"`)
+}
@@ -16,8 +16,10 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/agrea/ptr"
 	"github.com/go-redis/redis/v8"
@@ -30,6 +32,7 @@ import (
 	"github.com/dapr/components-contrib/state/query"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const (
@@ -97,6 +100,8 @@ type StateStore struct {
 	metadata       rediscomponent.Metadata
 	replicas       int
 	querySchemas   querySchemas
+	compressor     *utils.Compressor
+	retryConfig    retry.Config
 
 	features []state.Feature
 	logger   logger.Logger
@@ -109,7 +114,7 @@ type StateStore struct {
 func NewRedisStateStore(logger logger.Logger) *StateStore {
 	s := &StateStore{
 		json:     jsoniter.ConfigFastest,
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureListKeys, state.FeatureTTL},
 		logger:   logger,
 	}
 	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
@@ -139,6 +144,15 @@ func (r *StateStore) Init(metadata state.Metadata) error {
 		return err
 	}
 
+	if r.retryConfig, err = state.NewRetryConfig(metadata.Properties); err != nil {
+		return fmt.Errorf("redis store: %v", err)
+	}
+
+	// values saved as JSON documents are not compressed, so that they can be queried
+	if r.compressor, err = utils.NewCompressor(metadata.Properties); err != nil {
+		return fmt.Errorf("redis store: %v", err)
+	}
+
 	// check for query schemas
 	if r.querySchemas, err = parseQuerySchemas(m.QueryIndexes); err != nil {
 		return fmt.Errorf("redis store: error parsing query index schema: %v", err)
@@ -195,7 +209,7 @@ func (r *StateStore) parseConnectedSlaves(res string) int {
 	return 0
 }
 
-func (r *StateStore) deleteValue(req *state.DeleteRequest) error {
+func (r *StateStore) deleteValue(ctx context.Context, req *state.DeleteRequest) error {
 	if req.ETag == nil {
 		etag := "0"
 		req.ETag = &etag
@@ -207,7 +221,7 @@ func (r *StateStore) deleteValue(req *state.DeleteRequest) error {
 	} else {
 		delQuery = delDefaultQuery
 	}
-	_, err := r.client.Do(r.ctx, "EVAL", delQuery, 1, req.Key, *req.ETag).Result()
+	_, err := r.client.Do(ctx, "EVAL", delQuery, 1, req.Key, *req.ETag).Result()
 	if err != nil {
 		return state.NewETagError(state.ETagMismatch, err)
 	}
@@ -217,16 +231,23 @@ func (r *StateStore) deleteValue(req *state.DeleteRequest) error {
 
 // Delete performs a delete operation.
 func (r *StateStore) Delete(req *state.DeleteRequest) error {
+	return r.DeleteWithContext(r.ctx, req)
+}
+
+// DeleteWithContext performs a delete operation, honoring the cancellation of ctx.
+func (r *StateStore) DeleteWithContext(ctx context.Context, req *state.DeleteRequest) error {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
 	}
 
-	return state.DeleteWithOptions(r.deleteValue, req)
+	return state.DeleteWithRetries(func(req *state.DeleteRequest) error {
+		return r.deleteValue(ctx, req)
+	}, req, r.retryConfig)
 }
 
-func (r *StateStore) directGet(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "GET", req.Key).Result()
+func (r *StateStore) directGet(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	res, err := r.client.Do(ctx, "GET", req.Key).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -242,10 +263,10 @@ func (r *StateStore) directGet(req *state.GetRequest) (*state.GetResponse, error
 	}, nil
 }
 
-func (r *StateStore) getDefault(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "HGETALL", req.Key).Result() // Prefer values with ETags
+func (r *StateStore) getDefault(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	res, metadata, err := r.doWithTTL(ctx, req.Key, "HGETALL", req.Key) // Prefer values with ETags
 	if err != nil {
-		return r.directGet(req) // Falls back to original get for backward compats.
+		return r.directGet(ctx, req) // Falls back to original get for backward compats.
 	}
 	if res == nil {
 		return &state.GetResponse{}, nil
@@ -260,14 +281,20 @@ func (r *StateStore) getDefault(req *state.GetRequest) (*state.GetResponse, erro
 		return nil, err
 	}
 
+	bt, err := utils.Decompress([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+
 	return &state.GetResponse{
-		Data: []byte(data),
-		ETag: version,
+		Data:     bt,
+		ETag:     version,
+		Metadata: metadata,
 	}, nil
 }
 
-func (r *StateStore) getJSON(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "JSON.GET", req.Key).Result()
+func (r *StateStore) getJSON(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	res, metadata, err := r.doWithTTL(ctx, req.Key, "JSON.GET", req.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -298,18 +325,46 @@ func (r *StateStore) getJSON(req *state.GetRequest) (*state.GetResponse, error)
 	}
 
 	return &state.GetResponse{
-		Data: data,
-		ETag: version,
+		Data:     data,
+		ETag:     version,
+		Metadata: metadata,
 	}, nil
 }
 
+// doWithTTL runs the command args and reads the remaining time to live of key
+// in the same pipeline. It returns the result of the command, and the metadata
+// holding the time to live, which is nil if the key does not expire.
+func (r *StateStore) doWithTTL(ctx context.Context, key string, args ...interface{}) (interface{}, map[string]string, error) {
+	pipe := r.client.Pipeline()
+	cmd := pipe.Do(ctx, args...)
+	ttlCmd := pipe.TTL(ctx, key)
+	// The errors are read from the commands.
+	_, _ = pipe.Exec(ctx)
+
+	res, err := cmd.Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	ttl, err := ttlCmd.Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get ttl of key %s: %w", key, err)
+	}
+
+	return res, utils.WithTTLMetadata(nil, ttl), nil
+}
+
 // Get retrieves state from redis with a key.
 func (r *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	return r.GetWithContext(r.ctx, req)
+}
+
+// GetWithContext retrieves state from redis with a key, honoring the cancellation of ctx.
+func (r *StateStore) GetWithContext(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
 	if contentType, ok := req.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
-		return r.getJSON(req)
+		return r.getJSON(ctx, req)
 	}
 
-	return r.getDefault(req)
+	return r.getDefault(ctx, req)
 }
 
 type jsonEntry struct {
@@ -317,7 +372,7 @@ type jsonEntry struct {
 	Version *int        `json:"version,omitempty"`
 }
 
-func (r *StateStore) setValue(req *state.SetRequest) error {
+func (r *StateStore) setValue(ctx context.Context, req *state.SetRequest) error {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
@@ -347,34 +402,34 @@ func (r *StateStore) setValue(req *state.SetRequest) error {
 		bt, _ = utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
 	} else {
 		setQuery = setDefaultQuery
-		bt, _ = utils.Marshal(req.Value, r.json.Marshal)
+		bt, _ = r.compressor.Marshal(req.Value, r.json.Marshal)
 	}
 
-	err = r.client.Do(r.ctx, "EVAL", setQuery, 1, req.Key, ver, bt, firstWrite).Err()
+	err = r.client.Do(ctx, "EVAL", setQuery, 1, req.Key, ver, bt, firstWrite).Err()
 	if err != nil {
 		if req.ETag != nil {
 			return state.NewETagError(state.ETagMismatch, err)
 		}
 
-		return fmt.Errorf("failed to set key %s: %s", req.Key, err)
+		return fmt.Errorf("failed to set key %s: %w", req.Key, err)
 	}
 
 	if ttl != nil && *ttl > 0 {
-		_, err = r.client.Do(r.ctx, "EXPIRE", req.Key, *ttl).Result()
+		_, err = r.client.Do(ctx, "EXPIRE", req.Key, *ttl).Result()
 		if err != nil {
-			return fmt.Errorf("failed to set key %s ttl: %s", req.Key, err)
+			return fmt.Errorf("failed to set key %s ttl: %w", req.Key, err)
 		}
 	}
 
 	if ttl != nil && *ttl <= 0 {
-		_, err = r.client.Do(r.ctx, "PERSIST", req.Key).Result()
+		_, err = r.client.Do(ctx, "PERSIST", req.Key).Result()
 		if err != nil {
 			return fmt.Errorf("failed to persist key %s: %s", req.Key, err)
 		}
 	}
 
 	if req.Options.Consistency == state.Strong && r.replicas > 0 {
-		_, err = r.client.Do(r.ctx, "WAIT", r.replicas, 1000).Result()
+		_, err = r.client.Do(ctx, "WAIT", r.replicas, 1000).Result()
 		if err != nil {
 			return fmt.Errorf("redis waiting for %v replicas to acknowledge write, err: %s", r.replicas, err.Error())
 		}
@@ -385,11 +440,23 @@ func (r *StateStore) setValue(req *state.SetRequest) error {
 
 // Set saves state into redis.
 func (r *StateStore) Set(req *state.SetRequest) error {
-	return state.SetWithOptions(r.setValue, req)
+	return r.SetWithContext(r.ctx, req)
+}
+
+// SetWithContext saves state into redis, honoring the cancellation of ctx.
+func (r *StateStore) SetWithContext(ctx context.Context, req *state.SetRequest) error {
+	return state.SetWithRetries(func(req *state.SetRequest) error {
+		return r.setValue(ctx, req)
+	}, req, r.retryConfig)
 }
 
 // Multi performs a transactional operation. succeeds only if all operations succeed, and fails if one or more operations fail.
 func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
+	return r.MultiWithContext(r.ctx, request)
+}
+
+// MultiWithContext performs a transactional operation, honoring the cancellation of ctx.
+func (r *StateStore) MultiWithContext(ctx context.Context, request *state.TransactionalStateRequest) error {
 	var setQuery, delQuery string
 	var isJSON bool
 	if contentType, ok := request.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
@@ -421,14 +488,14 @@ func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 			if isJSON {
 				bt, _ = utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
 			} else {
-				bt, _ = utils.Marshal(req.Value, r.json.Marshal)
+				bt, _ = r.compressor.Marshal(req.Value, r.json.Marshal)
 			}
-			pipe.Do(r.ctx, "EVAL", setQuery, 1, req.Key, ver, bt)
+			pipe.Do(ctx, "EVAL", setQuery, 1, req.Key, ver, bt)
 			if ttl != nil && *ttl > 0 {
-				pipe.Do(r.ctx, "EXPIRE", req.Key, *ttl)
+				pipe.Do(ctx, "EXPIRE", req.Key, *ttl)
 			}
 			if ttl != nil && *ttl <= 0 {
-				pipe.Do(r.ctx, "PERSIST", req.Key)
+				pipe.Do(ctx, "PERSIST", req.Key)
 			}
 		} else if o.Operation == state.Delete {
 			req := o.Request.(state.DeleteRequest)
@@ -436,11 +503,11 @@ func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 				etag := "0"
 				req.ETag = &etag
 			}
-			pipe.Do(r.ctx, "EVAL", delQuery, 1, req.Key, *req.ETag)
+			pipe.Do(ctx, "EVAL", delQuery, 1, req.Key, *req.ETag)
 		}
 	}
 
-	_, err := pipe.Exec(r.ctx)
+	_, err := pipe.Exec(ctx)
 
 	return err
 }
@@ -515,6 +582,11 @@ func (r *StateStore) parseTTL(req *state.SetRequest) (*int, error) {
 
 // Query executes a query against store.
 func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	return r.QueryWithContext(r.ctx, req)
+}
+
+// QueryWithContext executes a query against store, honoring the cancellation of ctx.
+func (r *StateStore) QueryWithContext(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	indexName, ok := daprmetadata.TryGetQueryIndexName(req.Metadata)
 	if !ok {
 		return nil, fmt.Errorf("query index not found")
@@ -529,7 +601,7 @@ func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
-	data, token, err := q.execute(r.ctx, r.client)
+	data, token, err := q.execute(ctx, r.client)
 	if err != nil {
 		return &state.QueryResponse{}, err
 	}
@@ -540,6 +612,52 @@ func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error
 	}, nil
 }
 
+// ListKeys returns the keys starting with req.Prefix in lexical order.
+// The token is the last key of the previous page. As SCAN returns the keys
+// in no particular order, every page scans all the keys starting with
+// req.Prefix, on every master node of a cluster.
+func (r *StateStore) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	match := escapePattern(req.Prefix) + "*"
+	var lock sync.Mutex
+	keys := []string{}
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		iter := client.Scan(ctx, 0, match, 0).Iterator()
+		for iter.Next(ctx) {
+			if key := iter.Val(); key > req.Token {
+				lock.Lock()
+				keys = append(keys, key)
+				lock.Unlock()
+			}
+		}
+
+		return iter.Err()
+	}
+
+	var err error
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		// SCAN only visits the keys of the node which receives it.
+		err = cluster.ForEachMaster(r.ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(r.ctx, r.client)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	// SCAN may return a key more than once.
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+
+	return state.NewListKeysResponse(unique, req.Limit), nil
+}
+
 func (r *StateStore) Close() error {
 	r.cancel()
 
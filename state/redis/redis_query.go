@@ -15,6 +15,7 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -26,7 +27,10 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-var ErrMultipleSortBy error = errors.New("multiple SORTBY steps are not allowed. Sort multiple fields in a single step")
+var (
+	ErrMultipleSortBy  error = errors.New("multiple SORTBY steps are not allowed. Sort multiple fields in a single step")
+	ErrNonNumericRange error = errors.New("range operators are only supported on numeric values")
+)
 
 type Query struct {
 	schemaName string
@@ -34,6 +38,7 @@ type Query struct {
 	query      []interface{}
 	limit      int
 	offset     int64
+	fields     []string
 }
 
 func NewQuery(schemaName string, aliases map[string]string) *Query {
@@ -67,6 +72,48 @@ func (q *Query) VisitEQ(f *query.EQ) (string, error) {
 	}
 }
 
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	// -<equal expression>
+	str, err := q.VisitEQ(&query.EQ{Key: f.Key, Val: f.Val})
+	if err != nil {
+		return "", err
+	}
+
+	return "-" + str, nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	// numeric: @<key>:[(<val> +inf]
+	return q.visitRange(f.Key, f.Val, "(%v +inf")
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	// numeric: @<key>:[<val> +inf]
+	return q.visitRange(f.Key, f.Val, "%v +inf")
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	// numeric: @<key>:[-inf (<val>]
+	return q.visitRange(f.Key, f.Val, "-inf (%v")
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	// numeric: @<key>:[-inf <val>]
+	return q.visitRange(f.Key, f.Val, "-inf %v")
+}
+
+func (q *Query) visitRange(key string, val interface{}, format string) (string, error) {
+	if _, ok := val.(float64); !ok {
+		return "", ErrNonNumericRange
+	}
+	alias, err := q.getAlias(key)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("@%s:[%s]", alias, fmt.Sprintf(format, val)), nil
+}
+
 func (q *Query) VisitIN(f *query.IN) (string, error) {
 	// string:  @<key>:(<val1>|<val2>...)
 	// numeric: replace with OR
@@ -104,6 +151,22 @@ func (q *Query) VisitIN(f *query.IN) (string, error) {
 	}
 }
 
+func (q *Query) VisitNIN(f *query.NIN) (string, error) {
+	// -<in expression>
+	switch len(f.Vals) {
+	case 0:
+		return "", fmt.Errorf("empty NIN operator for key %q", f.Key)
+	case 1:
+		return q.VisitNEQ(&query.NEQ{Key: f.Key, Val: f.Vals[0]})
+	}
+	str, err := q.VisitIN(&query.IN{Key: f.Key, Vals: f.Vals})
+	if err != nil {
+		return "", err
+	}
+
+	return "-" + str, nil
+}
+
 func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
 	var (
 		arr []string
@@ -117,11 +180,41 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.NEQ:
+			if str, err = q.VisitNEQ(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.GT:
+			if str, err = q.VisitGT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.GTE:
+			if str, err = q.VisitGTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.LT:
+			if str, err = q.VisitLT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.LTE:
+			if str, err = q.VisitLTE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
 		case *query.IN:
 			if str, err = q.VisitIN(f); err != nil {
 				return "", err
 			}
 			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.NIN:
+			if str, err = q.VisitNIN(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
 		case *query.OR:
 			if str, err = q.VisitOR(f); err != nil {
 				return "", err
@@ -151,10 +244,14 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 }
 
 func (q *Query) Finalize(filters string, qq *query.Query) error {
+	if qq.Aggregate != nil {
+		return fmt.Errorf("aggregation queries are not supported")
+	}
 	if len(filters) == 0 {
 		filters = "*"
 	}
 	q.query = []interface{}{filters}
+	q.fields = qq.Fields
 
 	// sorting
 	if len(qq.Sort) > 0 {
@@ -190,8 +287,64 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// returnArgs returns the RETURN step of the search, which selects either the
+// whole documents or the projected fields, along with the versions.
+func (q *Query) returnArgs() []interface{} {
+	if len(q.fields) == 0 {
+		return []interface{}{"RETURN", "2", "$.data", "$.version"}
+	}
+
+	args := []interface{}{"RETURN", strconv.Itoa(len(q.fields) + 1), "$.version"}
+	for _, field := range q.fields {
+		args = append(args, "$.data."+field)
+	}
+
+	return args
+}
+
+// projectedDocument builds the document holding the projected fields returned by
+// a search, at the same paths as in the value. Missing fields are not returned.
+func projectedDocument(data []interface{}) ([]byte, *string, error) {
+	if len(data)%2 != 0 {
+		return nil, nil, fmt.Errorf("%#v is not a list of fields", data)
+	}
+
+	var etag *string
+	doc := map[string]interface{}{}
+	for i := 0; i < len(data); i += 2 {
+		name, _ := data[i].(string)
+		value, _ := data[i+1].(string)
+		if name == "$.version" {
+			etag = &value
+			continue
+		}
+
+		// Strings may be returned unquoted.
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value
+		}
+
+		parent := doc
+		parts := strings.Split(strings.TrimPrefix(name, "$.data."), ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[part] = child
+			}
+			parent = child
+		}
+		parent[parts[len(parts)-1]] = v
+	}
+
+	b, err := json.Marshal(doc)
+
+	return b, etag, err
+}
+
 func (q *Query) execute(ctx context.Context, client redis.UniversalClient) ([]state.QueryItem, string, error) {
-	query := append(append([]interface{}{"FT.SEARCH", q.schemaName}, q.query...), "RETURN", "2", "$.data", "$.version")
+	query := append(append([]interface{}{"FT.SEARCH", q.schemaName}, q.query...), q.returnArgs()...)
 	ret, err := client.Do(ctx, query...).Result()
 	if err != nil {
 		return nil, "", err
@@ -214,7 +367,12 @@ func (q *Query) execute(ctx context.Context, client redis.UniversalClient) ([]st
 		item := state.QueryItem{
 			Key: arr[i].(string),
 		}
-		if data, ok := arr[i+1].([]interface{}); ok && len(data) == 4 && data[0] == "$.data" && data[2] == "$.version" {
+		if data, ok := arr[i+1].([]interface{}); ok && len(q.fields) > 0 {
+			var err error
+			if item.Data, item.ETag, err = projectedDocument(data); err != nil {
+				item.Error = err.Error()
+			}
+		} else if ok && len(data) == 4 && data[0] == "$.data" && data[2] == "$.version" {
 			item.Data = []byte(data[1].(string))
 			etag := data[3].(string)
 			item.ETag = &etag
@@ -45,6 +45,14 @@ func TestMongoQuery(t *testing.T) {
 			input: "../../tests/state/query/q6.json",
 			query: []interface{}{"((@id:[123 123])|((@org:(B)) (((@id:[567 567])|(@id:[890 890])))))", "SORTBY", "id", "LIMIT", "0", "2"},
 		},
+		{
+			input: "../../tests/state/query/q7.json",
+			query: []interface{}{"((@id:[100 +inf]) (@id:[-inf (200]) (-@state:(CA)) ((@id:[(150 +inf])|(@id:[-inf 110])|(-@state:(WA|OR))))", "SORTBY", "id", "LIMIT", "0", "2"},
+		},
+		{
+			input: "../../tests/state/query/q8.json",
+			err:   ErrNonNumericRange,
+		},
 	}
 	for _, test := range tests {
 		data, err := ioutil.ReadFile(test.input)
@@ -54,7 +62,7 @@ func TestMongoQuery(t *testing.T) {
 		assert.NoError(t, err)
 
 		q := &Query{
-			aliases: map[string]string{"person.org": "org", "person.id": "id", "state": "state"},
+			aliases: map[string]string{"person.org": "org", "person.id": "id", "state": "state", "created": "created"},
 		}
 		qbuilder := query.NewQueryBuilder(q)
 		if err = qbuilder.BuildQuery(&qq); err != nil {
@@ -64,3 +72,25 @@ func TestMongoQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryFields(t *testing.T) {
+	data, err := ioutil.ReadFile("../../tests/state/query/q10.json")
+	assert.NoError(t, err)
+	var qq query.Query
+	err = json.Unmarshal(data, &qq)
+	assert.NoError(t, err)
+
+	q := &Query{
+		aliases: map[string]string{"state": "state"},
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	err = qbuilder.BuildQuery(&qq)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"@state:(CA)", "LIMIT", "0", "2"}, q.query)
+	assert.Equal(t, []interface{}{"RETURN", "4", "$.version", "$.data.state", "$.data.person.org", "$.data.person.name"}, q.returnArgs())
+
+	doc, etag, err := projectedDocument([]interface{}{"$.version", "3", "$.data.state", "CA", "$.data.person.org", `"A"`})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"state":"CA","person":{"org":"A"}}`, string(doc))
+	assert.Equal(t, "3", *etag)
+}
@@ -27,6 +27,7 @@ import (
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 )
 
@@ -435,6 +436,10 @@ func TestSetRequestWithTTL(t *testing.T) {
 		ttl, _ := ss.client.TTL(ss.ctx, "weapon100").Result()
 
 		assert.Equal(t, time.Duration(ttlInSeconds)*time.Second, ttl)
+
+		res, err := ss.Get(&state.GetRequest{Key: "weapon100"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"ttlInSeconds": "100"}, res.Metadata)
 	})
 
 	t.Run("TTL not specified", func(t *testing.T) {
@@ -446,6 +451,10 @@ func TestSetRequestWithTTL(t *testing.T) {
 		ttl, _ := ss.client.TTL(ss.ctx, "weapon200").Result()
 
 		assert.Equal(t, time.Duration(-1), ttl)
+
+		res, err := ss.Get(&state.GetRequest{Key: "weapon200"})
+		assert.Nil(t, err)
+		assert.Nil(t, res.Metadata)
 	})
 
 	t.Run("TTL Changed for Existing Key", func(t *testing.T) {
@@ -515,6 +524,66 @@ func TestTransactionalDeleteNoEtag(t *testing.T) {
 	assert.Equal(t, 0, len(vals))
 }
 
+func TestListKeys(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client: c,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	for _, key := range []string{"app||a", "app||b", "app||c", "other||a"} {
+		assert.NoError(t, ss.Set(&state.SetRequest{Key: key, Value: "v"}))
+	}
+
+	resp, err := ss.ListKeys(&state.ListKeysRequest{Prefix: "app||", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, &state.ListKeysResponse{Keys: []string{"app||a", "app||b"}, Token: "app||b"}, resp)
+
+	resp, err = ss.ListKeys(&state.ListKeysRequest{Prefix: "app||", Limit: 2, Token: resp.Token})
+	assert.NoError(t, err)
+	assert.Equal(t, &state.ListKeysResponse{Keys: []string{"app||c"}}, resp)
+
+	resp, err = ss.ListKeys(&state.ListKeysRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app||a", "app||b", "app||c", "other||a"}, resp.Keys)
+	assert.Empty(t, resp.Token)
+}
+
+func TestSetAndGetCompressed(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	compressor, err := utils.NewCompressor(map[string]string{utils.CompressionKey: utils.CompressionGzip})
+	assert.NoError(t, err)
+	ss := &StateStore{
+		client:     c,
+		json:       jsoniter.ConfigFastest,
+		logger:     logger.NewLogger("test"),
+		compressor: compressor,
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	// a value saved before compression was enabled
+	assert.NoError(t, c.Do(context.Background(), "HSET", "plain", "data", `"v1"`, "version", 1).Err())
+
+	assert.NoError(t, ss.Set(&state.SetRequest{Key: "compressed", Value: "v2"}))
+	raw, err := c.HGet(context.Background(), "compressed", "data").Result()
+	assert.NoError(t, err)
+	assert.True(t, utils.IsCompressed([]byte(raw)))
+
+	res, err := ss.Get(&state.GetRequest{Key: "compressed"})
+	assert.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(res.Data))
+
+	res, err = ss.Get(&state.GetRequest{Key: "plain"})
+	assert.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+}
+
 func setupMiniredis() (*miniredis.Miniredis, *redis.Client) {
 	s, err := miniredis.Run()
 	if err != nil {
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+const keySpaceChannelFormat = "__keyspace@%d__:"
+
+// Watch delivers the changes of the keys starting with req.KeyPrefix to the handler
+// until ctx is canceled or the store is closed. It relies on Redis keyspace
+// notifications, which must be enabled on the server for the generic, hash,
+// expired, evicted and module events, e.g. with
+// `CONFIG SET notify-keyspace-events KA`; without them no change is delivered.
+// The value and ETag of a change are read when the notification is received,
+// so a handler may observe a later version than the one which triggered it,
+// and values saved as JSON may be delivered more than once.
+func (r *StateStore) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	channelPrefix := fmt.Sprintf(keySpaceChannelFormat, r.clientSettings.DB)
+	p := r.client.PSubscribe(ctx, channelPrefix+escapePattern(req.KeyPrefix)+"*")
+	// wait for the subscription to be confirmed
+	if _, err := p.Receive(ctx); err != nil {
+		p.Close()
+
+		return fmt.Errorf("redis store: error subscribing to keyspace notifications: %w", err)
+	}
+
+	go func() {
+		defer p.Close()
+		ch := p.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				r.handleKeyspaceEvent(ctx, strings.TrimPrefix(msg.Channel, channelPrefix), msg.Payload, handler)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *StateStore) handleKeyspaceEvent(ctx context.Context, key string, event string, handler state.WatchHandler) {
+	e := &state.WatchEvent{Key: key}
+	switch event {
+	case "del", "json.del", "expired", "evicted":
+		e.Operation = state.Delete
+	case "hincrby", "json.set":
+		// the version is the last field written by the set scripts
+		var (
+			res *state.GetResponse
+			err error
+		)
+		if event == "json.set" {
+			res, err = r.getJSON(ctx, &state.GetRequest{Key: key})
+		} else {
+			res, err = r.getDefault(ctx, &state.GetRequest{Key: key})
+		}
+		if err != nil {
+			r.logger.Errorf("redis store: error reading changed key %s: %s", key, err)
+
+			return
+		}
+		if res.Data == nil {
+			// the key was deleted before it could be read; its delete event follows
+			return
+		}
+		e.Operation = state.Upsert
+		e.Value = res.Data
+		e.ETag = res.ETag
+	default:
+		return
+	}
+
+	if err := handler(ctx, e); err != nil {
+		r.logger.Errorf("redis store: error handling state change for key %s: %s", key, err)
+	}
+}
+
+// escapePattern escapes the glob-style special characters of a Redis pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
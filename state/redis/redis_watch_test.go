@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agrea/ptr"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestWatch(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+	defer ss.cancel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan *state.WatchEvent, 10)
+	err := ss.Watch(ctx, &state.WatchRequest{KeyPrefix: "order||"}, func(_ context.Context, e *state.WatchEvent) error {
+		events <- e
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	receive := func(t *testing.T) *state.WatchEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a state change")
+
+			return nil
+		}
+	}
+
+	// miniredis does not send keyspace notifications, so they are published by the test
+	require.NoError(t, ss.Set(&state.SetRequest{Key: "order||1", Value: "created"}))
+	s.Publish("__keyspace@0__:order||1", "hset")
+	s.Publish("__keyspace@0__:order||1", "hincrby")
+	s.Publish("__keyspace@0__:customer||1", "hincrby")
+	s.Publish("__keyspace@0__:order||1", "del")
+
+	e := receive(t)
+	assert.Equal(t, "order||1", e.Key)
+	assert.Equal(t, state.Upsert, e.Operation)
+	assert.Equal(t, `"created"`, string(e.Value))
+	assert.Equal(t, ptr.String("1"), e.ETag)
+
+	e = receive(t)
+	assert.Equal(t, "order||1", e.Key)
+	assert.Equal(t, state.Delete, e.Operation)
+	assert.Nil(t, e.Value)
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, "order||", escapePattern("order||"))
+	assert.Equal(t, `a\*b\?c\[d\]e\\`, escapePattern(`a*b?c[d]e\`))
+}
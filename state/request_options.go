@@ -14,7 +14,20 @@ limitations under the License.
 package state
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/kit/retry"
 )
 
 const (
@@ -22,6 +35,11 @@ const (
 	LastWrite  = "last-write"
 	Strong     = "strong"
 	Eventual   = "eventual"
+
+	// RetryMetadataPrefix is the prefix of the component metadata properties which
+	// configure the retries of set and delete operations, e.g. retryMaxRetries,
+	// retryInitialInterval, retryMaxInterval or retryRandomizationFactor.
+	RetryMetadataPrefix = "retry"
 )
 
 // CheckRequestOptions checks if request options use supported keywords.
@@ -67,6 +85,19 @@ func validateConsistencyOption(c string) error {
 	return nil
 }
 
+// NewRetryConfig returns the retry configuration set in the component metadata.
+// The backoff is exponential with jitter, and operations are not retried unless
+// retryMaxRetries is set.
+func NewRetryConfig(properties map[string]string) (retry.Config, error) {
+	config := retry.DefaultConfigWithNoRetry()
+	config.Policy = retry.PolicyExponential
+	if err := retry.DecodeConfigWithPrefix(&config, properties, RetryMetadataPrefix); err != nil {
+		return config, fmt.Errorf("error decoding retry config: %w", err)
+	}
+
+	return config, nil
+}
+
 // SetWithOptions handles SetRequest with request options.
 func SetWithOptions(method func(req *SetRequest) error, req *SetRequest) error {
 	return method(req)
@@ -76,3 +107,89 @@ func SetWithOptions(method func(req *SetRequest) error, req *SetRequest) error {
 func DeleteWithOptions(method func(req *DeleteRequest) error, req *DeleteRequest) error {
 	return method(req)
 }
+
+// SetWithRetries handles SetRequest like SetWithOptions, and retries the
+// transient errors returned by method as configured by retryConfig.
+func SetWithRetries(method func(req *SetRequest) error, req *SetRequest, retryConfig retry.Config) error {
+	return withRetries(func() error {
+		return SetWithOptions(method, req)
+	}, retryConfig)
+}
+
+// DeleteWithRetries handles DeleteRequest like DeleteWithOptions, and retries
+// the transient errors returned by method as configured by retryConfig.
+func DeleteWithRetries(method func(req *DeleteRequest) error, req *DeleteRequest, retryConfig retry.Config) error {
+	return withRetries(func() error {
+		return DeleteWithOptions(method, req)
+	}, retryConfig)
+}
+
+func withRetries(operation func() error, retryConfig retry.Config) error {
+	if retryConfig.MaxRetries == 0 {
+		return operation()
+	}
+
+	return backoff.Retry(func() error {
+		err := operation()
+		if err != nil && !IsTransientError(err) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}, retryConfig.NewBackOff())
+}
+
+// transientRedisErrors are the prefixes of the errors returned by Redis
+// while it is loading its dataset or failing over.
+var transientRedisErrors = []string{"LOADING ", "READONLY ", "CLUSTERDOWN ", "TRYAGAIN ", "MASTERDOWN "}
+
+// redisError is implemented by the errors replied by Redis.
+type redisError interface {
+	error
+	RedisError()
+}
+
+// IsTransientError reports whether an operation which failed with err may
+// succeed if it is retried, i.e. whether err is a connection error, a timeout
+// or an unavailability of the store. Canceled operations are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var redisErr redisError
+	if errors.As(err, &redisErr) {
+		for _, prefix := range transientRedisErrors {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		switch grpcErr.GRPCStatus().Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+
+	return false
+}
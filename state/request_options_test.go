@@ -14,9 +14,22 @@ limitations under the License.
 package state
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/kit/retry"
 )
 
 // TestSetRequestWithOptions is used to test request options.
@@ -44,6 +57,127 @@ func TestSetRequestWithOptions(t *testing.T) {
 	})
 }
 
+func TestNewRetryConfig(t *testing.T) {
+	t.Run("no retries by default", func(t *testing.T) {
+		config, err := NewRetryConfig(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), config.MaxRetries)
+		assert.Equal(t, retry.PolicyExponential, config.Policy)
+	})
+
+	t.Run("from metadata", func(t *testing.T) {
+		config, err := NewRetryConfig(map[string]string{
+			"retryMaxRetries":          "3",
+			"retryInitialInterval":     "10ms",
+			"retryRandomizationFactor": "0.2",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), config.MaxRetries)
+		assert.Equal(t, 10*time.Millisecond, config.InitialInterval)
+		assert.Equal(t, float32(0.2), config.RandomizationFactor)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := NewRetryConfig(map[string]string{"retryMaxRetries": "abc"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRequestWithRetries(t *testing.T) {
+	config, err := NewRetryConfig(map[string]string{"retryMaxRetries": "2", "retryInitialInterval": "1ms"})
+	require.NoError(t, err)
+
+	t.Run("set retries transient errors", func(t *testing.T) {
+		counter := 0
+		err := SetWithRetries(func(req *SetRequest) error {
+			counter++
+			if counter < 3 {
+				return fmt.Errorf("error writing: %w", syscall.ECONNRESET)
+			}
+
+			return nil
+		}, &SetRequest{}, config)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, counter)
+	})
+
+	t.Run("delete gives up after max retries", func(t *testing.T) {
+		counter := 0
+		err := DeleteWithRetries(func(req *DeleteRequest) error {
+			counter++
+
+			return io.EOF
+		}, &DeleteRequest{}, config)
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 3, counter)
+	})
+
+	t.Run("etag errors are not retried", func(t *testing.T) {
+		counter := 0
+		err := SetWithRetries(func(req *SetRequest) error {
+			counter++
+
+			return NewETagError(ETagMismatch, nil)
+		}, &SetRequest{}, config)
+		var etagErr *ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, 1, counter)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		counter := 0
+		err := SetWithRetries(func(req *SetRequest) error {
+			counter++
+
+			return errors.New("invalid ttl")
+		}, &SetRequest{}, config)
+		assert.EqualError(t, err, "invalid ttl")
+		assert.Equal(t, 1, counter)
+	})
+
+	t.Run("canceled operations are not retried", func(t *testing.T) {
+		counter := 0
+		err := DeleteWithRetries(func(req *DeleteRequest) error {
+			counter++
+
+			return fmt.Errorf("error deleting: %w", context.Canceled)
+		}, &DeleteRequest{}, config)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, counter)
+	})
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"nil", nil, false},
+		{"bad connection", fmt.Errorf("error: %w", driver.ErrBadConn), true},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"redis loading", redisTestError("LOADING Redis is loading the dataset in memory"), true},
+		{"redis syntax error", redisTestError("ERR syntax error"), false},
+		{"grpc unavailable", status.Error(codes.Unavailable, "unavailable"), true},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "invalid"), false},
+		{"etag mismatch", NewETagError(ETagMismatch, nil), false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"validation error", errors.New("key is required"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.transient, IsTransientError(tt.err))
+		})
+	}
+}
+
+type redisTestError string
+
+func (e redisTestError) Error() string { return string(e) }
+
+func (redisTestError) RedisError() {}
+
 // TestCheckRequestOptions is used to validate request options.
 func TestCheckRequestOptions(t *testing.T) {
 	t.Run("set state options", func(t *testing.T) {
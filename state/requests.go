@@ -109,3 +109,19 @@ type QueryRequest struct {
 	Query    query.Query       `json:"query"`
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
+
+// ListKeysRequest is the object describing a page of keys to list.
+// A Limit of zero returns all the remaining keys. Token is the value
+// returned by the previous page, or empty for the first page.
+type ListKeysRequest struct {
+	Prefix   string            `json:"prefix,omitempty"`
+	Limit    int               `json:"limit,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// WatchRequest is the object describing a subscription to state changes.
+type WatchRequest struct {
+	KeyPrefix string            `json:"keyPrefix"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
@@ -36,6 +36,16 @@ type QueryResponse struct {
 	Results  []QueryItem       `json:"results"`
 	Token    string            `json:"token,omitempty"`
 	Metadata map[string]string `json:"metadata,omitempty"`
+	// Aggregations holds the results of an aggregation query, one per group.
+	Aggregations []AggregationResult `json:"aggregations,omitempty"`
+}
+
+// AggregationResult is an object representing the aggregated values of a group of documents.
+type AggregationResult struct {
+	// Group holds the values of the group by keys. It is empty if the documents are not grouped.
+	Group map[string]interface{} `json:"group,omitempty"`
+	// Values holds the aggregated values by alias.
+	Values map[string]interface{} `json:"values"`
 }
 
 // QueryItem is an object representing a single entry in query results.
@@ -46,3 +56,19 @@ type QueryItem struct {
 	Error       string  `json:"error,omitempty"`
 	ContentType *string `json:"contentType,omitempty"`
 }
+
+// ListKeysResponse is the response object for listing keys.
+// Token is empty when there are no more keys to list.
+type ListKeysResponse struct {
+	Keys  []string `json:"keys"`
+	Token string   `json:"token,omitempty"`
+}
+
+// WatchEvent is an object representing a single change of state.
+// Value and ETag are empty for delete operations.
+type WatchEvent struct {
+	Key       string        `json:"key"`
+	Operation OperationType `json:"operation"`
+	Value     []byte        `json:"value,omitempty"`
+	ETag      *string       `json:"etag,omitempty"`
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	defaultSnapshotPageSize  = 100
+	defaultSnapshotBatchSize = 100
+
+	ttlInSecondsKey = "ttlInSeconds"
+)
+
+// SnapshotRecord is a line of a snapshot archive, which holds a key and its value.
+type SnapshotRecord struct {
+	Key string `json:"key"`
+	// Value holds the values which are valid JSON documents.
+	Value json.RawMessage `json:"value,omitempty"`
+	// Data holds the other values, encoded in base64.
+	Data []byte `json:"data,omitempty"`
+	// ETag is the ETag of the value in the exported store. It is informational,
+	// since the values are saved unconditionally when they are imported.
+	ETag        *string `json:"etag,omitempty"`
+	ContentType *string `json:"contentType,omitempty"`
+	// TTLInSeconds is the remaining time to live of the value, if the
+	// exported store reports it in the ttlInSeconds metadata of the value.
+	TTLInSeconds *int `json:"ttlInSeconds,omitempty"`
+}
+
+// ExportOptions configures the export of a snapshot.
+type ExportOptions struct {
+	// Prefix restricts the export to the keys starting with it.
+	Prefix string
+	// PageSize is the number of keys listed and read at once. It defaults to 100.
+	PageSize int
+}
+
+// ImportOptions configures the import of a snapshot.
+type ImportOptions struct {
+	// BatchSize is the number of values saved at once with BulkSet. It defaults to 100.
+	BatchSize int
+}
+
+// Export writes the keys of store and their values to w as a snapshot archive,
+// with a JSON SnapshotRecord per line, and returns the number of exported keys.
+// The store must implement KeyLister. The snapshot is not consistent: the
+// values changed during the export may be exported before or after the change.
+func Export(store Store, w io.Writer, opts ExportOptions) (int, error) {
+	lister, ok := store.(KeyLister)
+	if !ok {
+		return 0, errors.New("the state store does not support listing keys")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultSnapshotPageSize
+	}
+
+	count := 0
+	encoder := json.NewEncoder(w)
+	req := &ListKeysRequest{Prefix: opts.Prefix, Limit: opts.PageSize}
+	for {
+		page, err := lister.ListKeys(req)
+		if err != nil {
+			return count, fmt.Errorf("error listing keys: %w", err)
+		}
+
+		records, err := readSnapshotRecords(store, page.Keys)
+		if err != nil {
+			return count, err
+		}
+		for i := range records {
+			if err = encoder.Encode(&records[i]); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if page.Token == "" {
+			return count, nil
+		}
+		req.Token = page.Token
+	}
+}
+
+// readSnapshotRecords reads the values of keys, using BulkGet if the store supports it.
+// The keys deleted since they were listed are skipped.
+func readSnapshotRecords(store Store, keys []string) ([]SnapshotRecord, error) {
+	reqs := make([]GetRequest, len(keys))
+	for i, key := range keys {
+		reqs[i] = GetRequest{Key: key}
+	}
+
+	supported, bulkRes, err := store.BulkGet(reqs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+	if !supported {
+		bulkRes = make([]BulkGetResponse, len(reqs))
+		for i := range reqs {
+			res, err := store.Get(&reqs[i])
+			if err != nil {
+				return nil, fmt.Errorf("error reading the value of key %s: %w", reqs[i].Key, err)
+			}
+			bulkRes[i] = BulkGetResponse{
+				Key:         reqs[i].Key,
+				Data:        res.Data,
+				ETag:        res.ETag,
+				Metadata:    res.Metadata,
+				ContentType: res.ContentType,
+			}
+		}
+	}
+
+	records := make([]SnapshotRecord, 0, len(bulkRes))
+	for _, res := range bulkRes {
+		if res.Error != "" {
+			return nil, fmt.Errorf("error reading the value of key %s: %s", res.Key, res.Error)
+		}
+		if res.Data == nil {
+			continue
+		}
+
+		record := SnapshotRecord{
+			Key:         res.Key,
+			ETag:        res.ETag,
+			ContentType: res.ContentType,
+		}
+		if json.Valid(res.Data) {
+			record.Value = res.Data
+		} else {
+			record.Data = res.Data
+		}
+		if val, ok := res.Metadata[ttlInSecondsKey]; ok {
+			ttl, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ttl of key %s: %w", res.Key, err)
+			}
+			record.TTLInSeconds = &ttl
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Import saves the values of a snapshot archive written by Export in store,
+// and returns the number of imported keys. The existing values are overwritten.
+func Import(store Store, r io.Reader, opts ImportOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultSnapshotBatchSize
+	}
+
+	count := 0
+	batch := make([]SetRequest, 0, opts.BatchSize)
+	decoder := json.NewDecoder(r)
+	for {
+		var record SnapshotRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("invalid snapshot record: %w", err)
+		}
+		if record.Key == "" {
+			return count, errors.New("invalid snapshot record: missing key")
+		}
+
+		req := SetRequest{
+			Key:         record.Key,
+			ContentType: record.ContentType,
+		}
+		if record.Value != nil {
+			req.Value = record.Value
+		} else {
+			req.Value = record.Data
+		}
+		if record.TTLInSeconds != nil {
+			req.Metadata = map[string]string{ttlInSecondsKey: strconv.Itoa(*record.TTLInSeconds)}
+		}
+
+		batch = append(batch, req)
+		if len(batch) == opts.BatchSize {
+			if err = store.BulkSet(batch); err != nil {
+				return count, fmt.Errorf("error saving values: %w", err)
+			}
+			count += len(batch)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := store.BulkSet(batch); err != nil {
+			return count, fmt.Errorf("error saving values: %w", err)
+		}
+		count += len(batch)
+	}
+
+	return count, nil
+}
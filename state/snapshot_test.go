@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapStore is a store which keeps the raw values in a map.
+type mapStore struct {
+	DefaultBulkStore
+	values    map[string][]byte
+	ttls      map[string]string
+	bulkSizes []int
+}
+
+func newMapStore() *mapStore {
+	s := &mapStore{values: map[string][]byte{}, ttls: map[string]string{}}
+	s.DefaultBulkStore = NewDefaultBulkStore(s)
+
+	return s
+}
+
+func (s *mapStore) Init(metadata Metadata) error {
+	return nil
+}
+
+func (s *mapStore) Features() []Feature {
+	return []Feature{FeatureListKeys}
+}
+
+func (s *mapStore) Delete(req *DeleteRequest) error {
+	delete(s.values, req.Key)
+
+	return nil
+}
+
+func (s *mapStore) Get(req *GetRequest) (*GetResponse, error) {
+	data, ok := s.values[req.Key]
+	if !ok {
+		return &GetResponse{}, nil
+	}
+	res := &GetResponse{Data: data, ETag: &req.Key}
+	if ttl, ok := s.ttls[req.Key]; ok {
+		res.Metadata = map[string]string{ttlInSecondsKey: ttl}
+	}
+
+	return res, nil
+}
+
+func (s *mapStore) Set(req *SetRequest) error {
+	switch v := req.Value.(type) {
+	case []byte:
+		s.values[req.Key] = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		s.values[req.Key] = b
+	}
+	if ttl, ok := req.Metadata[ttlInSecondsKey]; ok {
+		s.ttls[req.Key] = ttl
+	}
+
+	return nil
+}
+
+func (s *mapStore) BulkSet(req []SetRequest) error {
+	s.bulkSizes = append(s.bulkSizes, len(req))
+
+	return s.DefaultBulkStore.BulkSet(req)
+}
+
+func (s *mapStore) ListKeys(req *ListKeysRequest) (*ListKeysResponse, error) {
+	keys := []string{}
+	for key := range s.values {
+		if strings.HasPrefix(key, req.Prefix) && key > req.Token {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return NewListKeysResponse(keys, req.Limit), nil
+}
+
+func TestSnapshot(t *testing.T) {
+	source := newMapStore()
+	source.values = map[string][]byte{
+		"app||1": []byte(`{"name":"John"}`),
+		"app||2": []byte("plain text"),
+		"app||3": []byte(`"v3"`),
+		"other":  []byte(`1`),
+	}
+	source.ttls["app||3"] = "60"
+
+	var archive bytes.Buffer
+	n, err := Export(source, &archive, ExportOptions{Prefix: "app||", PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	lines := strings.Split(strings.TrimSpace(archive.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"key":"app||1","value":{"name":"John"},"etag":"app||1"}`, lines[0])
+	assert.JSONEq(t, `{"key":"app||2","data":"cGxhaW4gdGV4dA==","etag":"app||2"}`, lines[1])
+	assert.JSONEq(t, `{"key":"app||3","value":"v3","etag":"app||3","ttlInSeconds":60}`, lines[2])
+
+	target := newMapStore()
+	n, err = Import(target, &archive, ImportOptions{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int{2, 1}, target.bulkSizes)
+	assert.Equal(t, map[string][]byte{
+		"app||1": []byte(`{"name":"John"}`),
+		"app||2": []byte("plain text"),
+		"app||3": []byte(`"v3"`),
+	}, target.values)
+	assert.Equal(t, map[string]string{"app||3": "60"}, target.ttls)
+}
+
+func TestExportWithoutKeyLister(t *testing.T) {
+	s := &Store1{}
+	s.DefaultBulkStore = NewDefaultBulkStore(s)
+
+	_, err := Export(s, &bytes.Buffer{}, ExportOptions{})
+	assert.Error(t, err)
+}
+
+func TestImportInvalidArchive(t *testing.T) {
+	_, err := Import(newMapStore(), strings.NewReader(`{"value":1}`), ImportOptions{})
+	assert.Error(t, err)
+
+	_, err = Import(newMapStore(), strings.NewReader(`not json`), ImportOptions{})
+	assert.Error(t, err)
+}
@@ -35,10 +35,16 @@ type migrationResult struct {
 	upsertProcFullName       string
 	pkColumnType             string
 	getCommand               string
+	bulkGetCommand           string
+	listKeysCommand          string
 	deleteWithETagCommand    string
 	deleteWithoutETagCommand string
+	deleteExpiredCommand     string
 }
 
+// notExpiredCondition excludes the rows whose ttl has elapsed.
+const notExpiredCondition = "([ExpireDate] IS NULL OR [ExpireDate] > GETDATE())"
+
 func newMigration(store *SQLServer) migrator {
 	return &migration{
 		store: store,
@@ -49,10 +55,13 @@ func (m *migration) newMigrationResult() migrationResult {
 	r := migrationResult{
 		bulkDeleteProcName:       fmt.Sprintf("sp_BulkDelete_%s", m.store.tableName),
 		itemRefTableTypeName:     fmt.Sprintf("[%s].%s_Table", m.store.schema, m.store.tableName),
-		upsertProcName:           fmt.Sprintf("sp_Upsert_v2_%s", m.store.tableName),
-		getCommand:               fmt.Sprintf("SELECT [Data], [RowVersion] FROM [%s].[%s] WHERE [Key] = @Key", m.store.schema, m.store.tableName),
+		upsertProcName:           fmt.Sprintf("sp_Upsert_v3_%s", m.store.tableName),
+		getCommand:               fmt.Sprintf("SELECT [Data], [RowVersion] FROM [%s].[%s] WHERE [Key] = @Key AND %s", m.store.schema, m.store.tableName, notExpiredCondition),
+		bulkGetCommand:           fmt.Sprintf("SELECT CONVERT(NVARCHAR(MAX), [Key]), [Data], [RowVersion] FROM [%s].[%s] WHERE %s AND [Key] IN ", m.store.schema, m.store.tableName, notExpiredCondition),
+		listKeysCommand:          fmt.Sprintf(`SELECT CONVERT(NVARCHAR(MAX), [Key]) FROM [%s].[%s] WHERE %s AND CONVERT(NVARCHAR(MAX), [Key]) LIKE @Prefix ESCAPE '\'`, m.store.schema, m.store.tableName, notExpiredCondition),
 		deleteWithETagCommand:    fmt.Sprintf(`DELETE [%s].[%s] WHERE [Key]=@Key AND [RowVersion]=@RowVersion`, m.store.schema, m.store.tableName),
 		deleteWithoutETagCommand: fmt.Sprintf(`DELETE [%s].[%s] WHERE [Key]=@Key`, m.store.schema, m.store.tableName),
+		deleteExpiredCommand:     fmt.Sprintf(`DELETE [%s].[%s] WHERE [ExpireDate] IS NOT NULL AND [ExpireDate] <= GETDATE()`, m.store.schema, m.store.tableName),
 	}
 
 	r.bulkDeleteProcFullName = fmt.Sprintf("[%s].%s", m.store.schema, r.bulkDeleteProcName)
@@ -118,6 +127,11 @@ func (m *migration) executeMigrations() (migrationResult, error) {
 		return r, fmt.Errorf("failed to create db table: %v", err)
 	}
 
+	err = m.ensureExpireDateColumnExists(db)
+	if err != nil {
+		return r, fmt.Errorf("failed to add expiration column: %v", err)
+	}
+
 	err = m.ensureStoredProcedureExists(db, r)
 	if err != nil {
 		return r, fmt.Errorf("failed to create stored procedures: %v", err)
@@ -190,7 +204,8 @@ func (m *migration) ensureTableExists(db *sql.DB, r migrationResult) error {
 			[Key] 			%s CONSTRAINT PK_%s PRIMARY KEY,
 			[Data]			NVARCHAR(MAX) NOT NULL,
 			[InsertDate] 	DateTime2 NOT NULL DEFAULT(GETDATE()),
-			[UpdateDate] 	DateTime2 NULL,`,
+			[UpdateDate] 	DateTime2 NULL,
+			[ExpireDate] 	DateTime2 NULL,`,
 		m.store.schema, m.store.tableName, m.store.schema, m.store.tableName, r.pkColumnType, m.store.tableName)
 
 	if m.store.indexedProperties != nil {
@@ -210,6 +225,18 @@ func (m *migration) ensureTableExists(db *sql.DB, r migrationResult) error {
 	return runCommand(tsql, db)
 }
 
+// ensureExpireDateColumnExists adds the expiration column to the tables
+// created by previous versions.
+/* #nosec. */
+func (m *migration) ensureExpireDateColumnExists(db *sql.DB) error {
+	tsql := fmt.Sprintf(`
+	IF COL_LENGTH('[%s].[%s]', 'ExpireDate') IS NULL
+		ALTER TABLE [%s].[%s] ADD [ExpireDate] DateTime2 NULL`,
+		m.store.schema, m.store.tableName, m.store.schema, m.store.tableName)
+
+	return runCommand(tsql, db)
+}
+
 /* #nosec. */
 func (m *migration) ensureTypeExists(db *sql.DB, mr migrationResult) error {
 	tsql := fmt.Sprintf(`
@@ -283,20 +310,22 @@ func (m *migration) ensureUpsertStoredProcedureExists(db *sql.DB, mr migrationRe
 				@Key 			%s,
 				@Data 			NVARCHAR(MAX),
 				@RowVersion		BINARY(8),
-				@FirstWrite		BIT)
+				@FirstWrite		BIT,
+				@TTL			INT)
 			AS
+				DECLARE @ExpireDate DATETIME2 = CASE WHEN @TTL > 0 THEN DATEADD(SECOND, @TTL, GETDATE()) ELSE NULL END
 				IF (@FirstWrite=1)
 					BEGIN
 						IF (@RowVersion IS NOT NULL)
 							BEGIN
 								BEGIN TRANSACTION;
-								IF NOT EXISTS (SELECT * FROM [%s] WHERE [KEY]=@KEY AND RowVersion = @RowVersion)
+								IF NOT EXISTS (SELECT * FROM [%s] WHERE [KEY]=@KEY AND RowVersion = @RowVersion AND %s)
 									BEGIN
 										THROW 2601, ''FIRST-WRITE: COMPETING RECORD ALREADY WRITTEN.'', 1
 									END
 								BEGIN
 									UPDATE [%s]
-									SET [Data]=@Data, UpdateDate=GETDATE()
+									SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=@ExpireDate
 									WHERE [Key]=@Key AND RowVersion = @RowVersion
 								END
 								COMMIT;
@@ -304,19 +333,19 @@ func (m *migration) ensureUpsertStoredProcedureExists(db *sql.DB, mr migrationRe
 						ELSE
 							BEGIN
 								BEGIN TRANSACTION;
-								IF EXISTS (SELECT * FROM [%s] WHERE [KEY]=@KEY)
+								IF EXISTS (SELECT * FROM [%s] WHERE [KEY]=@KEY AND %s)
 									BEGIN
 										THROW 2601, ''FIRST-WRITE: COMPETING RECORD ALREADY WRITTEN.'', 1
 									END
 								BEGIN
 									BEGIN TRY
-										INSERT INTO [%s] ([Key], [Data]) VALUES (@Key, @Data);
+										INSERT INTO [%s] ([Key], [Data], [ExpireDate]) VALUES (@Key, @Data, @ExpireDate);
 									END TRY
 						
 									BEGIN CATCH
 										IF ERROR_NUMBER() IN (2601, 2627)
 											UPDATE [%s]
-											SET [Data]=@Data, UpdateDate=GETDATE()
+											SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=@ExpireDate
 											WHERE [Key]=@Key AND RowVersion = ISNULL(@RowVersion, RowVersion)
 									END CATCH
 								END
@@ -328,20 +357,20 @@ func (m *migration) ensureUpsertStoredProcedureExists(db *sql.DB, mr migrationRe
 						IF (@RowVersion IS NOT NULL)
 							BEGIN
 								UPDATE [%s]
-								SET [Data]=@Data, UpdateDate=GETDATE()
-								WHERE [Key]=@Key AND RowVersion = @RowVersion
+								SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=@ExpireDate
+								WHERE [Key]=@Key AND RowVersion = @RowVersion AND %s
 								RETURN
 							END
 						ELSE
 							BEGIN
 								BEGIN TRY
-									INSERT INTO [%s] ([Key], [Data]) VALUES (@Key, @Data);
+									INSERT INTO [%s] ([Key], [Data], [ExpireDate]) VALUES (@Key, @Data, @ExpireDate);
 								END TRY
 					
 								BEGIN CATCH
 									IF ERROR_NUMBER() IN (2601, 2627)
 										UPDATE [%s]
-										SET [Data]=@Data, UpdateDate=GETDATE()
+										SET [Data]=@Data, UpdateDate=GETDATE(), ExpireDate=@ExpireDate
 										WHERE [Key]=@Key AND RowVersion = ISNULL(@RowVersion, RowVersion)
 								END CATCH
 							END
@@ -350,11 +379,14 @@ func (m *migration) ensureUpsertStoredProcedureExists(db *sql.DB, mr migrationRe
 		mr.upsertProcFullName,
 		mr.pkColumnType,
 		m.store.tableName,
+		notExpiredCondition,
 		m.store.tableName,
 		m.store.tableName,
+		notExpiredCondition,
 		m.store.tableName,
 		m.store.tableName,
 		m.store.tableName,
+		notExpiredCondition,
 		m.store.tableName,
 		m.store.tableName,
 	)
@@ -14,12 +14,15 @@ limitations under the License.
 package sqlserver
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 	"unicode"
 
 	"github.com/agrea/ptr"
@@ -71,17 +74,19 @@ const (
 	keyColumnName        = "Key"
 	rowVersionColumnName = "RowVersion"
 	databaseNameKey      = "databaseName"
+	cleanupIntervalKey   = "cleanupInterval"
 
-	defaultKeyLength = 200
-	defaultSchema    = "dbo"
-	defaultDatabase  = "dapr"
-	defaultTable     = "state"
+	defaultKeyLength       = 200
+	defaultSchema          = "dbo"
+	defaultDatabase        = "dapr"
+	defaultTable           = "state"
+	defaultCleanupInterval = time.Hour
 )
 
 // NewSQLServerStateStore creates a new instance of a Sql Server transaction store.
 func NewSQLServerStateStore(logger logger.Logger) *SQLServer {
 	store := SQLServer{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureListKeys, state.FeatureTTL},
 		logger:   logger,
 	}
 	store.migratorFactory = newMigration
@@ -105,18 +110,24 @@ type SQLServer struct {
 	keyType           KeyType
 	keyLength         int
 	indexedProperties []IndexedProperty
+	cleanupInterval   time.Duration
 	migratorFactory   func(*SQLServer) migrator
 
 	bulkDeleteCommand        string
 	itemRefTableTypeName     string
 	upsertCommand            string
 	getCommand               string
+	bulkGetCommand           string
+	listKeysCommand          string
 	deleteWithETagCommand    string
 	deleteWithoutETagCommand string
+	deleteExpiredCommand     string
 
 	features []state.Feature
 	logger   logger.Logger
 	db       *sql.DB
+	ctx      context.Context
+	cancel   context.CancelFunc
 }
 
 func isLetterOrNumber(c rune) bool {
@@ -181,6 +192,10 @@ func (s *SQLServer) Init(metadata state.Metadata) error {
 		return err
 	}
 
+	if err := s.getCleanupInterval(metadata); err != nil {
+		return err
+	}
+
 	migration := s.migratorFactory(s)
 	mr, err := migration.executeMigrations()
 	if err != nil {
@@ -191,14 +206,22 @@ func (s *SQLServer) Init(metadata state.Metadata) error {
 	s.bulkDeleteCommand = fmt.Sprintf("exec %s @itemsToDelete;", mr.bulkDeleteProcFullName)
 	s.upsertCommand = mr.upsertProcFullName
 	s.getCommand = mr.getCommand
+	s.bulkGetCommand = mr.bulkGetCommand
+	s.listKeysCommand = mr.listKeysCommand
 	s.deleteWithETagCommand = mr.deleteWithETagCommand
 	s.deleteWithoutETagCommand = mr.deleteWithoutETagCommand
+	s.deleteExpiredCommand = mr.deleteExpiredCommand
 
 	s.db, err = sql.Open("sqlserver", s.connectionString)
 	if err != nil {
 		return err
 	}
 
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if s.cleanupInterval > 0 {
+		go s.startCleanupThread()
+	}
+
 	return nil
 }
 
@@ -332,6 +355,21 @@ func (s *SQLServer) getTable(metadata state.Metadata) error {
 	return nil
 }
 
+// Returns the interval at which the rows whose ttl has elapsed are deleted.
+// A value of zero or less disables the cleanup.
+func (s *SQLServer) getCleanupInterval(metadata state.Metadata) error {
+	s.cleanupInterval = defaultCleanupInterval
+	if val, ok := metadata.Properties[cleanupIntervalKey]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", cleanupIntervalKey, val, err)
+		}
+		s.cleanupInterval = d
+	}
+
+	return nil
+}
+
 // Features returns the features available in this state store.
 func (s *SQLServer) Features() []state.Feature {
 	return s.features
@@ -339,7 +377,12 @@ func (s *SQLServer) Features() []state.Feature {
 
 // Multi performs multiple updates on a Sql server store.
 func (s *SQLServer) Multi(request *state.TransactionalStateRequest) error {
-	tx, err := s.db.Begin()
+	return s.MultiWithContext(context.Background(), request)
+}
+
+// MultiWithContext performs multiple updates on a Sql server store, honoring the cancellation of ctx.
+func (s *SQLServer) MultiWithContext(ctx context.Context, request *state.TransactionalStateRequest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -353,7 +396,7 @@ func (s *SQLServer) Multi(request *state.TransactionalStateRequest) error {
 				return err
 			}
 
-			err = s.executeSet(tx, &setReq)
+			err = s.executeSet(ctx, tx, &setReq)
 			if err != nil {
 				tx.Rollback()
 				return err
@@ -366,7 +409,7 @@ func (s *SQLServer) Multi(request *state.TransactionalStateRequest) error {
 				return err
 			}
 
-			err = s.executeDelete(tx, &delReq)
+			err = s.executeDelete(ctx, tx, &delReq)
 			if err != nil {
 				tx.Rollback()
 				return err
@@ -411,10 +454,15 @@ func (s *SQLServer) getDeletes(req state.TransactionalStateOperation) (state.Del
 
 // Delete removes an entity from the store.
 func (s *SQLServer) Delete(req *state.DeleteRequest) error {
-	return s.executeDelete(s.db, req)
+	return s.DeleteWithContext(context.Background(), req)
 }
 
-func (s *SQLServer) executeDelete(db dbExecutor, req *state.DeleteRequest) error {
+// DeleteWithContext removes an entity from the store, honoring the cancellation of ctx.
+func (s *SQLServer) DeleteWithContext(ctx context.Context, req *state.DeleteRequest) error {
+	return s.executeDelete(ctx, s.db, req)
+}
+
+func (s *SQLServer) executeDelete(ctx context.Context, db dbExecutor, req *state.DeleteRequest) error {
 	var err error
 	var res sql.Result
 	if req.ETag != nil {
@@ -424,9 +472,9 @@ func (s *SQLServer) executeDelete(db dbExecutor, req *state.DeleteRequest) error
 			return state.NewETagError(state.ETagInvalid, err)
 		}
 
-		res, err = db.Exec(s.deleteWithETagCommand, sql.Named(keyColumnName, req.Key), sql.Named(rowVersionColumnName, b))
+		res, err = db.ExecContext(ctx, s.deleteWithETagCommand, sql.Named(keyColumnName, req.Key), sql.Named(rowVersionColumnName, b))
 	} else {
-		res, err = db.Exec(s.deleteWithoutETagCommand, sql.Named(keyColumnName, req.Key))
+		res, err = db.ExecContext(ctx, s.deleteWithoutETagCommand, sql.Named(keyColumnName, req.Key))
 	}
 
 	// err represents errors thrown by the stored procedure or the database itself
@@ -457,12 +505,17 @@ type TvpDeleteTableStringKey struct {
 
 // BulkDelete removes multiple entries from the store.
 func (s *SQLServer) BulkDelete(req []state.DeleteRequest) error {
-	tx, err := s.db.Begin()
+	return s.BulkDeleteWithContext(context.Background(), req)
+}
+
+// BulkDeleteWithContext removes multiple entries from the store, honoring the cancellation of ctx.
+func (s *SQLServer) BulkDeleteWithContext(ctx context.Context, req []state.DeleteRequest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	err = s.executeBulkDelete(tx, req)
+	err = s.executeBulkDelete(ctx, tx, req)
 	if err != nil {
 		tx.Rollback()
 
@@ -474,7 +527,7 @@ func (s *SQLServer) BulkDelete(req []state.DeleteRequest) error {
 	return nil
 }
 
-func (s *SQLServer) executeBulkDelete(db dbExecutor, req []state.DeleteRequest) error {
+func (s *SQLServer) executeBulkDelete(ctx context.Context, db dbExecutor, req []state.DeleteRequest) error {
 	values := make([]TvpDeleteTableStringKey, len(req))
 	for i, d := range req {
 		var etag []byte
@@ -493,7 +546,7 @@ func (s *SQLServer) executeBulkDelete(db dbExecutor, req []state.DeleteRequest)
 		Value:    values,
 	}
 
-	res, err := db.Exec(s.bulkDeleteCommand, sql.Named("itemsToDelete", itemsToDelete))
+	res, err := db.ExecContext(ctx, s.bulkDeleteCommand, sql.Named("itemsToDelete", itemsToDelete))
 	if err != nil {
 		return err
 	}
@@ -514,7 +567,12 @@ func (s *SQLServer) executeBulkDelete(db dbExecutor, req []state.DeleteRequest)
 
 // Get returns an entity from store.
 func (s *SQLServer) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	rows, err := s.db.Query(s.getCommand, sql.Named(keyColumnName, req.Key))
+	return s.GetWithContext(context.Background(), req)
+}
+
+// GetWithContext returns an entity from store, honoring the cancellation of ctx.
+func (s *SQLServer) GetWithContext(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	rows, err := s.db.QueryContext(ctx, s.getCommand, sql.Named(keyColumnName, req.Key))
 	if err != nil {
 		return nil, err
 	}
@@ -546,20 +604,127 @@ func (s *SQLServer) Get(req *state.GetRequest) (*state.GetResponse, error) {
 
 // BulkGet performs a bulks get operations.
 func (s *SQLServer) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
-	return false, nil, nil
+	return s.BulkGetWithContext(context.Background(), req)
+}
+
+// BulkGetWithContext returns the data for multiple keys using a single query, honoring the cancellation of ctx.
+// Keys that do not exist are returned with empty data.
+func (s *SQLServer) BulkGetWithContext(ctx context.Context, req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	if len(req) == 0 {
+		return true, []state.BulkGetResponse{}, nil
+	}
+
+	params := make([]interface{}, len(req))
+	placeholders := make([]string, len(req))
+	for i, r := range req {
+		if r.Key == "" {
+			return false, nil, fmt.Errorf("missing key in bulk get operation")
+		}
+		name := fmt.Sprintf("%s%d", keyColumnName, i)
+		params[i] = sql.Named(name, r.Key)
+		placeholders[i] = "@" + name
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.bulkGetCommand+"("+strings.Join(placeholders, ", ")+")", params...)
+	if err != nil {
+		return false, nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]state.BulkGetResponse, len(req))
+	for rows.Next() {
+		var key, data string
+		var rowVersion []byte
+		if err = rows.Scan(&key, &data, &rowVersion); err != nil {
+			return false, nil, err
+		}
+
+		found[s.normalizeKey(key)] = state.BulkGetResponse{
+			Key:  key,
+			Data: []byte(data),
+			ETag: ptr.String(hex.EncodeToString(rowVersion)),
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return false, nil, err
+	}
+
+	res := make([]state.BulkGetResponse, len(req))
+	for i, r := range req {
+		item, ok := found[s.normalizeKey(r.Key)]
+		if !ok {
+			item = state.BulkGetResponse{}
+		}
+		item.Key = r.Key
+		item.Metadata = r.Metadata
+		res[i] = item
+	}
+
+	return true, res, nil
+}
+
+// ListKeys returns the keys starting with req.Prefix, ordered by the key column.
+// The token is the last key of the previous page.
+func (s *SQLServer) ListKeys(req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	command := s.listKeysCommand
+	params := []interface{}{sql.Named("Prefix", utils.LikePrefixPattern(req.Prefix))}
+	if req.Token != "" {
+		command += " AND [Key] > @Token"
+		params = append(params, sql.Named("Token", req.Token))
+	}
+	command += " ORDER BY [Key]"
+	if req.Limit > 0 {
+		command += fmt.Sprintf(" OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", req.Limit+1)
+	}
+
+	rows, err := s.db.Query(command, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, s.normalizeKey(key))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return state.NewListKeysResponse(keys, req.Limit), nil
+}
+
+// normalizeKey returns the key in the form used to match rows to requests.
+// SQL Server returns uniqueidentifier values in upper case.
+func (s *SQLServer) normalizeKey(key string) string {
+	if s.keyType == UUIDKeyType {
+		return strings.ToLower(key)
+	}
+
+	return key
 }
 
 // Set adds/updates an entity on store.
 func (s *SQLServer) Set(req *state.SetRequest) error {
-	return s.executeSet(s.db, req)
+	return s.SetWithContext(context.Background(), req)
+}
+
+// SetWithContext adds/updates an entity on store, honoring the cancellation of ctx.
+func (s *SQLServer) SetWithContext(ctx context.Context, req *state.SetRequest) error {
+	return s.executeSet(ctx, s.db, req)
 }
 
 // dbExecutor implements a common functionality implemented by db or tx.
 type dbExecutor interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-func (s *SQLServer) executeSet(db dbExecutor, req *state.SetRequest) error {
+func (s *SQLServer) executeSet(ctx context.Context, db dbExecutor, req *state.SetRequest) error {
 	var err error
 	var bytes []byte
 	bytes, err = utils.Marshal(req.Value, json.Marshal)
@@ -576,11 +741,19 @@ func (s *SQLServer) executeSet(db dbExecutor, req *state.SetRequest) error {
 		etag = sql.Named(rowVersionColumnName, b)
 	}
 
+	// A ttl of 0 means the value never expires.
+	ttl := 0
+	if t, err := utils.ParseTTL(req.Metadata); err != nil {
+		return fmt.Errorf("error parsing TTL: %w", err)
+	} else if t != nil {
+		ttl = *t
+	}
+
 	var res sql.Result
 	if req.Options.Concurrency == state.FirstWrite {
-		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 1))
+		res, err = db.ExecContext(ctx, s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 1), sql.Named("TTL", ttl))
 	} else {
-		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 0))
+		res, err = db.ExecContext(ctx, s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 0), sql.Named("TTL", ttl))
 	}
 
 	if err != nil {
@@ -605,13 +778,18 @@ func (s *SQLServer) executeSet(db dbExecutor, req *state.SetRequest) error {
 
 // BulkSet adds/updates multiple entities on store.
 func (s *SQLServer) BulkSet(req []state.SetRequest) error {
-	tx, err := s.db.Begin()
+	return s.BulkSetWithContext(context.Background(), req)
+}
+
+// BulkSetWithContext adds/updates multiple entities on store, honoring the cancellation of ctx.
+func (s *SQLServer) BulkSetWithContext(ctx context.Context, req []state.SetRequest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
 	for i := range req {
-		err = s.executeSet(tx, &req[i])
+		err = s.executeSet(ctx, tx, &req[i])
 		if err != nil {
 			tx.Rollback()
 
@@ -623,3 +801,46 @@ func (s *SQLServer) BulkSet(req []state.SetRequest) error {
 
 	return err
 }
+
+// startCleanupThread periodically deletes the rows whose ttl has elapsed until the store is closed.
+func (s *SQLServer) startCleanupThread() {
+	ticker := time.NewTicker(s.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.deleteExpiredValues(s.ctx); err != nil {
+				s.logger.Errorf("error deleting expired values from SQL Server: %s", err)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SQLServer) deleteExpiredValues(ctx context.Context) error {
+	res, err := s.db.ExecContext(ctx, s.deleteExpiredCommand)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		s.logger.Debugf("Deleted %d expired values from SQL Server", n)
+	}
+
+	return nil
+}
+
+// Close implements io.Closer.
+func (s *SQLServer) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+
+	return nil
+}
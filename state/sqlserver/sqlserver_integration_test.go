@@ -13,6 +13,7 @@ limitations under the License.
 package sqlserver
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -75,6 +76,9 @@ func TestIntegrationCases(t *testing.T) {
 	t.Run("Multi operations", testMultiOperations)
 	t.Run("Bulk sets", testBulkSet)
 	t.Run("Bulk delete", testBulkDelete)
+	t.Run("Bulk get", testBulkGet)
+	t.Run("List keys", testListKeys)
+	t.Run("Set with ttl", testSetWithTTL)
 	t.Run("Insert and Update Set Record Dates", testInsertAndUpdateSetRecordDates)
 	t.Run("Multiple initializations", testMultipleInitializations)
 
@@ -587,6 +591,105 @@ func testBulkSet(t *testing.T) {
 	}
 }
 
+func testListKeys(t *testing.T) {
+	store := getTestStore(t, "")
+
+	prefix := uuid.New().String() + "||"
+	keys := []string{prefix + "a", prefix + "b", prefix + "c"}
+	sets := make([]state.SetRequest, len(keys))
+	for i, key := range keys {
+		sets[i] = state.SetRequest{Key: key, Value: user{key, "John", "Coffee"}}
+	}
+	err := store.BulkSet(append(sets, state.SetRequest{Key: uuid.New().String(), Value: user{"", "Laura", "Water"}}))
+	assert.Nil(t, err)
+
+	res, err := store.ListKeys(&state.ListKeysRequest{Prefix: prefix, Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, keys[:2], res.Keys)
+	assert.Equal(t, keys[1], res.Token)
+
+	res, err = store.ListKeys(&state.ListKeysRequest{Prefix: prefix, Limit: 2, Token: res.Token})
+	assert.Nil(t, err)
+	assert.Equal(t, keys[2:], res.Keys)
+	assert.Empty(t, res.Token)
+}
+
+func testSetWithTTL(t *testing.T) {
+	store := getTestStore(t, "")
+
+	u := user{uuid.New().String(), "John", "Coffee"}
+	err := store.Set(&state.SetRequest{Key: u.ID, Value: u, Metadata: map[string]string{"ttlInSeconds": "1"}})
+	assert.Nil(t, err)
+	assertUserExists(t, store, u.ID)
+
+	time.Sleep(2 * time.Second)
+	assertUserDoesNotExist(t, store, u.ID)
+
+	err = store.deleteExpiredValues(context.Background())
+	assert.Nil(t, err)
+	assertDBQuery(t, store, fmt.Sprintf("SELECT COUNT(*) FROM [%s].[%s] WHERE [Key]='%s'", store.schema, store.tableName, u.ID), func(t *testing.T, rows *sql.Rows) {
+		assert.True(t, rows.Next())
+
+		var count int
+		assert.Nil(t, rows.Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+}
+
+func testBulkGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		kt     KeyType
+		keyGen userKeyGenerator
+	}{
+		{"Bulk get string key type", StringKeyType, &numbericKeyGenerator{}},
+		{"Bulk get integer key type", IntegerKeyType, &numbericKeyGenerator{}},
+		{"Bulk get uuid key type", UUIDKeyType, &uuidKeyGenerator{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := getTestStoreWithKeyType(t, test.kt, "")
+			keyGen := test.keyGen
+
+			users := []user{
+				{keyGen.NextKey(), "John", "Coffee"},
+				{keyGen.NextKey(), "Laura", "Water"},
+			}
+			sets := make([]state.SetRequest, len(users))
+			for i, u := range users {
+				sets[i] = state.SetRequest{Key: u.ID, Value: u}
+			}
+			err := store.BulkSet(sets)
+			assert.Nil(t, err)
+
+			missingKey := keyGen.NextKey()
+			supported, res, err := store.BulkGet([]state.GetRequest{
+				{Key: users[1].ID},
+				{Key: missingKey},
+				{Key: users[0].ID},
+			})
+			assert.Nil(t, err)
+			assert.True(t, supported)
+			require.Len(t, res, 3)
+
+			assert.Equal(t, users[1].ID, res[0].Key)
+			assert.NotNil(t, res[0].ETag)
+			var loaded user
+			assert.Nil(t, json.Unmarshal(res[0].Data, &loaded))
+			assert.Equal(t, users[1], loaded)
+
+			assert.Equal(t, missingKey, res[1].Key)
+			assert.Nil(t, res[1].Data)
+			assert.Nil(t, res[1].ETag)
+
+			assert.Equal(t, users[0].ID, res[2].Key)
+			assert.Nil(t, json.Unmarshal(res[2].Data, &loaded))
+			assert.Equal(t, users[0], loaded)
+		})
+	}
+}
+
 func testBulkDelete(t *testing.T) {
 	tests := []struct {
 		name   string
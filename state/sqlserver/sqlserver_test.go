@@ -321,6 +321,11 @@ func TestInvalidConfiguration(t *testing.T) {
 			props:       map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: "test", keyTypeKey: "invalid"},
 			expectedErr: "invalid key type",
 		},
+		{
+			name:        "Invalid cleanup interval",
+			props:       map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: "test", cleanupIntervalKey: "1 hour"},
+			expectedErr: "invalid cleanupInterval",
+		},
 	}
 
 	for _, tt := range tests {
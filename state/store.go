@@ -14,6 +14,7 @@ limitations under the License.
 package state
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/dapr/components-contrib/health"
@@ -94,7 +95,68 @@ func (b *DefaultBulkStore) BulkDelete(req []DeleteRequest) error {
 	return nil
 }
 
+// BulkGetWithContext performs a bulks get operations.
+func (b *DefaultBulkStore) BulkGetWithContext(ctx context.Context, req []GetRequest) (bool, []BulkGetResponse, error) {
+	// by default, the store doesn't support bulk get
+	// return false so daprd will fallback to call get() method one by one
+	return false, nil, nil
+}
+
+// BulkSetWithContext performs a bulks save operation.
+func (b *DefaultBulkStore) BulkSetWithContext(ctx context.Context, req []SetRequest) error {
+	s := NewStoreWithContext(b.s)
+	for i := range req {
+		err := s.SetWithContext(ctx, &req[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BulkDeleteWithContext performs a bulk delete operation.
+func (b *DefaultBulkStore) BulkDeleteWithContext(ctx context.Context, req []DeleteRequest) error {
+	s := NewStoreWithContext(b.s)
+	for i := range req {
+		err := s.DeleteWithContext(ctx, &req[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Querier is an interface to execute queries.
 type Querier interface {
 	Query(req *QueryRequest) (*QueryResponse, error)
 }
+
+// KeyLister is an interface to enumerate the keys of a state store.
+// The keys are listed in pages of at most Limit keys, in a stable order so
+// that the token of a page resumes the listing after its last key.
+type KeyLister interface {
+	ListKeys(req *ListKeysRequest) (*ListKeysResponse, error)
+}
+
+// NewListKeysResponse builds a page of at most limit keys from keys sorted in lexical order.
+// Stores read one key more than the limit to know whether there is a next page, in which
+// case the last key of the page is returned as the token.
+func NewListKeysResponse(keys []string, limit int) *ListKeysResponse {
+	if limit > 0 && len(keys) > limit {
+		return &ListKeysResponse{Keys: keys[:limit], Token: keys[limit-1]}
+	}
+
+	return &ListKeysResponse{Keys: keys}
+}
+
+// Watcher is an interface to subscribe to the changes of state.
+type Watcher interface {
+	// Watch delivers the changes of the keys starting with req.KeyPrefix to the handler
+	// until ctx is canceled.
+	Watch(ctx context.Context, req *WatchRequest, handler WatchHandler) error
+}
+
+// WatchHandler is the handler used to deliver state change events.
+type WatchHandler func(ctx context.Context, e *WatchEvent) error
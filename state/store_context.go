@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import "context"
+
+// StoreWithContext is a Store whose operations accept a context.Context
+// which can be used to cancel the operation or propagate deadlines.
+type StoreWithContext interface {
+	Store
+	BulkStoreWithContext
+	DeleteWithContext(ctx context.Context, req *DeleteRequest) error
+	GetWithContext(ctx context.Context, req *GetRequest) (*GetResponse, error)
+	SetWithContext(ctx context.Context, req *SetRequest) error
+}
+
+// BulkStoreWithContext is the context-aware variant of BulkStore.
+type BulkStoreWithContext interface {
+	BulkDeleteWithContext(ctx context.Context, req []DeleteRequest) error
+	BulkGetWithContext(ctx context.Context, req []GetRequest) (bool, []BulkGetResponse, error)
+	BulkSetWithContext(ctx context.Context, req []SetRequest) error
+}
+
+// TransactionalStoreWithContext is the context-aware variant of TransactionalStore.
+type TransactionalStoreWithContext interface {
+	TransactionalStore
+	MultiWithContext(ctx context.Context, request *TransactionalStateRequest) error
+}
+
+// QuerierWithContext is the context-aware variant of Querier.
+type QuerierWithContext interface {
+	Querier
+	QueryWithContext(ctx context.Context, req *QueryRequest) (*QueryResponse, error)
+}
+
+// NewStoreWithContext returns the store itself if it natively supports contexts,
+// otherwise it wraps the store in an adapter. The adapter cannot interrupt an
+// operation which has already been sent to the underlying store, so it only
+// checks the context before each call.
+func NewStoreWithContext(store Store) StoreWithContext {
+	if s, ok := store.(StoreWithContext); ok {
+		return s
+	}
+
+	return &storeWithContext{Store: store}
+}
+
+// NewTransactionalStoreWithContext returns the store itself if it natively supports contexts,
+// otherwise it wraps the store in an adapter which checks the context before each call.
+func NewTransactionalStoreWithContext(store TransactionalStore) TransactionalStoreWithContext {
+	if s, ok := store.(TransactionalStoreWithContext); ok {
+		return s
+	}
+
+	return &transactionalStoreWithContext{TransactionalStore: store}
+}
+
+// NewQuerierWithContext returns the querier itself if it natively supports contexts,
+// otherwise it wraps the querier in an adapter which checks the context before each call.
+func NewQuerierWithContext(querier Querier) QuerierWithContext {
+	if q, ok := querier.(QuerierWithContext); ok {
+		return q
+	}
+
+	return &querierWithContext{Querier: querier}
+}
+
+// storeWithContext adapts a Store to the StoreWithContext interface.
+type storeWithContext struct {
+	Store
+}
+
+func (s *storeWithContext) DeleteWithContext(ctx context.Context, req *DeleteRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.Delete(req)
+}
+
+func (s *storeWithContext) GetWithContext(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(req)
+}
+
+func (s *storeWithContext) SetWithContext(ctx context.Context, req *SetRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.Set(req)
+}
+
+func (s *storeWithContext) BulkDeleteWithContext(ctx context.Context, req []DeleteRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.BulkDelete(req)
+}
+
+func (s *storeWithContext) BulkGetWithContext(ctx context.Context, req []GetRequest) (bool, []BulkGetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	return s.BulkGet(req)
+}
+
+func (s *storeWithContext) BulkSetWithContext(ctx context.Context, req []SetRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.BulkSet(req)
+}
+
+// transactionalStoreWithContext adapts a TransactionalStore to the TransactionalStoreWithContext interface.
+type transactionalStoreWithContext struct {
+	TransactionalStore
+}
+
+func (t *transactionalStoreWithContext) MultiWithContext(ctx context.Context, request *TransactionalStateRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return t.Multi(request)
+}
+
+// querierWithContext adapts a Querier to the QuerierWithContext interface.
+type querierWithContext struct {
+	Querier
+}
+
+func (q *querierWithContext) QueryWithContext(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return q.Query(req)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreWithContext_adapter(t *testing.T) {
+	s := &Store2{}
+	store := NewStoreWithContext(s)
+
+	t.Run("forwards calls with a live context", func(t *testing.T) {
+		ctx := context.Background()
+		_, err := store.GetWithContext(ctx, &GetRequest{})
+		require.NoError(t, err)
+		require.NoError(t, store.SetWithContext(ctx, &SetRequest{}))
+		require.NoError(t, store.DeleteWithContext(ctx, &DeleteRequest{}))
+		require.Equal(t, 3, s.count)
+
+		require.NoError(t, store.BulkSetWithContext(ctx, []SetRequest{{}, {}}))
+		require.NoError(t, store.BulkDeleteWithContext(ctx, []DeleteRequest{{}, {}}))
+		require.Equal(t, 2, s.bulkCount)
+	})
+
+	t.Run("does not call the store with a canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := store.GetWithContext(ctx, &GetRequest{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, store.SetWithContext(ctx, &SetRequest{}), context.Canceled)
+		require.ErrorIs(t, store.DeleteWithContext(ctx, &DeleteRequest{}), context.Canceled)
+		require.ErrorIs(t, store.BulkSetWithContext(ctx, []SetRequest{{}}), context.Canceled)
+		require.Equal(t, 3, s.count)
+		require.Equal(t, 2, s.bulkCount)
+	})
+}
+
+func TestDefaultBulkStore_withContext(t *testing.T) {
+	s := &Store1{}
+	s.DefaultBulkStore = NewDefaultBulkStore(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, s.BulkSetWithContext(ctx, []SetRequest{{}, {}, {}}))
+	require.NoError(t, s.BulkDeleteWithContext(ctx, []DeleteRequest{{}, {}}))
+	require.Equal(t, 5, s.count)
+
+	cancel()
+	require.ErrorIs(t, s.BulkSetWithContext(ctx, []SetRequest{{}}), context.Canceled)
+	require.Equal(t, 5, s.count)
+}
@@ -46,6 +46,20 @@ func TestStore_withDefaultBulkImpl(t *testing.T) {
 	require.Equal(t, 0, s.bulkCount)
 }
 
+func TestNewListKeysResponse(t *testing.T) {
+	resp := NewListKeysResponse([]string{"a", "b", "c"}, 2)
+	require.Equal(t, []string{"a", "b"}, resp.Keys)
+	require.Equal(t, "b", resp.Token)
+
+	resp = NewListKeysResponse([]string{"a", "b"}, 2)
+	require.Equal(t, []string{"a", "b"}, resp.Keys)
+	require.Empty(t, resp.Token)
+
+	resp = NewListKeysResponse([]string{"a", "b", "c"}, 0)
+	require.Equal(t, []string{"a", "b", "c"}, resp.Keys)
+	require.Empty(t, resp.Token)
+}
+
 func TestStore_withCustomisedBulkImpl_notSupportBulkGet(t *testing.T) {
 	s := &Store2{supportBulkGet: false}
 	var store Store = s
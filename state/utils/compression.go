@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// CompressionKey is the component metadata key of the algorithm used to
+	// compress the values. Values are not compressed if it is not set.
+	CompressionKey = "compression"
+
+	// CompressionGzip compresses the values with gzip.
+	CompressionGzip = "gzip"
+	// CompressionZstd compresses the values with zstd.
+	CompressionZstd = "zstd"
+)
+
+const (
+	gzipID byte = 'g'
+	zstdID byte = 'z'
+)
+
+// compressionMarker prefixes the compressed values, followed by the identifier
+// of the algorithm. JSON documents never start with a NUL byte, so values
+// saved before compression was enabled are read unchanged.
+var compressionMarker = []byte{0x00, 'd', 'c'}
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+)
+
+// Compressor compresses the values of a state store. A nil Compressor
+// leaves the values uncompressed.
+type Compressor struct {
+	id          byte
+	zstdEncoder *zstd.Encoder
+}
+
+// NewCompressor returns the Compressor configured in the component metadata,
+// or nil if compression is not enabled.
+func NewCompressor(properties map[string]string) (*Compressor, error) {
+	switch algorithm := strings.ToLower(properties[CompressionKey]); algorithm {
+	case "", "none":
+		return nil, nil
+	case CompressionGzip:
+		return &Compressor{id: gzipID}, nil
+	case CompressionZstd:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Compressor{id: zstdID, zstdEncoder: encoder}, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s algorithm %q", CompressionKey, algorithm)
+	}
+}
+
+// Marshal marshals the value like Marshal, and compresses the result.
+func (c *Compressor) Marshal(val interface{}, marshaler func(interface{}) ([]byte, error)) ([]byte, error) {
+	bt, err := Marshal(val, marshaler)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Compress(bt)
+}
+
+// Compress returns the compressed data prefixed with the compression marker.
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	header := make([]byte, 0, len(compressionMarker)+1)
+	header = append(header, compressionMarker...)
+	header = append(header, c.id)
+
+	if c.id == zstdID {
+		return c.zstdEncoder.EncodeAll(data, header), nil
+	}
+
+	buf := bytes.NewBuffer(header)
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// IsCompressed reports whether data starts with the compression marker.
+func IsCompressed(data []byte) bool {
+	return len(data) > len(compressionMarker) && bytes.HasPrefix(data, compressionMarker)
+}
+
+// Decompress returns the original data of a value compressed by a Compressor.
+// It does not depend on the configured compression, so that the values saved
+// while compression was enabled remain readable once it is disabled. Values
+// without the compression marker, e.g. saved before compression was enabled,
+// are returned unchanged.
+func Decompress(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	payload := data[len(compressionMarker)+1:]
+	switch data[len(compressionMarker)] {
+	case gzipID:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing value: %w", err)
+		}
+		defer r.Close()
+
+		return io.ReadAll(r)
+	case zstdID:
+		zstdDecoderOnce.Do(func() {
+			zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+		})
+		if zstdDecoderErr != nil {
+			return nil, zstdDecoderErr
+		}
+
+		return zstdDecoder.DecodeAll(payload, nil)
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm %q", data[len(compressionMarker)])
+	}
+}
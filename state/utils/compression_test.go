@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCompressor(t *testing.T) {
+	c, err := NewCompressor(map[string]string{})
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = NewCompressor(map[string]string{CompressionKey: "none"})
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	_, err = NewCompressor(map[string]string{CompressionKey: "lz4"})
+	assert.Error(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	value := map[string]string{"description": strings.Repeat("dapr ", 200)}
+	expected, _ := json.Marshal(value)
+
+	for _, algorithm := range []string{CompressionGzip, CompressionZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			c, err := NewCompressor(map[string]string{CompressionKey: algorithm})
+			require.NoError(t, err)
+
+			data, err := c.Marshal(value, json.Marshal)
+			require.NoError(t, err)
+			assert.True(t, IsCompressed(data))
+			assert.Less(t, len(data), len(expected))
+
+			actual, err := Decompress(data)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("nil compressor", func(t *testing.T) {
+		var c *Compressor
+		data, err := c.Marshal(value, json.Marshal)
+		require.NoError(t, err)
+		assert.Equal(t, expected, data)
+	})
+
+	t.Run("compressed values are read once compression is disabled", func(t *testing.T) {
+		c, err := NewCompressor(map[string]string{CompressionKey: CompressionZstd})
+		require.NoError(t, err)
+		data, err := c.Marshal(value, json.Marshal)
+		require.NoError(t, err)
+
+		actual, err := Decompress(data)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("uncompressed values are read unchanged", func(t *testing.T) {
+		actual, err := Decompress(expected)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+
+		actual, err = Decompress(nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("corrupted value", func(t *testing.T) {
+		_, err := Decompress(append(append([]byte{}, compressionMarker...), gzipID, 1, 2, 3))
+		assert.Error(t, err)
+	})
+}
@@ -13,6 +13,20 @@ limitations under the License.
 
 package utils
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func Marshal(val interface{}, marshaler func(interface{}) ([]byte, error)) ([]byte, error) {
 	var err error = nil
 	bt, ok := val.([]byte)
@@ -22,3 +36,74 @@ func Marshal(val interface{}, marshaler func(interface{}) ([]byte, error)) ([]by
 
 	return bt, err
 }
+
+// DecodeValue returns the raw bytes of a value read from the state table of a
+// SQL store. Binary values are stored as a JSON string holding their base64 encoding.
+func DecodeValue(value string, isBinary bool) ([]byte, error) {
+	if !isBinary {
+		return []byte(value), nil
+	}
+
+	var s string
+	if err := json.Unmarshal([]byte(value), &s); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// LikePrefixPattern returns a SQL LIKE pattern which matches the strings
+// starting with prefix. Wildcards in prefix are escaped with a backslash.
+func LikePrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// ParseTTL returns the time to live in seconds set in the request metadata,
+// or nil if the value never expires. A value of -1 or 0 means that the value
+// never expires.
+func ParseTTL(requestMetadata map[string]string) (*int, error) {
+	val, ok := requestMetadata[metadata.TTLMetadataKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	ttl, err := strconv.Atoi(val)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", metadata.TTLMetadataKey, err)
+	}
+	if ttl < -1 {
+		return nil, fmt.Errorf("incorrect value for %s: %d", metadata.TTLMetadataKey, ttl)
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	return &ttl, nil
+}
+
+// WithTTLMetadata returns a copy of the response metadata holding the remaining
+// time to live of a value, in seconds rounded up, so that it can be saved again
+// with the same expiration. The metadata is returned unchanged if ttl is not positive.
+func WithTTLMetadata(responseMetadata map[string]string, ttl time.Duration) map[string]string {
+	if ttl <= 0 {
+		return responseMetadata
+	}
+
+	md := make(map[string]string, len(responseMetadata)+1)
+	for k, v := range responseMetadata {
+		md[k] = v
+	}
+	md[metadata.TTLMetadataKey] = strconv.FormatInt(int64((ttl+time.Second-1)/time.Second), 10)
+
+	return md
+}
+
+// ValueETag returns an ETag derived from the content of a value, for the state
+// stores which cannot expose a version of their values. The stores using it
+// must guard their conditional writes with an atomic compare-and-swap.
+func ValueETag(data []byte) string {
+	h := fnv.New64a()
+	h.Write(data)
+
+	return strconv.FormatUint(h.Sum64(), 16)
+}
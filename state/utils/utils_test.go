@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeValue(t *testing.T) {
+	data, err := DecodeValue(`{"a":1}`, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), data)
+
+	data, err = DecodeValue(`"aGVsbG8="`, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	_, err = DecodeValue(`"not base64!"`, true)
+	assert.Error(t, err)
+}
+
+func TestLikePrefixPattern(t *testing.T) {
+	assert.Equal(t, "%", LikePrefixPattern(""))
+	assert.Equal(t, "myapp||%", LikePrefixPattern("myapp||"))
+	assert.Equal(t, `a\%b\_c\\%`, LikePrefixPattern(`a%b_c\`))
+}
+
+func TestParseTTL(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		ttl, err := ParseTTL(map[string]string{})
+		assert.NoError(t, err)
+		assert.Nil(t, ttl)
+	})
+
+	t.Run("positive", func(t *testing.T) {
+		ttl, err := ParseTTL(map[string]string{"ttlInSeconds": "60"})
+		assert.NoError(t, err)
+		assert.Equal(t, 60, *ttl)
+	})
+
+	t.Run("never expires", func(t *testing.T) {
+		for _, val := range []string{"-1", "0"} {
+			ttl, err := ParseTTL(map[string]string{"ttlInSeconds": val})
+			assert.NoError(t, err)
+			assert.Nil(t, ttl)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, val := range []string{"-2", "abc"} {
+			_, err := ParseTTL(map[string]string{"ttlInSeconds": val})
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestWithTTLMetadata(t *testing.T) {
+	md := map[string]string{"contentType": "application/json"}
+
+	assert.Equal(t, md, WithTTLMetadata(md, 0))
+	assert.Equal(t, map[string]string{"contentType": "application/json", "ttlInSeconds": "60"}, WithTTLMetadata(md, time.Minute))
+	assert.Equal(t, map[string]string{"ttlInSeconds": "2"}, WithTTLMetadata(nil, 1500*time.Millisecond))
+	assert.Len(t, md, 1)
+}
+
+func TestValueETag(t *testing.T) {
+	etag := ValueETag([]byte("value"))
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, etag, ValueETag([]byte("value")))
+	assert.NotEqual(t, etag, ValueETag([]byte("other value")))
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const (
@@ -117,7 +118,8 @@ type Conn interface {
 // StateStore is a state store.
 type StateStore struct {
 	*config
-	conn Conn
+	conn        Conn
+	retryConfig retry.Config
 
 	features []state.Feature
 	logger   logger.Logger
@@ -143,6 +145,10 @@ func (s *StateStore) Init(metadata state.Metadata) (err error) {
 		return
 	}
 
+	if s.retryConfig, err = state.NewRetryConfig(metadata.Properties); err != nil {
+		return
+	}
+
 	conn, _, err := zk.Connect(c.servers, c.sessionTimeout,
 		zk.WithMaxBufferSize(c.maxBufferSize), zk.WithMaxConnBufferSize(c.maxConnBufferSize))
 	if err != nil {
@@ -190,22 +196,21 @@ func (s *StateStore) Delete(req *state.DeleteRequest) error {
 		return err
 	}
 
-	return state.DeleteWithOptions(func(req *state.DeleteRequest) error {
+	return state.DeleteWithRetries(func(req *state.DeleteRequest) error {
 		err := s.conn.Delete(r.Path, r.Version)
-		if errors.Is(err, zk.ErrNoNode) {
-			return nil
-		}
-
 		if err != nil {
-			if req.ETag != nil {
+			if r.Version != anyVersion && (errors.Is(err, zk.ErrNoNode) || errors.Is(err, zk.ErrBadVersion)) {
 				return state.NewETagError(state.ETagMismatch, err)
 			}
+			if errors.Is(err, zk.ErrNoNode) {
+				return nil
+			}
 
 			return err
 		}
 
 		return nil
-	}, req)
+	}, req, s.retryConfig)
 }
 
 // BulkDelete performs a bulk delete operation.
@@ -226,10 +231,14 @@ func (s *StateStore) BulkDelete(reqs []state.DeleteRequest) error {
 		return err
 	}
 
-	for _, res := range res {
-		if res.Error != nil && !errors.Is(res.Error, zk.ErrNoNode) {
-			err = multierror.Append(err, res.Error)
+	for i, res := range res {
+		if res.Error == nil {
+			continue
+		}
+		if errors.Is(res.Error, zk.ErrNoNode) && ops[i].(*zk.DeleteRequest).Version == anyVersion {
+			continue
 		}
+		err = multierror.Append(err, multiOpError(ops[i], res.Error))
 	}
 
 	return err
@@ -242,23 +251,31 @@ func (s *StateStore) Set(req *state.SetRequest) error {
 		return err
 	}
 
-	return state.SetWithOptions(func(req *state.SetRequest) error {
-		_, err = s.conn.Set(r.Path, r.Data, r.Version)
+	return state.SetWithRetries(func(req *state.SetRequest) error {
+		if isCreateOnly(req, r) {
+			_, err := s.conn.Create(r.Path, r.Data, 0, nil)
+			if errors.Is(err, zk.ErrNodeExists) {
+				return state.NewETagError(state.ETagMismatch, err)
+			}
 
-		if errors.Is(err, zk.ErrNoNode) {
-			_, err = s.conn.Create(r.Path, r.Data, 0, nil)
+			return err
 		}
 
-		if err != nil {
-			if req.ETag != nil {
+		_, err := s.conn.Set(r.Path, r.Data, r.Version)
+		if r.Version != anyVersion {
+			if errors.Is(err, zk.ErrNoNode) || errors.Is(err, zk.ErrBadVersion) {
 				return state.NewETagError(state.ETagMismatch, err)
 			}
 
 			return err
 		}
 
-		return nil
-	}, req)
+		if errors.Is(err, zk.ErrNoNode) {
+			_, err = s.conn.Create(r.Path, r.Data, 0, nil)
+		}
+
+		return err
+	}, req, s.retryConfig)
 }
 
 // BulkSet performs a bulks save operation.
@@ -270,7 +287,11 @@ func (s *StateStore) BulkSet(reqs []state.SetRequest) error {
 		if err != nil {
 			return err
 		}
-		ops = append(ops, req)
+		if isCreateOnly(&reqs[i], req) {
+			ops = append(ops, s.newCreateRequest(req))
+		} else {
+			ops = append(ops, req)
+		}
 	}
 
 	for {
@@ -284,14 +305,14 @@ func (s *StateStore) BulkSet(reqs []state.SetRequest) error {
 		for i, res := range res {
 			if res.Error != nil {
 				if errors.Is(res.Error, zk.ErrNoNode) {
-					if req, ok := ops[i].(*zk.SetDataRequest); ok {
+					if req, ok := ops[i].(*zk.SetDataRequest); ok && req.Version == anyVersion {
 						retry = append(retry, s.newCreateRequest(req))
 
 						continue
 					}
 				}
 
-				err = multierror.Append(err, res.Error)
+				err = multierror.Append(err, multiOpError(ops[i], res.Error))
 			}
 		}
 
@@ -303,6 +324,28 @@ func (s *StateStore) BulkSet(reqs []state.SetRequest) error {
 	}
 }
 
+// multiOpError returns the error of an operation of a multi request, as an
+// ETag mismatch error if the version of the node differs from the one of op
+// or, for the first-write creations, if the node exists.
+func multiOpError(op interface{}, err error) error {
+	switch op := op.(type) {
+	case *zk.CreateRequest:
+		if errors.Is(err, zk.ErrNodeExists) {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+	case *zk.SetDataRequest:
+		if op.Version != anyVersion && (errors.Is(err, zk.ErrNoNode) || errors.Is(err, zk.ErrBadVersion)) {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+	case *zk.DeleteRequest:
+		if op.Version != anyVersion && (errors.Is(err, zk.ErrNoNode) || errors.Is(err, zk.ErrBadVersion)) {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+	}
+
+	return err
+}
+
 func (s *StateStore) newCreateRequest(req *zk.SetDataRequest) *zk.CreateRequest {
 	return &zk.CreateRequest{Path: req.Path, Data: req.Data}
 }
@@ -313,17 +356,12 @@ func (s *StateStore) newDeleteRequest(req *state.DeleteRequest) (*zk.DeleteReque
 		return nil, err
 	}
 
-	var version int32
-
-	if req.Options.Concurrency == state.LastWrite {
-		version = anyVersion
-	} else {
-		var etag string
+	version := int32(anyVersion)
 
-		if req.ETag != nil {
-			etag = *req.ETag
+	if req.Options.Concurrency != state.LastWrite {
+		if version, err = s.parseETag(req.ETag); err != nil {
+			return nil, err
 		}
-		version = s.parseETag(etag)
 	}
 
 	return &zk.DeleteRequest{
@@ -343,17 +381,12 @@ func (s *StateStore) newSetDataRequest(req *state.SetRequest) (*zk.SetDataReques
 		return nil, err
 	}
 
-	var version int32
+	version := int32(anyVersion)
 
-	if req.Options.Concurrency == state.LastWrite {
-		version = anyVersion
-	} else {
-		var etag string
-
-		if req.ETag != nil {
-			etag = *req.ETag
+	if req.Options.Concurrency != state.LastWrite {
+		if version, err = s.parseETag(req.ETag); err != nil {
+			return nil, err
 		}
-		version = s.parseETag(etag)
 	}
 
 	return &zk.SetDataRequest{
@@ -371,16 +404,25 @@ func (s *StateStore) prefixedKey(key string) string {
 	return path.Join(s.keyPrefixPath, key)
 }
 
-func (s *StateStore) parseETag(etag string) int32 {
-	if etag != "" {
-		// Since the version is taken to be int32
-		version, err := strconv.ParseInt(etag, 10, 32)
-		if err == nil {
-			return int32(version)
-		}
+// parseETag returns the znode version held by etag, or anyVersion if etag is empty.
+func (s *StateStore) parseETag(etag *string) (int32, error) {
+	if etag == nil || *etag == "" {
+		return anyVersion, nil
 	}
 
-	return anyVersion
+	// Since the version is taken to be int32
+	version, err := strconv.ParseInt(*etag, 10, 32)
+	if err != nil || version < 0 {
+		return 0, state.NewETagError(state.ETagInvalid, err)
+	}
+
+	return int32(version), nil
+}
+
+// isCreateOnly returns true if the first-write request has no etag, so that
+// it must only succeed if the znode does not exist yet.
+func isCreateOnly(req *state.SetRequest, r *zk.SetDataRequest) bool {
+	return req.Options.Concurrency == state.FirstWrite && r.Version == anyVersion
 }
 
 func (s *StateStore) marshalData(v interface{}) ([]byte, error) {
@@ -143,6 +143,33 @@ func TestDelete(t *testing.T) {
 		err := s.Delete(&state.DeleteRequest{Key: "foo"})
 		assert.NoError(t, err, "Delete must be successful")
 	})
+
+	t.Run("With version and NoNode error", func(t *testing.T) {
+		conn.EXPECT().Delete("foo", int32(123)).Return(zk.ErrNoNode).Times(1)
+
+		err := s.Delete(&state.DeleteRequest{Key: "foo", ETag: &etag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("With version mismatch", func(t *testing.T) {
+		conn.EXPECT().Delete("foo", int32(123)).Return(zk.ErrBadVersion).Times(1)
+
+		err := s.Delete(&state.DeleteRequest{Key: "foo", ETag: &etag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("With invalid etag", func(t *testing.T) {
+		invalidEtag := "not-an-etag"
+
+		err := s.Delete(&state.DeleteRequest{Key: "foo", ETag: &invalidEtag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+	})
 }
 
 // BulkDelete.
@@ -185,6 +212,20 @@ func TestBulkDelete(t *testing.T) {
 		err := s.BulkDelete([]state.DeleteRequest{{Key: "foo"}, {Key: "bar"}})
 		assert.NoError(t, err, "Key must be exists")
 	})
+	t.Run("With version mismatch", func(t *testing.T) {
+		etag := "123"
+		conn.EXPECT().Multi([]interface{}{
+			&zk.DeleteRequest{Path: "foo", Version: int32(123)},
+			&zk.DeleteRequest{Path: "bar", Version: int32(anyVersion)},
+		}).Return([]zk.MultiResponse{
+			{Error: zk.ErrBadVersion}, {},
+		}, nil).Times(1)
+
+		err := s.BulkDelete([]state.DeleteRequest{{Key: "foo", ETag: &etag}, {Key: "bar"}})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
 }
 
 // Set.
@@ -235,6 +276,52 @@ func TestSet(t *testing.T) {
 		err := s.Set(&state.SetRequest{Key: "foo", Value: "bar"})
 		assert.NoError(t, err, "Key must be create")
 	})
+	t.Run("With version and NoNode error", func(t *testing.T) {
+		conn.EXPECT().Set("foo", []byte("\"bar\""), int32(123)).Return(nil, zk.ErrNoNode).Times(1)
+
+		err := s.Set(&state.SetRequest{Key: "foo", Value: "bar", ETag: &etag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+	t.Run("With version mismatch", func(t *testing.T) {
+		conn.EXPECT().Set("foo", []byte("\"bar\""), int32(123)).Return(nil, zk.ErrBadVersion).Times(1)
+
+		err := s.Set(&state.SetRequest{Key: "foo", Value: "bar", ETag: &etag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+	t.Run("With invalid etag", func(t *testing.T) {
+		invalidEtag := "not-an-etag"
+
+		err := s.Set(&state.SetRequest{Key: "foo", Value: "bar", ETag: &invalidEtag})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+	})
+	t.Run("With first-write", func(t *testing.T) {
+		conn.EXPECT().Create("foo", []byte("\"bar\""), int32(0), nil).Return("/foo", nil).Times(1)
+
+		err := s.Set(&state.SetRequest{
+			Key:     "foo",
+			Value:   "bar",
+			Options: state.SetStateOption{Concurrency: state.FirstWrite},
+		})
+		assert.NoError(t, err, "Key must be create")
+	})
+	t.Run("With first-write and existing key", func(t *testing.T) {
+		conn.EXPECT().Create("foo", []byte("\"bar\""), int32(0), nil).Return("", zk.ErrNodeExists).Times(1)
+
+		err := s.Set(&state.SetRequest{
+			Key:     "foo",
+			Value:   "bar",
+			Options: state.SetStateOption{Concurrency: state.FirstWrite},
+		})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
 }
 
 // BulkSet.
@@ -289,4 +376,39 @@ func TestBulkSet(t *testing.T) {
 		})
 		assert.NoError(t, err, "Key must be set")
 	})
+	t.Run("With first-write keys", func(t *testing.T) {
+		conn.EXPECT().Multi([]interface{}{
+			&zk.CreateRequest{Path: "foo", Data: []byte("\"bar\"")},
+			&zk.SetDataRequest{Path: "bar", Data: []byte("\"foo\""), Version: int32(anyVersion)},
+		}).Return([]zk.MultiResponse{{}, {}}, nil).Times(1)
+
+		err := s.BulkSet([]state.SetRequest{
+			{Key: "foo", Value: "bar", Options: state.SetStateOption{Concurrency: state.FirstWrite}},
+			{Key: "bar", Value: "foo"},
+		})
+		assert.NoError(t, err, "Key must be set")
+	})
+	t.Run("With first-write and existing key", func(t *testing.T) {
+		conn.EXPECT().Multi([]interface{}{
+			&zk.CreateRequest{Path: "foo", Data: []byte("\"bar\"")},
+		}).Return([]zk.MultiResponse{{Error: zk.ErrNodeExists}}, nil).Times(1)
+
+		err := s.BulkSet([]state.SetRequest{
+			{Key: "foo", Value: "bar", Options: state.SetStateOption{Concurrency: state.FirstWrite}},
+		})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+	t.Run("With version mismatch", func(t *testing.T) {
+		etag := "123"
+		conn.EXPECT().Multi([]interface{}{
+			&zk.SetDataRequest{Path: "foo", Data: []byte("\"bar\""), Version: int32(123)},
+		}).Return([]zk.MultiResponse{{Error: zk.ErrBadVersion}}, nil).Times(1)
+
+		err := s.BulkSet([]state.SetRequest{{Key: "foo", Value: "bar", ETag: &etag}})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
 }
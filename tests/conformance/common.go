@@ -66,11 +66,14 @@ import (
 	s_azuretablestorage "github.com/dapr/components-contrib/state/azure/tablestorage"
 	s_cassandra "github.com/dapr/components-contrib/state/cassandra"
 	s_cockroachdb "github.com/dapr/components-contrib/state/cockroachdb"
+	s_hazelcast "github.com/dapr/components-contrib/state/hazelcast"
+	s_memcached "github.com/dapr/components-contrib/state/memcached"
 	s_mongodb "github.com/dapr/components-contrib/state/mongodb"
 	s_mysql "github.com/dapr/components-contrib/state/mysql"
 	s_postgresql "github.com/dapr/components-contrib/state/postgresql"
 	s_redis "github.com/dapr/components-contrib/state/redis"
 	s_sqlserver "github.com/dapr/components-contrib/state/sqlserver"
+	s_zookeeper "github.com/dapr/components-contrib/state/zookeeper"
 	conf_bindings "github.com/dapr/components-contrib/tests/conformance/bindings"
 	conf_pubsub "github.com/dapr/components-contrib/tests/conformance/pubsub"
 	conf_secret "github.com/dapr/components-contrib/tests/conformance/secretstores"
@@ -427,6 +430,12 @@ func loadStateStore(tc TestComponent) state.Store {
 		store = s_cassandra.NewCassandraStateStore(testLogger)
 	case "cockroachdb":
 		store = s_cockroachdb.New(testLogger)
+	case "memcached":
+		store = s_memcached.NewMemCacheStateStore(testLogger)
+	case "zookeeper":
+		store = s_zookeeper.NewZookeeperStateStore(testLogger)
+	case "hazelcast":
+		store = s_hazelcast.NewHazelcastStore(testLogger)
 	default:
 		return nil
 	}